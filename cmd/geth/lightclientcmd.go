@@ -0,0 +1,153 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var lightClientCommand = cli.Command{
+	Name:      "lightclient",
+	Usage:     "Verify Binance Chain headers and IAVL proofs off-chain",
+	ArgsUsage: "",
+	Category:  "MISCELLANEOUS COMMANDS",
+	Description: `
+The lightclient commands run Binance Chain headers and key/value proofs through
+the same validation as the light client precompiled contracts, without a node.
+They report the validation stage and the reason of any failure.`,
+	Subcommands: []cli.Command{
+		{
+			Action:    utils.MigrateFlags(lightClientVerifyHeader),
+			Name:      "verifyheader",
+			Usage:     "Verify a header against a trusted consensus state",
+			ArgsUsage: "<file>",
+			Description: `
+The file holds either the hex encoded input of the tendermint header validation
+contract, or the JSON arguments of lightclient_verifyHeader:
+
+    {"consensusState": ..., "header": ...}
+
+The consensus state is given in its binary encoding as a hex string, or as a
+JSON object with the chainId, height, appHash, curValidatorSetHash and the
+nextValidatorSet as a list of pubKey and votingPower. The header is given in its
+amino binary encoding as a hex string, or in its amino JSON encoding.
+
+On success the consensus state the header moves to is printed.`,
+		},
+		{
+			Action:    utils.MigrateFlags(lightClientVerifyProof),
+			Name:      "verifyproof",
+			Usage:     "Verify a key/value IAVL merkle proof against an app hash",
+			ArgsUsage: "<file>",
+			Description: `
+The file holds either the hex encoded input of the IAVL merkle proof validation
+contract, or the JSON arguments of lightclient_verifyProof:
+
+    {"storeName": ..., "key": ..., "value": ..., "appHash": ..., "proof": ...}
+
+The key, value, app hash and the protobuf encoded proof are hex strings. An empty
+value verifies the absence of the key.`,
+		},
+	},
+}
+
+// lightClientVerifyHeader verifies a Binance Chain header read from a file and
+// prints the outcome.
+func lightClientVerifyHeader(ctx *cli.Context) error {
+	var args ethapi.LightClientHeaderArgs
+	if input := readLightClientArgs(ctx, &args); input != nil {
+		args = ethapi.LightClientHeaderArgs{Input: input}
+	}
+	result, err := ethapi.NewPublicLightClientAPI().VerifyHeader(args)
+	if err != nil {
+		utils.Fatalf("Failed to verify header: %v", err)
+	}
+	if !result.Valid {
+		printLightClientFailure(result.Stage, result.Error)
+		return errors.New("header verification failed")
+	}
+	cs, err := json.MarshalIndent(result.ConsensusState, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode consensus state: %v", err)
+	}
+	fmt.Printf("Valid:                 true\n")
+	fmt.Printf("Validator set changed: %v\n", result.ValidatorSetChanged)
+	fmt.Printf("Consensus state:       %s\n", cs)
+	fmt.Printf("Output:                %s\n", result.Output)
+	return nil
+}
+
+// lightClientVerifyProof verifies a key/value IAVL merkle proof read from a file
+// and prints the outcome.
+func lightClientVerifyProof(ctx *cli.Context) error {
+	var args ethapi.LightClientProofArgs
+	if input := readLightClientArgs(ctx, &args); input != nil {
+		args = ethapi.LightClientProofArgs{Input: input}
+	}
+	result, err := ethapi.NewPublicLightClientAPI().VerifyProof(args)
+	if err != nil {
+		utils.Fatalf("Failed to verify proof: %v", err)
+	}
+	if !result.Valid {
+		printLightClientFailure(result.Stage, result.Error)
+		return errors.New("proof verification failed")
+	}
+	fmt.Printf("Valid:                 true\n")
+	return nil
+}
+
+// readLightClientArgs reads the file given as the first argument. Hex content is
+// returned as the raw contract input, anything else is decoded into args.
+func readLightClientArgs(ctx *cli.Context, args interface{}) *hexutil.Bytes {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	blob, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read arguments: %v", err)
+	}
+	if text := bytes.TrimSpace(blob); bytes.HasPrefix(text, []byte("0x")) {
+		input, err := hexutil.Decode(string(text))
+		if err != nil {
+			utils.Fatalf("Failed to decode input: %v", err)
+		}
+		return (*hexutil.Bytes)(&input)
+	}
+	if err := json.Unmarshal(blob, args); err != nil {
+		utils.Fatalf("Failed to decode arguments: %v", err)
+	}
+	return nil
+}
+
+// printLightClientFailure prints the stage and the reason of a failed light
+// client verification.
+func printLightClientFailure(stage, reason string) {
+	fmt.Printf("Valid:                 false\n")
+	if stage != "" {
+		fmt.Printf("Stage:                 %s\n", stage)
+	}
+	fmt.Printf("Error:                 %s\n", reason)
+}
@@ -83,6 +83,8 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolSnapshotFlag,
+		utils.TxPoolSnapshotLimitFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
@@ -90,6 +92,8 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPeerRateFlag,
+		utils.TxPoolSenderRateFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
@@ -111,6 +115,7 @@ var (
 		utils.CacheGCFlag,
 		utils.CacheSnapshotFlag,
 		utils.CacheNoPrefetchFlag,
+		utils.CacheAccessPrefetchFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
@@ -129,6 +134,11 @@ var (
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerOrderingFlag,
+		utils.MinerOrderingBandFlag,
+		utils.MinerOrderingSenderCapFlag,
+		utils.MinerOrderingAllowFlag,
+		utils.MinerOrderingDenyFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -138,11 +148,17 @@ var (
 		utils.DNSDiscoveryFlag,
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
+		utils.DevParliaFlag,
+		utils.DevParliaValidatorsFlag,
+		utils.DevParliaEpochFlag,
+		utils.DevParliaOfflineFlag,
 		utils.LegacyTestnetFlag,
 		utils.RopstenFlag,
 		utils.RinkebyFlag,
 		utils.GoerliFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMProfileFlag,
+		utils.ParallelTxsFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
 		utils.FakePoWFlag,
@@ -214,6 +230,10 @@ func init() {
 		dumpCommand,
 		dumpGenesisCommand,
 		inspectCommand,
+		// See parliacmd.go:
+		verifyParliaHeaderCommand,
+		// See lightclientcmd.go:
+		lightClientCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -260,6 +280,10 @@ func main() {
 // prepare manipulates memory cache allowance and setups metric system.
 // This function should be called before launching devp2p stack.
 func prepare(ctx *cli.Context) {
+	// The Parlia developer network is a flavour of the developer mode
+	if ctx.GlobalBool(utils.DevParliaFlag.Name) && !ctx.GlobalIsSet(utils.DeveloperFlag.Name) {
+		ctx.GlobalSet(utils.DeveloperFlag.Name, "true")
+	}
 	// If we're running a known preset, log it for convenience.
 	switch {
 	case ctx.GlobalIsSet(utils.LegacyTestnetFlag.Name):
@@ -454,6 +478,14 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		}
 		ethereum.TxPool().SetGasPrice(gasprice)
 
+		// Seal with every validator of the Parlia developer network in turn
+		if ctx.GlobalBool(utils.DevParliaFlag.Name) {
+			ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+			validators := utils.MakeDevParliaValidators(ctx, ks)
+			if err := ethereum.RotateValidators(utils.MakeDevParliaOffline(ctx, validators)); err != nil {
+				utils.Fatalf("Failed to rotate validators: %v", err)
+			}
+		}
 		threads := ctx.GlobalInt(utils.MinerLegacyThreadsFlag.Name)
 		if ctx.GlobalIsSet(utils.MinerThreadsFlag.Name) {
 			threads = ctx.GlobalInt(utils.MinerThreadsFlag.Name)
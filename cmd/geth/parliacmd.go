@@ -0,0 +1,146 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/parlia"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var verifyParliaHeaderCommand = cli.Command{
+	Action:    utils.MigrateFlags(verifyParliaHeader),
+	Name:      "verifyheader",
+	Usage:     "Explain why a header passes or fails Parlia verification (connect to node)",
+	ArgsUsage: "<hash|rlpfile> [endpoint]",
+	Flags:     []cli.Flag{utils.DataDirFlag},
+	Category:  "BLOCKCHAIN COMMANDS",
+	Description: `
+The verifyheader command asks a running node to verify a header against the
+Parlia consensus rules step by step, and prints the outcome of every step along
+with the recovered signer, the in-turn validator, the recents window, the
+back-off timing and the expected epoch validators.
+
+The header is either given by its hash, or read from a file holding the RLP
+encoding of the header or of its block, in binary or hex form. The node is
+reached through the given endpoint, or the IPC endpoint of the data directory.`,
+}
+
+// verifyParliaHeader retrieves the Parlia verification report of a header from
+// a running node and prints it.
+func verifyParliaHeader(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	var input []byte
+	if arg := ctx.Args().First(); hashish(arg) {
+		input = common.HexToHash(arg).Bytes()
+	} else {
+		blob, err := ioutil.ReadFile(arg)
+		if err != nil {
+			utils.Fatalf("Failed to read header: %v", err)
+		}
+		if text := bytes.TrimSpace(blob); bytes.HasPrefix(text, []byte("0x")) {
+			if blob, err = hexutil.Decode(string(text)); err != nil {
+				utils.Fatalf("Failed to decode header: %v", err)
+			}
+		}
+		input = blob
+	}
+	endpoint := ctx.Args().Get(1)
+	if endpoint == "" && ctx.GlobalIsSet(utils.DataDirFlag.Name) {
+		endpoint = filepath.Join(ctx.GlobalString(utils.DataDirFlag.Name), "geth.ipc")
+	}
+	client, err := dialRPC(endpoint)
+	if err != nil {
+		utils.Fatalf("Unable to attach to remote geth: %v", err)
+	}
+	defer client.Close()
+
+	var report parlia.HeaderVerificationReport
+	if err := client.Call(&report, "debug_verifyParliaHeader", hexutil.Bytes(input)); err != nil {
+		utils.Fatalf("Failed to verify header: %v", err)
+	}
+	printVerificationReport(&report)
+	return nil
+}
+
+// printVerificationReport prints a Parlia verification report in a human
+// readable form.
+func printVerificationReport(report *parlia.HeaderVerificationReport) {
+	fmt.Printf("Header #%d [%x]\n", report.Number, report.Hash)
+	fmt.Printf("Parent:      %x\n", report.ParentHash)
+	fmt.Printf("Valid:       %v\n\n", report.Valid)
+
+	for _, step := range report.Steps {
+		if step.Passed {
+			fmt.Printf("  %-22s ok\n", step.Name)
+		} else {
+			fmt.Printf("  %-22s FAILED: %s\n", step.Name, step.Error)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Coinbase:    %x\n", report.Coinbase)
+	if report.Signer != nil {
+		fmt.Printf("Signer:      %x\n", *report.Signer)
+	}
+	if report.InTurn != nil {
+		fmt.Printf("In-turn:     %x (signer in-turn: %v)\n", *report.InTurn, report.Signer != nil && *report.Signer == *report.InTurn)
+	}
+	fmt.Printf("Difficulty:  %v", report.Difficulty.ToInt())
+	if report.ExpectedDifficulty != nil {
+		fmt.Printf(" (expected %v)", report.ExpectedDifficulty.ToInt())
+	}
+	fmt.Println()
+
+	if len(report.Validators) > 0 {
+		fmt.Printf("\nValidators (%d):\n", len(report.Validators))
+		for _, val := range report.Validators {
+			fmt.Printf("  %x\n", val)
+		}
+		fmt.Printf("\nRecents window: %d blocks\n", report.RecentsLimit)
+		seen := make([]uint64, 0, len(report.Recents))
+		for number := range report.Recents {
+			seen = append(seen, number)
+		}
+		sort.Slice(seen, func(i, j int) bool { return seen[i] < seen[j] })
+		for _, number := range seen {
+			fmt.Printf("  #%-10d %x\n", number, report.Recents[number])
+		}
+	}
+	if timing := report.Timing; timing != nil {
+		fmt.Printf("\nTiming (ramanujan: %v):\n", timing.Ramanujan)
+		fmt.Printf("  parent time  %d\n", timing.ParentTime)
+		fmt.Printf("  period       %d\n", timing.Period)
+		fmt.Printf("  back-off     %d\n", timing.BackOffTime)
+		fmt.Printf("  earliest     %d\n", timing.Earliest)
+		fmt.Printf("  header time  %d\n", timing.Time)
+	}
+	if report.ExpectedValidators != nil || report.ActualValidators != nil {
+		fmt.Printf("\nEpoch validators:\n")
+		fmt.Printf("  expected  %x\n", []byte(report.ExpectedValidators))
+		fmt.Printf("  actual    %x\n", []byte(report.ActualValidators))
+	}
+}
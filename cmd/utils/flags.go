@@ -18,6 +18,7 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -189,6 +191,25 @@ var (
 		Name:  "dev.period",
 		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
 	}
+	DevParliaFlag = cli.BoolFlag{
+		Name:  "dev.parlia",
+		Usage: "Ephemeral Parlia network with in-memory validators and the system contracts pre-deployed, mining enabled",
+	}
+	DevParliaValidatorsFlag = cli.IntFlag{
+		Name:  "dev.parlia.validators",
+		Usage: "Number of validators of the Parlia developer network",
+		Value: 3,
+	}
+	DevParliaEpochFlag = cli.Uint64Flag{
+		Name:  "dev.parlia.epoch",
+		Usage: "Number of blocks between validator set updates of the Parlia developer network",
+		Value: 20,
+	}
+	DevParliaOfflineFlag = cli.StringFlag{
+		Name:  "dev.parlia.offline",
+		Usage: "Comma separated indices of the Parlia developer network validators never sealing (to exercise slashing)",
+		Value: "",
+	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
 		Usage: "Custom node name",
@@ -348,9 +369,19 @@ var (
 	}
 	TxPoolRejournalFlag = cli.DurationFlag{
 		Name:  "txpool.rejournal",
-		Usage: "Time interval to regenerate the local transaction journal",
+		Usage: "Time interval to regenerate the local transaction journal and the pool snapshot",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolSnapshotFlag = cli.StringFlag{
+		Name:  "txpool.snapshot",
+		Usage: "Disk snapshot of the remote transactions to refill the pool after node restarts (empty = disabled)",
+		Value: core.DefaultTxPoolConfig.Snapshot,
+	}
+	TxPoolSnapshotLimitFlag = cli.Uint64Flag{
+		Name:  "txpool.snapshotlimit",
+		Usage: "Maximum number of remote transactions kept in the pool snapshot",
+		Value: core.DefaultTxPoolConfig.SnapshotLimit,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -386,6 +417,16 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolPeerRateFlag = cli.Uint64Flag{
+		Name:  "txpool.peerrate",
+		Usage: "Maximum number of transactions admitted per second from a peer (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.PeerRate,
+	}
+	TxPoolSenderRateFlag = cli.Uint64Flag{
+		Name:  "txpool.senderrate",
+		Usage: "Maximum number of remote transactions admitted per second from a sender (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.SenderRate,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -416,6 +457,10 @@ var (
 		Name:  "cache.noprefetch",
 		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
 	}
+	CacheAccessPrefetchFlag = cli.BoolFlag{
+		Name:  "cache.accessprefetch",
+		Usage: "Prefetch the state blocks likely access, derived from their transactions, concurrently with importing them",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -487,6 +532,27 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerOrderingFlag = cli.StringFlag{
+		Name:  "miner.ordering",
+		Usage: `Transaction ordering policy ("price" or "fifo")`,
+		Value: "price",
+	}
+	MinerOrderingBandFlag = BigFlag{
+		Name:  "miner.ordering.band",
+		Usage: "Gas price band width within which the fifo ordering commits transactions first seen first",
+	}
+	MinerOrderingSenderCapFlag = cli.IntFlag{
+		Name:  "miner.ordering.sendercap",
+		Usage: "Maximum number of transactions of a sender per block (0 = unlimited)",
+	}
+	MinerOrderingAllowFlag = cli.StringFlag{
+		Name:  "miner.ordering.allow",
+		Usage: "Comma separated senders and recipients only the transactions of which are mined",
+	}
+	MinerOrderingDenyFlag = cli.StringFlag{
+		Name:  "miner.ordering.deny",
+		Usage: "Comma separated senders and recipients the transactions of which are never mined",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -507,6 +573,15 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMProfileFlag = cli.BoolFlag{
+		Name:  "vmprofile",
+		Usage: "Export per-opcode, precompile and storage cache costs of imported blocks into the metrics (slows down block import)",
+	}
+	ParallelTxsFlag = cli.IntFlag{
+		Name:  "parallel.txs",
+		Usage: "Number of workers executing the transactions of imported blocks optimistically in parallel (0 = serial)",
+		Value: eth.DefaultConfig.ParallelTxs,
+	}
 	InsecureUnlockAllowedFlag = cli.BoolFlag{
 		Name:  "allow-insecure-unlock",
 		Usage: "Allow insecure account unlocking when account-related RPCs are exposed by http",
@@ -1320,6 +1395,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolSnapshotFlag.Name) {
+		cfg.Snapshot = ctx.GlobalString(TxPoolSnapshotFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSnapshotLimitFlag.Name) {
+		cfg.SnapshotLimit = ctx.GlobalUint64(TxPoolSnapshotLimitFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1341,6 +1422,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPeerRateFlag.Name) {
+		cfg.PeerRate = ctx.GlobalUint64(TxPoolPeerRateFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSenderRateFlag.Name) {
+		cfg.SenderRate = ctx.GlobalUint64(TxPoolSenderRateFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -1401,6 +1488,37 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerOrderingFlag.Name) {
+		cfg.Ordering = ctx.GlobalString(MinerOrderingFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerOrderingBandFlag.Name) {
+		cfg.OrderingBand = GlobalBig(ctx, MinerOrderingBandFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerOrderingSenderCapFlag.Name) {
+		cfg.OrderingSenderCap = ctx.GlobalInt(MinerOrderingSenderCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerOrderingAllowFlag.Name) {
+		cfg.OrderingAllow = splitAddresses(MinerOrderingAllowFlag.Name, ctx.GlobalString(MinerOrderingAllowFlag.Name))
+	}
+	if ctx.GlobalIsSet(MinerOrderingDenyFlag.Name) {
+		cfg.OrderingDeny = splitAddresses(MinerOrderingDenyFlag.Name, ctx.GlobalString(MinerOrderingDenyFlag.Name))
+	}
+	if _, err := miner.NewTxOrdering(cfg); err != nil {
+		Fatalf("Invalid --%s: %v", MinerOrderingFlag.Name, err)
+	}
+}
+
+// splitAddresses parses the comma separated addresses given to a flag.
+func splitAddresses(flag, list string) []common.Address {
+	var addrs []common.Address
+	for _, account := range strings.Split(list, ",") {
+		if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+			Fatalf("Invalid address in --%s: %s", flag, trimmed)
+		} else {
+			addrs = append(addrs, common.HexToAddress(trimmed))
+		}
+	}
+	return addrs
 }
 
 func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
@@ -1522,6 +1640,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.GlobalBool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.GlobalIsSet(CacheAccessPrefetchFlag.Name) {
+		cfg.AccessPrefetch = ctx.GlobalBool(CacheAccessPrefetchFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1541,6 +1662,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(VMProfileFlag.Name) {
+		cfg.VMProfile = ctx.GlobalBool(VMProfileFlag.Name)
+	}
+	if ctx.GlobalIsSet(ParallelTxsFlag.Name) {
+		cfg.ParallelTxs = ctx.GlobalInt(ParallelTxsFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(EWASMInterpreterFlag.Name) {
 		cfg.EWASMInterpreter = ctx.GlobalString(EWASMInterpreterFlag.Name)
@@ -1603,7 +1730,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		}
 		log.Info("Using developer account", "address", developer.Address)
 
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		if ctx.GlobalBool(DevParliaFlag.Name) {
+			validators := MakeDevParliaValidators(ctx, ks)
+			for i, val := range validators {
+				log.Info("Using developer validator", "index", i, "address", val)
+			}
+			cfg.Genesis = core.DeveloperParliaGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), ctx.GlobalUint64(DevParliaEpochFlag.Name), validators)
+		} else {
+			cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		}
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) && !ctx.GlobalIsSet(MinerLegacyGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
 		}
@@ -1614,6 +1749,53 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	}
 }
 
+// MakeDevParliaValidators returns the validators of the Parlia developer network
+// in ascending order, reusing the first local accounts and creating the missing
+// ones. All of them are unlocked to seal blocks.
+func MakeDevParliaValidators(ctx *cli.Context, ks *keystore.KeyStore) []common.Address {
+	count := ctx.GlobalInt(DevParliaValidatorsFlag.Name)
+	if count < 1 {
+		Fatalf("Option %q: must be positive", DevParliaValidatorsFlag.Name)
+	}
+	accs := ks.Accounts()
+	for len(accs) < count {
+		acc, err := ks.NewAccount("")
+		if err != nil {
+			Fatalf("Failed to create validator account: %v", err)
+		}
+		accs = append(accs, acc)
+	}
+	validators := make([]common.Address, 0, count)
+	for _, acc := range accs[:count] {
+		if err := ks.Unlock(acc, ""); err != nil {
+			Fatalf("Failed to unlock validator account: %v", err)
+		}
+		validators = append(validators, acc.Address)
+	}
+	sort.Slice(validators, func(i, j int) bool { return bytes.Compare(validators[i][:], validators[j][:]) < 0 })
+	return validators
+}
+
+// MakeDevParliaOffline returns the validators of the Parlia developer network
+// selected by index as never sealing.
+func MakeDevParliaOffline(ctx *cli.Context, validators []common.Address) []common.Address {
+	var offline []common.Address
+	for _, index := range strings.Split(ctx.GlobalString(DevParliaOfflineFlag.Name), ",") {
+		if index = strings.TrimSpace(index); index == "" {
+			continue
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= len(validators) {
+			Fatalf("Option %q: invalid validator index %q", DevParliaOfflineFlag.Name, index)
+		}
+		offline = append(offline, validators[i])
+	}
+	if len(offline) >= len(validators) {
+		Fatalf("Option %q: at least one validator must seal", DevParliaOfflineFlag.Name)
+	}
+	return offline
+}
+
 // setDNSDiscoveryDefaults configures DNS discovery with the given URL if
 // no URLs are set.
 func setDNSDiscoveryDefaults(cfg *eth.Config, url string) {
@@ -1794,6 +1976,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	cache := &core.CacheConfig{
 		TrieCleanLimit:      eth.DefaultConfig.TrieCleanCache,
 		TrieCleanNoPrefetch: ctx.GlobalBool(CacheNoPrefetchFlag.Name),
+		TrieAccessPrefetch:  ctx.GlobalBool(CacheAccessPrefetchFlag.Name),
 		TrieDirtyLimit:      eth.DefaultConfig.TrieDirtyCache,
 		TrieDirtyDisabled:   ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieTimeLimit:       eth.DefaultConfig.TrieTimeout,
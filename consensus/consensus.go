@@ -133,4 +133,8 @@ type PoSA interface {
 
 	IsSystemTransaction(tx *types.Transaction, header *types.Header) (bool, error)
 	IsSystemContract(to *common.Address) bool
+
+	// DescribeSystemTransaction decodes why a system transaction was injected into
+	// the block of the given header. It returns nil for user transactions.
+	DescribeSystemTransaction(tx *types.Transaction, header *types.Header) (*types.SystemTxInfo, error)
 }
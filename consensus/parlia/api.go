@@ -17,9 +17,14 @@
 package parlia
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -86,3 +91,103 @@ func (api *API) GetValidatorsAtHash(hash common.Hash) ([]common.Address, error)
 	}
 	return snap.validators(), nil
 }
+
+// GetValidatorStats reports, for every validator, the in-turn slots it had in the
+// given block range, how many blocks it sealed and which of its slots were sealed
+// out-of-turn (and possibly slashed) by another validator.
+func (api *API) GetValidatorStats(fromBlock, toBlock *rpc.BlockNumber) (*ValidatorStatsResult, error) {
+	from, to := api.blockNumber(fromBlock), api.blockNumber(toBlock)
+	if to > api.chain.CurrentHeader().Number.Uint64() {
+		return nil, errUnknownBlock
+	}
+	return api.parlia.validatorStats(api.chain, from, to)
+}
+
+// GetValidatorSetHistory lists the validator set transitions announced by the
+// epoch blocks within the given block range.
+func (api *API) GetValidatorSetHistory(fromBlock, toBlock *rpc.BlockNumber) ([]*ValidatorSetTransition, error) {
+	from, to := api.blockNumber(fromBlock), api.blockNumber(toBlock)
+	if to > api.chain.CurrentHeader().Number.Uint64() {
+		return nil, errUnknownBlock
+	}
+	return api.parlia.validatorSetHistory(api.chain, from, to)
+}
+
+// GetBackOffSchedule reports, for each of the given number of heights following
+// the current head, the in-turn validator and the earliest time every other
+// validator may seal the block at, excluding those that signed recently.
+func (api *API) GetBackOffSchedule(blocks uint64) ([]*BackOffSchedule, error) {
+	return api.parlia.backOffSchedule(api.chain, api.chain.CurrentHeader(), blocks)
+}
+
+// blockNumber resolves an optional block number, defaulting to the current head.
+func (api *API) blockNumber(number *rpc.BlockNumber) uint64 {
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		return api.chain.CurrentHeader().Number.Uint64()
+	}
+	return uint64(number.Int64())
+}
+
+// GetDoubleSignEvidences retrieves the evidences of validators sealing two
+// different headers at the same height, as seen by the local node.
+func (api *API) GetDoubleSignEvidences() []*DoubleSignEvidence {
+	return api.parlia.doubleSign.list()
+}
+
+// DoubleSign creates a subscription that fires each time a validator is caught
+// sealing two different headers at the same height.
+func (api *API) DoubleSign(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan DoubleSignEvent)
+		sub := api.parlia.SubscribeDoubleSignEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev.Evidence)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// DebugAPI is the collection of Parlia diagnostics exposed over the debug namespace.
+type DebugAPI struct {
+	chain  consensus.ChainReader
+	parlia *Parlia
+}
+
+// VerifyParliaHeader runs the Parlia verification steps against a header and
+// reports how each of them fared. The header is given either by its hash, or by
+// the RLP encoding of the header or of its block.
+func (api *DebugAPI) VerifyParliaHeader(rlpOrHash hexutil.Bytes) (*HeaderVerificationReport, error) {
+	var header *types.Header
+	if len(rlpOrHash) == common.HashLength {
+		if header = api.chain.GetHeaderByHash(common.BytesToHash(rlpOrHash)); header == nil {
+			return nil, errUnknownBlock
+		}
+	} else {
+		header = new(types.Header)
+		if err := rlp.DecodeBytes(rlpOrHash, header); err != nil {
+			block := new(types.Block)
+			if rlp.DecodeBytes(rlpOrHash, block) != nil {
+				return nil, fmt.Errorf("invalid header RLP: %v", err)
+			}
+			header = block.Header()
+		}
+	}
+	return api.parlia.verifyHeaderReport(api.chain, header), nil
+}
@@ -0,0 +1,93 @@
+package parlia
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const maxBackOffScheduleBlocks = 1024 // Maximum number of heights a single schedule query may cover
+
+// ValidatorBackOff is the earliest time a validator may seal a given height.
+type ValidatorBackOff struct {
+	Validator      common.Address `json:"validator"`
+	InTurn         bool           `json:"inTurn"`
+	SignedRecently bool           `json:"signedRecently"`     // Excluded from sealing by the recents window
+	Step           *uint64        `json:"step,omitempty"`     // Back-off step of an out-of-turn validator
+	BackOffTime    uint64         `json:"backOffTime"`        // Seconds to wait on top of the period
+	Earliest       uint64         `json:"earliest,omitempty"` // Earliest timestamp the validator may seal at
+}
+
+// BackOffSchedule lists when every validator may seal a given height.
+type BackOffSchedule struct {
+	Number     uint64              `json:"number"`
+	InTurn     common.Address      `json:"inTurn"`
+	Ramanujan  bool                `json:"ramanujan"`  // Whether the back-off rules apply at this height
+	ParentTime uint64              `json:"parentTime"` // Timestamp of the parent, assumed sealed in-turn if not yet known
+	Validators []*ValidatorBackOff `json:"validators"`
+}
+
+// backOffSchedule computes the back-off schedule of the given number of heights
+// following head. Heights beyond the next one assume every block gets sealed by
+// the in-turn validator at the earliest time, and the validator set to stay the
+// same.
+func (p *Parlia) backOffSchedule(chain consensus.ChainReader, head *types.Header, blocks uint64) ([]*BackOffSchedule, error) {
+	if blocks == 0 || blocks > maxBackOffScheduleBlocks {
+		return nil, fmt.Errorf("block count must be between 1 and %d", maxBackOffScheduleBlocks)
+	}
+	snap, err := p.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	snap = snap.copy()
+
+	var (
+		schedules  = make([]*BackOffSchedule, 0, blocks)
+		parentTime = head.Time
+		validators = snap.validators()
+		limit      = uint64(len(validators)/2 + 1)
+	)
+	for i := uint64(0); i < blocks; i++ {
+		number := snap.Number + 1
+		schedule := &BackOffSchedule{
+			Number:     number,
+			InTurn:     snap.supposeValidator(),
+			Ramanujan:  p.chainConfig.IsRamanujan(new(big.Int).SetUint64(number)),
+			ParentTime: parentTime,
+		}
+		steps := backOffSteps(snap)
+		for idx, val := range validators {
+			backOff := &ValidatorBackOff{
+				Validator: val,
+				InTurn:    val == schedule.InTurn,
+			}
+			for seen, recent := range snap.Recents {
+				if recent == val && seen+limit > number {
+					backOff.SignedRecently = true
+				}
+			}
+			if schedule.Ramanujan && !backOff.InTurn {
+				step := steps[idx]
+				backOff.Step = &step
+				backOff.BackOffTime = backOffTime(snap, val)
+			}
+			if !backOff.SignedRecently {
+				backOff.Earliest = parentTime + p.config.Period + backOff.BackOffTime
+			}
+			schedule.Validators = append(schedule.Validators, backOff)
+		}
+		schedules = append(schedules, schedule)
+
+		// Assume the in-turn validator seals the block as early as possible
+		if number >= limit {
+			delete(snap.Recents, number-limit)
+		}
+		snap.Recents[number] = schedule.InTurn
+		snap.Number = number
+		parentTime += p.config.Period
+	}
+	return schedules, nil
+}
@@ -0,0 +1,165 @@
+package parlia
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	inMemorySignedHeaders  = 4096 // Number of recent (validator, height) pairs to check for equivocation
+	maxDoubleSignEvidences = 256  // Number of most recent double-sign evidences kept in memory
+)
+
+// DoubleSignEvidence is the proof of a validator sealing two different headers
+// at the same height. Header1RLP and Header2RLP are the RLP encodings of the two
+// headers, as needed to submit the evidence to the slash contract.
+type DoubleSignEvidence struct {
+	Validator  common.Address `json:"validator"`
+	Number     uint64         `json:"number"`
+	Header1    *types.Header  `json:"header1"`
+	Header2    *types.Header  `json:"header2"`
+	Header1RLP hexutil.Bytes  `json:"header1Rlp"`
+	Header2RLP hexutil.Bytes  `json:"header2Rlp"`
+	Detected   uint64         `json:"detected"` // Unix time the equivocation was spotted locally
+}
+
+// DoubleSignEvent is posted when a validator is caught sealing two different
+// headers at the same height.
+type DoubleSignEvent struct{ Evidence *DoubleSignEvidence }
+
+// signedSlot identifies a height sealed by a validator.
+type signedSlot struct {
+	validator common.Address
+	number    uint64
+}
+
+// doubleSignDetector tracks the headers sealed by every validator at recent
+// heights and reports any conflicting pair.
+type doubleSignDetector struct {
+	seen *lru.ARCCache // Signed header per recent (validator, height) pair
+
+	evidences []*DoubleSignEvidence // Most recent evidences, oldest first
+	lock      sync.Mutex            // Protects the tracker and the evidence list
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+}
+
+// newDoubleSignDetector creates an equivocation detector.
+func newDoubleSignDetector() *doubleSignDetector {
+	seen, err := lru.NewARC(inMemorySignedHeaders)
+	if err != nil {
+		panic(err)
+	}
+	return &doubleSignDetector{seen: seen}
+}
+
+// observe records a header sealed by signer, checking it against any other header
+// the same validator sealed at the same height.
+func (d *doubleSignDetector) observe(header *types.Header, signer common.Address) {
+	slot := signedSlot{validator: signer, number: header.Number.Uint64()}
+
+	d.lock.Lock()
+	known, ok := d.seen.Get(slot)
+	if !ok {
+		d.seen.Add(slot, header)
+		d.lock.Unlock()
+		return
+	}
+	first, hash := known.(*types.Header), header.Hash()
+	if first.Hash() == hash {
+		d.lock.Unlock()
+		return
+	}
+	for _, evidence := range d.evidences {
+		if evidence.Validator == signer && evidence.Number == slot.number && evidence.Header2.Hash() == hash {
+			d.lock.Unlock()
+			return
+		}
+	}
+	evidence, err := newDoubleSignEvidence(signer, first, header)
+	if err != nil {
+		d.lock.Unlock()
+		log.Error("Failed to encode double sign evidence", "validator", signer, "number", slot.number, "err", err)
+		return
+	}
+	d.evidences = append(d.evidences, evidence)
+	if len(d.evidences) > maxDoubleSignEvidences {
+		d.evidences = d.evidences[len(d.evidences)-maxDoubleSignEvidences:]
+	}
+	d.lock.Unlock()
+
+	log.Warn("Validator double signed", "validator", signer, "number", slot.number, "hash1", first.Hash(), "hash2", hash)
+	d.feed.Send(DoubleSignEvent{Evidence: evidence})
+}
+
+// list returns the double-sign evidences collected so far, oldest first.
+func (d *doubleSignDetector) list() []*DoubleSignEvidence {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return append([]*DoubleSignEvidence{}, d.evidences...)
+}
+
+// subscribe registers a subscription for double-sign events.
+func (d *doubleSignDetector) subscribe(ch chan<- DoubleSignEvent) event.Subscription {
+	return d.scope.Track(d.feed.Subscribe(ch))
+}
+
+// newDoubleSignEvidence assembles the evidence of two conflicting headers.
+func newDoubleSignEvidence(validator common.Address, header1, header2 *types.Header) (*DoubleSignEvidence, error) {
+	rlp1, err := rlp.EncodeToBytes(header1)
+	if err != nil {
+		return nil, err
+	}
+	rlp2, err := rlp.EncodeToBytes(header2)
+	if err != nil {
+		return nil, err
+	}
+	return &DoubleSignEvidence{
+		Validator:  validator,
+		Number:     header1.Number.Uint64(),
+		Header1:    header1,
+		Header2:    header2,
+		Header1RLP: rlp1,
+		Header2RLP: rlp2,
+		Detected:   uint64(time.Now().Unix()),
+	}, nil
+}
+
+// CheckDoubleSign recovers the signer of a header received from the network and
+// checks it for equivocation ahead of the full header verification. Only headers
+// sealed by a member of the current validator set are tracked.
+func (p *Parlia) CheckDoubleSign(chain consensus.ChainReader, header *types.Header) {
+	if header.Number == nil || header.Number.Sign() == 0 || len(header.Extra) < extraVanity+extraSeal {
+		return
+	}
+	signer, err := ecrecover(header, p.signatures, p.chainConfig.ChainID)
+	if err != nil || signer != header.Coinbase {
+		return
+	}
+	current := chain.CurrentHeader()
+	snap, err := p.snapshot(chain, current.Number.Uint64(), current.Hash(), nil)
+	if err != nil {
+		return
+	}
+	if _, ok := snap.Validators[signer]; !ok {
+		return
+	}
+	p.doubleSign.observe(header, signer)
+}
+
+// SubscribeDoubleSignEvent registers a subscription of DoubleSignEvent.
+func (p *Parlia) SubscribeDoubleSignEvent(ch chan<- DoubleSignEvent) event.Subscription {
+	return p.doubleSign.subscribe(ch)
+}
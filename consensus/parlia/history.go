@@ -0,0 +1,109 @@
+package parlia
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const maxValidatorSetHistoryEpochs = 10000 // Maximum number of epochs a single history query may cover
+
+// ValidatorSetTransition is a change of the validator set announced by an epoch block.
+type ValidatorSetTransition struct {
+	Number     uint64           `json:"number"`     // Epoch block announcing the new validator set
+	Hash       common.Hash      `json:"hash"`       // Hash of the epoch block
+	Effective  uint64           `json:"effective"`  // First block sealed under the new validator set
+	Validators []common.Address `json:"validators"` // Validator set announced by the epoch block
+	Added      []common.Address `json:"added"`      // Validators joining the set
+	Removed    []common.Address `json:"removed"`    // Validators leaving the set
+}
+
+// indexValidatorSet persists the validator set carried in the extra-data of an
+// epoch header, unless it was already indexed.
+func (p *Parlia) indexValidatorSet(header *types.Header) ([]common.Address, error) {
+	number, hash := header.Number.Uint64(), header.Hash()
+	if validators := rawdb.ReadValidatorSet(p.db, number, hash); validators != nil {
+		return validators, nil
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return nil, errMissingSignature
+	}
+	validators, err := ParseValidators(header.Extra[extraVanity : len(header.Extra)-extraSeal])
+	if err != nil {
+		return nil, err
+	}
+	rawdb.WriteValidatorSet(p.db, number, hash, validators)
+	return validators, nil
+}
+
+// validatorSetHistory lists the validator set transitions announced by the
+// canonical epoch blocks within [from, to]. Epoch blocks that were never
+// processed locally, e.g. mined or fast synced ones, are decoded from their
+// headers and indexed lazily.
+func (p *Parlia) validatorSetHistory(chain consensus.ChainReader, from, to uint64) ([]*ValidatorSetTransition, error) {
+	if from > to {
+		return nil, errors.New("invalid block range")
+	}
+	epoch := p.config.Epoch
+	if (to/epoch)-(from/epoch) >= maxValidatorSetHistoryEpochs {
+		return nil, fmt.Errorf("block range exceeds %d epochs", maxValidatorSetHistoryEpochs)
+	}
+	// Start from the epoch preceding the range to be able to diff the first transition
+	start := from - from%epoch
+	if start >= epoch {
+		start -= epoch
+	}
+	var (
+		transitions []*ValidatorSetTransition
+		previous    []common.Address
+	)
+	for number := start; number <= to; number += epoch {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		validators, err := p.indexValidatorSet(header)
+		if err != nil {
+			return nil, err
+		}
+		added, removed := diffValidators(previous, validators)
+		if number >= from && (number == 0 || len(added) > 0 || len(removed) > 0) {
+			transitions = append(transitions, &ValidatorSetTransition{
+				Number:     number,
+				Hash:       header.Hash(),
+				Effective:  number + uint64(len(previous)/2),
+				Validators: validators,
+				Added:      added,
+				Removed:    removed,
+			})
+		}
+		previous = validators
+	}
+	return transitions, nil
+}
+
+// diffValidators returns the validators present in next but not in prev, and
+// the ones present in prev but not in next.
+func diffValidators(prev, next []common.Address) (added, removed []common.Address) {
+	prevSet := make(map[common.Address]struct{}, len(prev))
+	for _, val := range prev {
+		prevSet[val] = struct{}{}
+	}
+	nextSet := make(map[common.Address]struct{}, len(next))
+	for _, val := range next {
+		nextSet[val] = struct{}{}
+		if _, ok := prevSet[val]; !ok {
+			added = append(added, val)
+		}
+	}
+	for _, val := range prev {
+		if _, ok := nextSet[val]; !ok {
+			removed = append(removed, val)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,139 @@
+package parlia
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const maxValidatorStatsRange = 10000 // Maximum number of blocks a single liveness query may cover
+
+// errStatsRangeTooLarge is returned if a liveness query spans more blocks than allowed.
+var errStatsRangeTooLarge = fmt.Errorf("block range exceeds %d blocks", maxValidatorStatsRange)
+
+// ValidatorStats is the liveness record of a single validator over a block range.
+type ValidatorStats struct {
+	InTurnSlots     uint64   `json:"inTurnSlots"`     // Blocks in which the validator was the in-turn signer
+	Sealed          uint64   `json:"sealed"`          // Blocks actually sealed by the validator
+	SealedInTurn    uint64   `json:"sealedInTurn"`    // Blocks sealed by the validator in its own slot
+	SealedOutOfTurn uint64   `json:"sealedOutOfTurn"` // Blocks sealed by the validator in someone else's slot
+	MissedSlots     uint64   `json:"missedSlots"`     // In-turn slots sealed out-of-turn by another validator
+	MissedBlocks    []uint64 `json:"missedBlocks"`    // Block numbers of the missed slots
+	SlashedBlocks   []uint64 `json:"slashedBlocks"`   // Missed slots which triggered a slash system transaction
+}
+
+// ValidatorStatsResult is the liveness report of all validators seen in a block range.
+type ValidatorStatsResult struct {
+	FromBlock  uint64                             `json:"fromBlock"`
+	ToBlock    uint64                             `json:"toBlock"`
+	Validators map[common.Address]*ValidatorStats `json:"validators"`
+}
+
+// validatorStats walks the canonical headers in [from, to] and replays the
+// validator rotation of the snapshots to account in-turn, sealed and missed
+// slots for every validator.
+func (p *Parlia) validatorStats(chain consensus.ChainReader, from, to uint64) (*ValidatorStatsResult, error) {
+	if from == 0 {
+		from = 1 // The genesis block is not sealed by anybody
+	}
+	if from > to {
+		return nil, errors.New("invalid block range")
+	}
+	if to-from+1 > maxValidatorStatsRange {
+		return nil, errStatsRangeTooLarge
+	}
+	result := &ValidatorStatsResult{
+		FromBlock:  from,
+		ToBlock:    to,
+		Validators: make(map[common.Address]*ValidatorStats),
+	}
+	stats := func(val common.Address) *ValidatorStats {
+		s, ok := result.Validators[val]
+		if !ok {
+			s = &ValidatorStats{MissedBlocks: []uint64{}, SlashedBlocks: []uint64{}}
+			result.Validators[val] = s
+		}
+		return s
+	}
+	for number := from; number <= to; number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		snap, err := p.snapshot(chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		supposed := snap.supposeValidator()
+		stats(supposed).InTurnSlots++
+
+		signer := stats(header.Coinbase)
+		signer.Sealed++
+		if header.Difficulty.Cmp(diffInTurn) == 0 {
+			signer.SealedInTurn++
+			continue
+		}
+		signer.SealedOutOfTurn++
+
+		missed := stats(supposed)
+		missed.MissedSlots++
+		missed.MissedBlocks = append(missed.MissedBlocks, number)
+
+		block := chain.GetBlock(header.Hash(), number)
+		if block == nil {
+			return nil, errUnknownBlock
+		}
+		if slashed, ok := p.findSlashedValidator(block); ok && slashed == supposed {
+			missed.SlashedBlocks = append(missed.SlashedBlocks, number)
+		}
+	}
+	return result, nil
+}
+
+// findSlashedValidator looks for the slash system transaction within a block and
+// returns the validator it punished, if any.
+func (p *Parlia) findSlashedValidator(block *types.Block) (common.Address, bool) {
+	slashContract := common.HexToAddress(systemcontracts.SlashContract)
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil || *tx.To() != slashContract {
+			continue
+		}
+		if isSystemTx, err := p.IsSystemTransaction(tx, block.Header()); err != nil || !isSystemTx {
+			continue
+		}
+		if val, err := p.unpackSlash(tx.Data()); err == nil {
+			return val, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// unpackSlash decodes the validator argument of a slash system transaction.
+func (p *Parlia) unpackSlash(data []byte) (common.Address, error) {
+	if len(data) < 4 {
+		return common.Address{}, errors.New("slash input too short")
+	}
+	method, err := p.slashABI.MethodById(data[:4])
+	if err != nil {
+		return common.Address{}, err
+	}
+	if method.RawName != "slash" {
+		return common.Address{}, fmt.Errorf("unexpected slash contract method %s", method.RawName)
+	}
+	args, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(args) != 1 {
+		return common.Address{}, errors.New("invalid slash arguments")
+	}
+	val, ok := args[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("invalid slash arguments")
+	}
+	return val, nil
+}
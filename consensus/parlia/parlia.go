@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -193,6 +194,8 @@ type Parlia struct {
 	recentSnaps *lru.ARCCache // Snapshots for recent block to speed up
 	signatures  *lru.ARCCache // Signatures of recent blocks to speed up mining
 
+	doubleSign *doubleSignDetector // Tracker of validators sealing conflicting headers
+
 	signer types.Signer
 
 	val      common.Address // Ethereum address of the signing key
@@ -247,6 +250,7 @@ func New(
 		ethAPI:          ethAPI,
 		recentSnaps:     recentSnaps,
 		signatures:      signatures,
+		doubleSign:      newDoubleSignDetector(),
 		validatorSetABI: vABI,
 		slashABI:        sABI,
 		signer:          types.NewEIP155Signer(chainConfig.ChainID),
@@ -313,6 +317,33 @@ func (p *Parlia) VerifyHeaders(chain consensus.ChainReader, headers []*types.Hea
 // looking those up from the database. This is useful for concurrently verifying
 // a batch of new headers.
 func (p *Parlia) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+	if err := p.verifyStandaloneFields(chain, header); err != nil {
+		return err
+	}
+	// All basic checks passed, verify cascading fields and the seal, the genesis
+	// block being the always valid dead-end
+	if err := p.verifyCascadingFields(chain, header, parents); err != nil {
+		return err
+	}
+	if header.Number.Uint64() == 0 {
+		return nil
+	}
+	if err := p.verifySeal(chain, header, parents); err != nil {
+		return err
+	}
+	// Track the seal for equivocation. Only the headers being imported pass
+	// through here, the ones checked on demand by the debug API do not.
+	signer, err := ecrecover(header, p.signatures, p.chainConfig.ChainID)
+	if err != nil {
+		return err
+	}
+	p.doubleSign.observe(header, signer)
+	return nil
+}
+
+// verifyStandaloneFields checks the header fields that can be verified without
+// looking at any other header.
+func (p *Parlia) verifyStandaloneFields(chain consensus.ChainReader, header *types.Header) error {
 	if header.Number == nil {
 		return errUnknownBlock
 	}
@@ -357,15 +388,12 @@ func (p *Parlia) verifyHeader(chain consensus.ChainReader, header *types.Header,
 		}
 	}
 	// If all checks passed, validate any special fields for hard forks
-	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
-		return err
-	}
-	// All basic checks passed, verify cascading fields
-	return p.verifyCascadingFields(chain, header, parents)
+	return misc.VerifyForkHashes(chain.Config(), header, false)
 }
 
 // verifyCascadingFields verifies all the header fields that are not standalone,
-// rather depend on a batch of previous headers. The caller may optionally pass
+// rather depend on a batch of previous headers, apart from the seal which is
+// checked by verifySeal. The caller may optionally pass
 // in a batch of parents (ascending order) to avoid looking those up from the
 // database. This is useful for concurrently verifying a batch of new headers.
 func (p *Parlia) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
@@ -416,9 +444,7 @@ func (p *Parlia) verifyCascadingFields(chain consensus.ChainReader, header *type
 	if uint64(diff) >= limit || header.GasLimit < params.MinGasLimit {
 		return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit)
 	}
-
-	// All basic checks passed, verify the seal and return
-	return p.verifySeal(chain, header, parents)
+	return nil
 }
 
 // snapshot retrieves the authorization snapshot at a given point in time.
@@ -459,6 +485,8 @@ func (p *Parlia) snapshot(chain consensus.ChainReader, number uint64, hash commo
 					return nil, err
 				}
 
+				rawdb.WriteValidatorSet(p.db, number, hash, validators)
+
 				// new snap shot
 				snap = newSnapshot(p.config, p.signatures, number, hash, validators, p.ethAPI)
 				if err := snap.store(p.db); err != nil {
@@ -641,21 +669,18 @@ func (p *Parlia) Finalize(chain consensus.ChainReader, header *types.Header, sta
 	// If the block is a epoch end block, verify the validator list
 	// The verification can only be done when the state is ready, it can't be done in VerifyHeader.
 	if header.Number.Uint64()%p.config.Epoch == 0 {
-		newValidators, err := p.getCurrentValidators(header.ParentHash)
+		validatorsBytes, err := p.epochValidatorsBytes(header.ParentHash)
 		if err != nil {
 			return err
 		}
-		// sort validator by address
-		sort.Sort(validatorsAscending(newValidators))
-		validatorsBytes := make([]byte, len(newValidators)*validatorBytesLength)
-		for i, validator := range newValidators {
-			copy(validatorsBytes[i*validatorBytesLength:], validator.Bytes())
-		}
-
 		extraSuffix := len(header.Extra) - extraSeal
 		if !bytes.Equal(header.Extra[extraVanity:extraSuffix], validatorsBytes) {
 			return errMismatchingEpochValidators
 		}
+		// The announced validator set matches the contract, index it
+		if _, err := p.indexValidatorSet(header); err != nil {
+			return err
+		}
 	}
 	// No block rewards in PoA, so the state remains as is and uncles are dropped
 	cx := chainContext{Chain: chain, parlia: p}
@@ -767,6 +792,37 @@ func (p *Parlia) Authorize(val common.Address, signFn SignerFn, signTxFn SignerT
 	p.signTxFn = signTxFn
 }
 
+// NextValidators returns the validators allowed to seal the block on top of the
+// given parent: the in-turn validator first, followed by the others in turn
+// order. Validators which signed too recently are left out.
+func (p *Parlia) NextValidators(chain consensus.ChainReader, parent *types.Header) ([]common.Address, error) {
+	snap, err := p.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		validators = snap.validators()
+		number     = parent.Number.Uint64() + 1
+		limit      = uint64(len(validators)/2 + 1)
+		next       = make([]common.Address, 0, len(validators))
+	)
+	for i := range validators {
+		val := validators[(number+uint64(i))%uint64(len(validators))]
+
+		recently := false
+		for seen, recent := range snap.Recents {
+			if recent == val && (number < limit || seen > number-limit) {
+				recently = true
+				break
+			}
+		}
+		if !recently {
+			next = append(next, val)
+		}
+	}
+	return next, nil
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (p *Parlia) Seal(chain consensus.ChainReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
@@ -865,23 +921,46 @@ func (p *Parlia) SealHash(header *types.Header) common.Hash {
 	return SealHash(header, p.chainConfig.ChainID)
 }
 
-// APIs implements consensus.Engine, returning the user facing RPC API to query snapshot.
+// APIs implements consensus.Engine, returning the user facing RPC API to query snapshot
+// and the debug API to diagnose header verification failures.
 func (p *Parlia) APIs(chain consensus.ChainReader) []rpc.API {
 	return []rpc.API{{
 		Namespace: "parlia",
 		Version:   "1.0",
 		Service:   &API{chain: chain, parlia: p},
 		Public:    false,
+	}, {
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   &DebugAPI{chain: chain, parlia: p},
+		Public:    false,
 	}}
 }
 
-// Close implements consensus.Engine. It's a noop for parlia as there are no background threads.
+// Close implements consensus.Engine, terminating any double-sign subscriptions.
 func (p *Parlia) Close() error {
+	p.doubleSign.scope.Close()
 	return nil
 }
 
 // ==========================  interaction with contract/account =========
 
+// epochValidatorsBytes returns the validator list an epoch block must carry in
+// its extra-data, as reported by the validator contract at its parent block.
+func (p *Parlia) epochValidatorsBytes(parentHash common.Hash) ([]byte, error) {
+	newValidators, err := p.getCurrentValidators(parentHash)
+	if err != nil {
+		return nil, err
+	}
+	// sort validator by address
+	sort.Sort(validatorsAscending(newValidators))
+	validatorsBytes := make([]byte, len(newValidators)*validatorBytesLength)
+	for i, validator := range newValidators {
+		copy(validatorsBytes[i*validatorBytesLength:], validator.Bytes())
+	}
+	return validatorsBytes, nil
+}
+
 // getCurrentValidators get current validators
 func (p *Parlia) getCurrentValidators(blockHash common.Hash) ([]common.Address, error) {
 	// block
@@ -975,6 +1054,12 @@ func (p *Parlia) slash(spoiledVal common.Address, state *state.StateDB, header *
 	return p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining)
 }
 
+// isDeveloperChain reports whether the engine runs a developer network, whose
+// genesis deploys the system contracts already initialized.
+func (p *Parlia) isDeveloperChain() bool {
+	return p.chainConfig.ChainID.Cmp(params.AllCliqueProtocolChanges.ChainID) == 0
+}
+
 // init contract
 func (p *Parlia) initContract(state *state.StateDB, header *types.Header, chain core.ChainContext,
 	txs *[]*types.Transaction, receipts *[]*types.Receipt, receivedTxs *[]*types.Transaction, usedGas *uint64, mining bool) error {
@@ -997,6 +1082,12 @@ func (p *Parlia) initContract(state *state.StateDB, header *types.Header, chain
 		return err
 	}
 	for _, c := range contracts {
+		// Skip the contracts the developer genesis already initialized, their init
+		// would revert. Other networks always run it, as they ever did.
+		if p.isDeveloperChain() && systemcontracts.IsInitialized(state, common.HexToAddress(c)) {
+			log.Trace("skip initialized contract", "block hash", header.Hash(), "contract", c)
+			continue
+		}
 		msg := p.getSystemMessage(header.Coinbase, common.HexToAddress(c), data, common.Big0)
 		// apply message
 		log.Trace("init contract", "block hash", header.Hash(), "contract", c)
@@ -1150,21 +1241,27 @@ func backOffTime(snap *Snapshot, val common.Address) uint64 {
 			// The backOffTime does not matter when a validator is not authorized.
 			return 0
 		}
-		s := rand.NewSource(int64(snap.Number))
-		r := rand.New(s)
-		n := len(snap.Validators)
-		backOffSteps := make([]uint64, 0, n)
-		for idx := uint64(0); idx < uint64(n); idx++ {
-			backOffSteps = append(backOffSteps, idx)
-		}
-		r.Shuffle(n, func(i, j int) {
-			backOffSteps[i], backOffSteps[j] = backOffSteps[j], backOffSteps[i]
-		})
-		delay := initialBackOffTime + backOffSteps[idx]*wiggleTime
+		delay := initialBackOffTime + backOffSteps(snap)[idx]*wiggleTime
 		return delay
 	}
 }
 
+// backOffSteps returns the back-off step of every validator, in the order of
+// snap.validators(), shuffled deterministically by the snapshot number.
+func backOffSteps(snap *Snapshot) []uint64 {
+	s := rand.NewSource(int64(snap.Number))
+	r := rand.New(s)
+	n := len(snap.Validators)
+	steps := make([]uint64, 0, n)
+	for idx := uint64(0); idx < uint64(n); idx++ {
+		steps = append(steps, idx)
+	}
+	r.Shuffle(n, func(i, j int) {
+		steps[i], steps[j] = steps[j], steps[i]
+	})
+	return steps
+}
+
 // chain context
 type chainContext struct {
 	Chain  consensus.ChainReader
@@ -1,11 +1,27 @@
 package parlia
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"fmt"
+	"math/big"
 	"math/rand"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func TestImpactOfValidatorOutOfService(t *testing.T) {
@@ -144,3 +160,750 @@ func randomAddress() common.Address {
 	rand.Read(addrBytes)
 	return common.BytesToAddress(addrBytes)
 }
+
+func TestUnpackSlash(t *testing.T) {
+	p := New(&params.ChainConfig{ChainID: big.NewInt(56), Parlia: &params.ParliaConfig{}}, nil, nil)
+
+	val := randomAddress()
+	data, err := p.slashABI.Pack("slash", val)
+	if err != nil {
+		t.Fatalf("failed to pack slash: %v", err)
+	}
+	got, err := p.unpackSlash(data)
+	if err != nil {
+		t.Fatalf("failed to unpack slash: %v", err)
+	}
+	if got != val {
+		t.Errorf("slashed validator mismatch: have %x, want %x", got, val)
+	}
+	if _, err := p.unpackSlash(data[:3]); err == nil {
+		t.Errorf("expected error for truncated input")
+	}
+}
+
+func TestDescribeSystemTransaction(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(56), Parlia: &params.ParliaConfig{}}
+	p := New(config, nil, nil)
+
+	key, _ := crypto.GenerateKey()
+	header := &types.Header{Number: big.NewInt(100), Coinbase: crypto.PubkeyToAddress(key.PublicKey)}
+	signer := types.NewEIP155Signer(config.ChainID)
+
+	val := randomAddress()
+	slashData, _ := p.slashABI.Pack("slash", val)
+	depositData, _ := p.validatorSetABI.Pack("deposit", val)
+
+	tests := []struct {
+		to       string
+		data     []byte
+		gasPrice int64
+		reason   string
+		args     map[string]interface{}
+	}{
+		{systemcontracts.SlashContract, slashData, 0, types.SystemTxSlash, map[string]interface{}{"validator": val}},
+		{systemcontracts.ValidatorContract, depositData, 0, types.SystemTxDeposit, map[string]interface{}{"valAddr": val, "amount": "0x3e8"}},
+		{systemcontracts.SystemRewardContract, nil, 0, types.SystemTxSystemReward, map[string]interface{}{"amount": "0x3e8"}},
+		{systemcontracts.GovHubContract, common.FromHex("e1c7392a"), 0, types.SystemTxInit, map[string]interface{}{"contract": common.HexToAddress(systemcontracts.GovHubContract)}},
+		{systemcontracts.SlashContract, slashData[:20], 0, types.SystemTxUnknown, map[string]interface{}{"input": hexutil.Bytes(slashData[:20])}},
+		{systemcontracts.SlashContract, slashData, 1, "", nil},
+	}
+	for i, tt := range tests {
+		tx, err := types.SignTx(types.NewTransaction(0, common.HexToAddress(tt.to), big.NewInt(1000), 1000000, big.NewInt(tt.gasPrice), tt.data), signer, key)
+		if err != nil {
+			t.Fatalf("test %d: failed to sign transaction: %v", i, err)
+		}
+		info, err := p.DescribeSystemTransaction(tx, header)
+		if err != nil {
+			t.Fatalf("test %d: failed to describe transaction: %v", i, err)
+		}
+		if tt.reason == "" {
+			if info != nil {
+				t.Errorf("test %d: user transaction described as %+v", i, info)
+			}
+			continue
+		}
+		if info == nil || info.Reason != tt.reason {
+			t.Errorf("test %d: reason mismatch: have %+v, want %s", i, info, tt.reason)
+			continue
+		}
+		for name, want := range tt.args {
+			if have := fmt.Sprint(info.Args[name]); have != fmt.Sprint(want) {
+				t.Errorf("test %d: argument %s mismatch: have %s, want %v", i, name, have, want)
+			}
+		}
+	}
+}
+
+func TestDoubleSignDetector(t *testing.T) {
+	detector := newDoubleSignDetector()
+	events := make(chan DoubleSignEvent, 1)
+	sub := detector.subscribe(events)
+	defer sub.Unsubscribe()
+
+	val := randomAddress()
+	header1 := &types.Header{Number: big.NewInt(100), Coinbase: val, Extra: []byte("a")}
+	header2 := &types.Header{Number: big.NewInt(100), Coinbase: val, Extra: []byte("b")}
+
+	detector.observe(header1, val)
+	detector.observe(header1, val)
+	if evidences := detector.list(); len(evidences) != 0 {
+		t.Fatalf("unexpected evidences for a single header: %d", len(evidences))
+	}
+	detector.observe(header2, val)
+	detector.observe(header2, val)
+
+	evidences := detector.list()
+	if len(evidences) != 1 {
+		t.Fatalf("evidence count mismatch: have %d, want 1", len(evidences))
+	}
+	if evidences[0].Header1.Hash() != header1.Hash() || evidences[0].Header2.Hash() != header2.Hash() {
+		t.Errorf("evidence headers mismatch")
+	}
+	var decoded types.Header
+	if err := rlp.DecodeBytes(evidences[0].Header2RLP, &decoded); err != nil || decoded.Hash() != header2.Hash() {
+		t.Errorf("evidence RLP mismatch: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Evidence.Validator != val {
+			t.Errorf("event validator mismatch: have %x, want %x", ev.Evidence.Validator, val)
+		}
+	default:
+		t.Errorf("no double sign event posted")
+	}
+}
+
+// testChainReader is a consensus.ChainReader backed by an in-memory list of headers
+// and, optionally, the blocks carrying them.
+type testChainReader struct {
+	config  *params.ChainConfig
+	headers []*types.Header
+	blocks  map[common.Hash]*types.Block
+}
+
+func (c *testChainReader) Config() *params.ChainConfig  { return c.config }
+func (c *testChainReader) CurrentHeader() *types.Header { return c.headers[len(c.headers)-1] }
+
+func (c *testChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+func (c *testChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if number < uint64(len(c.headers)) {
+		return c.headers[number]
+	}
+	return nil
+}
+
+func (c *testChainReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+func (c *testChainReader) GetBlock(hash common.Hash, number uint64) *types.Block {
+	if block := c.blocks[hash]; block != nil && block.NumberU64() == number {
+		return block
+	}
+	return nil
+}
+
+func TestVerifyHeaderReport(t *testing.T) {
+	// Create a genesis with three validators, sorted by address
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	extra := make([]byte, extraVanity)
+	for _, key := range keys {
+		extra = append(extra, crypto.PubkeyToAddress(key.PublicKey).Bytes()...)
+	}
+	genesis := &types.Header{
+		Number:     big.NewInt(0),
+		Time:       uint64(time.Now().Unix()) - 100,
+		GasLimit:   8000000,
+		Difficulty: big.NewInt(1),
+		UncleHash:  uncleHash,
+		Extra:      append(extra, make([]byte, extraSeal)...),
+	}
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Parlia: &params.ParliaConfig{Period: 3, Epoch: 200}}
+	chain := &testChainReader{config: config, headers: []*types.Header{genesis}}
+	engine := New(config, rawdb.NewMemoryDatabase(), nil)
+
+	// Seal block 1 by an out-of-turn validator claiming the in-turn difficulty
+	signer := keys[0]
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		Number:     big.NewInt(1),
+		Time:       genesis.Time + 3,
+		GasLimit:   genesis.GasLimit,
+		Difficulty: new(big.Int).Set(diffInTurn),
+		UncleHash:  uncleHash,
+		Coinbase:   crypto.PubkeyToAddress(signer.PublicKey),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+	sig, err := crypto.Sign(SealHash(header, config.ChainID).Bytes(), signer)
+	if err != nil {
+		t.Fatalf("failed to seal header: %v", err)
+	}
+	copy(header.Extra[extraVanity:], sig)
+
+	report := engine.verifyHeaderReport(chain, header)
+	if report.Valid {
+		t.Fatalf("header with wrong difficulty reported valid")
+	}
+	want := []*VerificationStep{
+		{Name: stepVerifyHeader, Passed: true},
+		{Name: stepVerifyCascadingFields, Passed: true},
+		{Name: stepVerifySeal, Passed: false, Error: errWrongDifficulty.Error()},
+	}
+	if len(report.Steps) != len(want) {
+		t.Fatalf("step count mismatch: have %d, want %d", len(report.Steps), len(want))
+	}
+	for i, step := range report.Steps {
+		if *step != *want[i] {
+			t.Errorf("step %d mismatch: have %+v, want %+v", i, step, want[i])
+		}
+	}
+	if report.Signer == nil || *report.Signer != header.Coinbase {
+		t.Errorf("signer mismatch: have %v, want %x", report.Signer, header.Coinbase)
+	}
+	if inturn := crypto.PubkeyToAddress(keys[1].PublicKey); report.InTurn == nil || *report.InTurn != inturn {
+		t.Errorf("in-turn validator mismatch: have %v, want %x", report.InTurn, inturn)
+	}
+	if report.ExpectedDifficulty.ToInt().Cmp(diffNoTurn) != 0 {
+		t.Errorf("expected difficulty mismatch: have %v, want %v", report.ExpectedDifficulty.ToInt(), diffNoTurn)
+	}
+	if report.RecentsLimit != 2 || len(report.Validators) != 3 {
+		t.Errorf("recents window mismatch: limit %d, validators %d", report.RecentsLimit, len(report.Validators))
+	}
+	if report.Timing == nil || report.Timing.Earliest != genesis.Time+3 {
+		t.Errorf("timing mismatch: %+v", report.Timing)
+	}
+}
+
+// Tests that the recent signers are reported below the height of the recents
+// window too, the window reaching back to the genesis.
+func TestVerifyHeaderReportEarlyRecents(t *testing.T) {
+	// Create a genesis with five validators, sorted by address
+	keys := make([]*ecdsa.PrivateKey, 5)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	extra := make([]byte, extraVanity)
+	for _, key := range keys {
+		extra = append(extra, crypto.PubkeyToAddress(key.PublicKey).Bytes()...)
+	}
+	genesis := &types.Header{
+		Number:     big.NewInt(0),
+		Time:       uint64(time.Now().Unix()) - 100,
+		GasLimit:   8000000,
+		Difficulty: big.NewInt(1),
+		UncleHash:  uncleHash,
+		Extra:      append(extra, make([]byte, extraSeal)...),
+	}
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Parlia: &params.ParliaConfig{Period: 3, Epoch: 200}}
+	chain := &testChainReader{config: config, headers: []*types.Header{genesis}}
+	engine := New(config, rawdb.NewMemoryDatabase(), nil)
+
+	// Seal blocks 1 and 2 by their in-turn validators
+	seal := func(parent *types.Header, key *ecdsa.PrivateKey) *types.Header {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			Time:       parent.Time + 3,
+			GasLimit:   parent.GasLimit,
+			Difficulty: new(big.Int).Set(diffInTurn),
+			UncleHash:  uncleHash,
+			Coinbase:   crypto.PubkeyToAddress(key.PublicKey),
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		sig, err := crypto.Sign(SealHash(header, config.ChainID).Bytes(), key)
+		if err != nil {
+			t.Fatalf("failed to seal header: %v", err)
+		}
+		copy(header.Extra[extraVanity:], sig)
+		return header
+	}
+	block1 := seal(genesis, keys[1])
+	chain.headers = append(chain.headers, block1)
+	block2 := seal(block1, keys[2])
+
+	report := engine.verifyHeaderReport(chain, block2)
+	if !report.Valid {
+		t.Fatalf("valid header reported invalid: %+v", report.Steps)
+	}
+	if report.RecentsLimit != 3 {
+		t.Fatalf("recents limit mismatch: have %d, want 3", report.RecentsLimit)
+	}
+	if len(report.Recents) != 1 || report.Recents[1] != block1.Coinbase {
+		t.Errorf("recents mismatch: have %v, want block 1 sealed by %x", report.Recents, block1.Coinbase)
+	}
+}
+
+// Tests that only the headers being imported are tracked for equivocation, not
+// the ones checked on demand by the header verification report.
+func TestDoubleSignImportOnly(t *testing.T) {
+	// Create a genesis with three validators
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	extra := make([]byte, extraVanity)
+	for _, key := range keys {
+		extra = append(extra, crypto.PubkeyToAddress(key.PublicKey).Bytes()...)
+	}
+	genesis := &types.Header{
+		Number:     big.NewInt(0),
+		Time:       uint64(time.Now().Unix()) - 100,
+		GasLimit:   8000000,
+		Difficulty: big.NewInt(1),
+		UncleHash:  uncleHash,
+		Extra:      append(extra, make([]byte, extraSeal)...),
+	}
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Parlia: &params.ParliaConfig{Period: 3, Epoch: 200}}
+	chain := &testChainReader{config: config, headers: []*types.Header{genesis}}
+	engine := New(config, rawdb.NewMemoryDatabase(), nil)
+
+	// Seal two different headers at height 1 by its in-turn validator
+	seal := func(gasLimit uint64) *types.Header {
+		header := &types.Header{
+			ParentHash: genesis.Hash(),
+			Number:     big.NewInt(1),
+			Time:       genesis.Time + 3,
+			GasLimit:   gasLimit,
+			Difficulty: new(big.Int).Set(diffInTurn),
+			UncleHash:  uncleHash,
+			Coinbase:   crypto.PubkeyToAddress(keys[1].PublicKey),
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		sig, err := crypto.Sign(SealHash(header, config.ChainID).Bytes(), keys[1])
+		if err != nil {
+			t.Fatalf("failed to seal header: %v", err)
+		}
+		copy(header.Extra[extraVanity:], sig)
+		return header
+	}
+	header1, header2 := seal(genesis.GasLimit), seal(genesis.GasLimit-1)
+
+	for i, header := range []*types.Header{header1, header2} {
+		if report := engine.verifyHeaderReport(chain, header); !report.Valid {
+			t.Fatalf("header %d: valid header reported invalid: %+v", i, report.Steps)
+		}
+	}
+	if evidences := engine.doubleSign.list(); len(evidences) != 0 {
+		t.Fatalf("evidence collected from the verification report: %d", len(evidences))
+	}
+	for i, header := range []*types.Header{header1, header2} {
+		if err := engine.VerifyHeader(chain, header, true); err != nil {
+			t.Fatalf("header %d: failed to verify: %v", i, err)
+		}
+	}
+	evidences := engine.doubleSign.list()
+	if len(evidences) != 1 {
+		t.Fatalf("evidence count mismatch: have %d, want 1", len(evidences))
+	}
+	if evidences[0].Header1.Hash() != header1.Hash() || evidences[0].Header2.Hash() != header2.Hash() {
+		t.Errorf("evidence headers mismatch")
+	}
+}
+
+func TestSimulateFinalize(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	validators := make([]common.Address, len(keys))
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	for i, key := range keys {
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := core.DeveloperParliaGenesisBlock(3, 200, validators).MustCommit(db)
+
+	config := rawdb.ReadChainConfig(db, genesis.Hash())
+	chain := &testChainReader{config: config, headers: []*types.Header{genesis.Header()}}
+	engine := New(config, db, nil)
+
+	// Seal block 1 out-of-turn with a single transfer paying some fees
+	signer := types.NewEIP155Signer(config.ChainID)
+	tx, _ := types.SignTx(types.NewTransaction(0, validators[2], big.NewInt(1), params.TxGas, big.NewInt(1000), nil), signer, keys[0])
+
+	statedb, err := state.New(genesis.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	sim, err := engine.SimulateFinalize(chain, genesis.Header(), validators[0], statedb, []*types.Transaction{tx})
+	if err != nil {
+		t.Fatalf("failed to simulate finalize: %v", err)
+	}
+	if !sim.Authorized || sim.InTurn || sim.Difficulty.ToInt().Cmp(diffNoTurn) != 0 {
+		t.Errorf("turn mismatch: authorized %v, in-turn %v, difficulty %v", sim.Authorized, sim.InTurn, sim.Difficulty)
+	}
+	if len(sim.Receipts) != 1 || sim.Receipts[0].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("user transaction not applied: %v", sim.Receipts)
+	}
+	fees := new(big.Int).Mul(big.NewInt(int64(params.TxGas)), big.NewInt(1000))
+	if sim.Fees.ToInt().Cmp(fees) != 0 {
+		t.Errorf("fees mismatch: have %v, want %v", sim.Fees.ToInt(), fees)
+	}
+	// The in-turn validator missed its slot and the fees are split between the
+	// system reward and validator set contracts
+	if sim.Slashed == nil || *sim.Slashed != validators[1] {
+		t.Errorf("slashed validator mismatch: have %v, want %x", sim.Slashed, validators[1])
+	}
+	reward := new(big.Int).Rsh(fees, systemRewardPercent)
+	deltas := map[string]*big.Int{
+		systemcontracts.SystemRewardContract: reward,
+		systemcontracts.ValidatorContract:    new(big.Int).Sub(fees, reward),
+	}
+	for contract, want := range deltas {
+		if have := sim.BalanceDeltas[common.HexToAddress(contract)]; have == nil || have.ToInt().Cmp(want) != 0 {
+			t.Errorf("balance delta of %s mismatch: have %v, want %v", contract, have, want)
+		}
+	}
+	reasons := make(map[string]int)
+	for _, tx := range sim.SystemTxs {
+		reasons[tx.Reason]++
+	}
+	if reasons[types.SystemTxSlash] != 1 || reasons[types.SystemTxDeposit] != 1 || reasons[types.SystemTxSystemReward] != 1 {
+		t.Errorf("unexpected system transactions: %v", reasons)
+	}
+	// The simulation must not touch the canonical state
+	if statedb, _ := state.New(genesis.Root(), state.NewDatabase(db), nil); statedb.GetNonce(validators[0]) != 0 {
+		t.Errorf("simulation leaked into the genesis state")
+	}
+}
+
+// Tests that the system contract upgrades scheduled at the height of the
+// simulated block are applied ahead of its transactions.
+func TestSimulateFinalizeUpgrade(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	validators := make([]common.Address, len(keys))
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	for i, key := range keys {
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := core.DeveloperParliaGenesisBlock(3, 200, validators).MustCommit(db)
+
+	// Schedule an upgrade of the gov hub contract at block 1
+	code := common.FromHex("0x6001600055")
+	contract := common.HexToAddress(systemcontracts.GovHubContract)
+
+	config := rawdb.ReadChainConfig(db, genesis.Hash())
+	config.BSCForks = map[string]*big.Int{params.LightClientGasFork: big.NewInt(1)}
+	config.SystemContractUpgrades = []*params.SystemContractUpgrade{{
+		Name:    "test",
+		Fork:    params.LightClientGasFork,
+		Configs: []*params.SystemContractUpgradeConfig{{ContractAddr: contract, CodeHash: crypto.Keccak256Hash(code), Code: code}},
+	}}
+	chain := &testChainReader{config: config, headers: []*types.Header{genesis.Header()}}
+	engine := New(config, db, nil)
+
+	statedb, err := state.New(genesis.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	if _, err := engine.SimulateFinalize(chain, genesis.Header(), validators[1], statedb, nil); err != nil {
+		t.Fatalf("failed to simulate finalize: %v", err)
+	}
+	if have, want := statedb.GetCodeHash(contract), crypto.Keccak256Hash(code); have != want {
+		t.Errorf("contract code hash mismatch: have %x, want %x", have, want)
+	}
+}
+
+func TestBackOffSchedule(t *testing.T) {
+	validators := make([]common.Address, 3)
+	for i := range validators {
+		key, _ := crypto.GenerateKey()
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i].Bytes(), validators[j].Bytes()) < 0
+	})
+	extra := make([]byte, extraVanity)
+	for _, val := range validators {
+		extra = append(extra, val.Bytes()...)
+	}
+	genesis := &types.Header{
+		Number:     big.NewInt(0),
+		Time:       uint64(time.Now().Unix()),
+		GasLimit:   8000000,
+		Difficulty: big.NewInt(1),
+		UncleHash:  uncleHash,
+		Extra:      append(extra, make([]byte, extraSeal)...),
+	}
+	config := &params.ChainConfig{ChainID: big.NewInt(1), RamanujanBlock: big.NewInt(0), Parlia: &params.ParliaConfig{Period: 3, Epoch: 200}}
+	chain := &testChainReader{config: config, headers: []*types.Header{genesis}}
+	engine := New(config, rawdb.NewMemoryDatabase(), nil)
+
+	if _, err := engine.backOffSchedule(chain, genesis, maxBackOffScheduleBlocks+1); err == nil {
+		t.Fatalf("oversized schedule accepted")
+	}
+	schedules, err := engine.backOffSchedule(chain, genesis, 4)
+	if err != nil {
+		t.Fatalf("failed to compute schedule: %v", err)
+	}
+	if len(schedules) != 4 {
+		t.Fatalf("schedule length mismatch: have %d, want 4", len(schedules))
+	}
+	// Every height is assumed sealed in-turn, so the previous in-turn validator
+	// is always the one excluded by the recents window
+	for i, schedule := range schedules {
+		number := uint64(i + 1)
+		if schedule.Number != number || schedule.ParentTime != genesis.Time+uint64(i)*3 {
+			t.Errorf("height %d: number %d, parent time %d", number, schedule.Number, schedule.ParentTime)
+		}
+		if inturn := validators[number%3]; schedule.InTurn != inturn {
+			t.Errorf("height %d: in-turn mismatch: have %x, want %x", number, schedule.InTurn, inturn)
+		}
+		steps := make(map[uint64]bool)
+		for j, backOff := range schedule.Validators {
+			recent := number > 1 && backOff.Validator == validators[(number-1)%3]
+			if backOff.SignedRecently != recent {
+				t.Errorf("height %d, validator %d: signed recently %v, want %v", number, j, backOff.SignedRecently, recent)
+			}
+			if backOff.InTurn {
+				if backOff.Step != nil || backOff.BackOffTime != 0 {
+					t.Errorf("height %d: in-turn validator backs off: %+v", number, backOff)
+				}
+			} else {
+				if backOff.Step == nil || backOff.BackOffTime != initialBackOffTime+*backOff.Step*wiggleTime {
+					t.Errorf("height %d, validator %d: back-off mismatch: %+v", number, j, backOff)
+				}
+				if backOff.Step != nil {
+					steps[*backOff.Step] = true
+				}
+			}
+			if want := schedule.ParentTime + 3 + backOff.BackOffTime; !recent && backOff.Earliest != want {
+				t.Errorf("height %d, validator %d: earliest mismatch: have %d, want %d", number, j, backOff.Earliest, want)
+			}
+			if recent && backOff.Earliest != 0 {
+				t.Errorf("height %d, validator %d: recent signer may seal at %d", number, j, backOff.Earliest)
+			}
+		}
+		if len(steps) != 2 {
+			t.Errorf("height %d: out-of-turn validators share a back-off step", number)
+		}
+	}
+	// The schedule must be deterministic
+	again, err := engine.backOffSchedule(chain, genesis, 4)
+	if err != nil {
+		t.Fatalf("failed to recompute schedule: %v", err)
+	}
+	if !reflect.DeepEqual(schedules, again) {
+		t.Errorf("schedule not deterministic")
+	}
+}
+
+func TestIsDeveloperChain(t *testing.T) {
+	dev := core.DeveloperParliaGenesisBlock(3, 200, []common.Address{{0x01}}).Config
+	if !(&Parlia{chainConfig: dev}).isDeveloperChain() {
+		t.Errorf("developer network not detected")
+	}
+	// Only the developer genesis pre-initializes the system contracts, the real
+	// networks must keep running their init at the first block
+	for _, config := range []*params.ChainConfig{params.MainnetChainConfig, params.ChapelChainConfig, params.RialtoChainConfig} {
+		if (&Parlia{chainConfig: config}).isDeveloperChain() {
+			t.Errorf("chain %v detected as developer network", config.ChainID)
+		}
+	}
+}
+
+// Tests that the liveness report accounts the in-turn, sealed and missed slots of
+// every validator over a known chain, along with the slashes of the missed ones.
+func TestGetValidatorStats(t *testing.T) {
+	// Create a genesis with three validators, sorted by address
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	vals := make([]common.Address, len(keys))
+	extra := make([]byte, extraVanity)
+	for i, key := range keys {
+		vals[i] = crypto.PubkeyToAddress(key.PublicKey)
+		extra = append(extra, vals[i].Bytes()...)
+	}
+	genesis := &types.Header{
+		Number:     big.NewInt(0),
+		Time:       uint64(time.Now().Unix()) - 100,
+		GasLimit:   8000000,
+		Difficulty: big.NewInt(1),
+		UncleHash:  uncleHash,
+		Extra:      append(extra, make([]byte, extraSeal)...),
+	}
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Parlia: &params.ParliaConfig{Period: 3, Epoch: 200}}
+	chain := &testChainReader{config: config, headers: []*types.Header{genesis}, blocks: make(map[common.Hash]*types.Block)}
+	engine := New(config, rawdb.NewMemoryDatabase(), nil)
+	signer := types.NewEIP155Signer(config.ChainID)
+
+	// seal appends a block sealed by the given validator, carrying the given
+	// transactions
+	seal := func(key *ecdsa.PrivateKey, difficulty *big.Int, txs types.Transactions) {
+		parent := chain.CurrentHeader()
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			Time:       parent.Time + 3,
+			GasLimit:   parent.GasLimit,
+			Difficulty: difficulty,
+			UncleHash:  uncleHash,
+			TxHash:     types.DeriveSha(txs),
+			Coinbase:   crypto.PubkeyToAddress(key.PublicKey),
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		sig, err := crypto.Sign(SealHash(header, config.ChainID).Bytes(), key)
+		if err != nil {
+			t.Fatalf("failed to seal header: %v", err)
+		}
+		copy(header.Extra[extraVanity:], sig)
+
+		chain.headers = append(chain.headers, header)
+		chain.blocks[header.Hash()] = types.NewBlockWithHeader(header).WithBody(txs, nil)
+	}
+	// The validators are in-turn at the blocks 3, 1 and 2 respectively:
+	//   - block 1 is sealed by its in-turn validator
+	//   - block 2 is sealed by the first validator, slashing the in-turn one
+	//   - block 3 is sealed by the second validator, not slashing the in-turn one
+	slashData, _ := engine.slashABI.Pack("slash", vals[2])
+	slash, err := types.SignTx(types.NewTransaction(0, common.HexToAddress(systemcontracts.SlashContract), big.NewInt(0), 1000000, big.NewInt(0), slashData), signer, keys[0])
+	if err != nil {
+		t.Fatalf("failed to sign slash transaction: %v", err)
+	}
+	seal(keys[1], diffInTurn, nil)
+	seal(keys[0], diffNoTurn, types.Transactions{slash})
+	seal(keys[1], diffNoTurn, nil)
+
+	api := &API{chain: chain, parlia: engine}
+	from := rpc.BlockNumber(1)
+	result, err := api.GetValidatorStats(&from, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve validator stats: %v", err)
+	}
+	if result.FromBlock != 1 || result.ToBlock != 3 {
+		t.Errorf("block range mismatch: have [%d, %d], want [1, 3]", result.FromBlock, result.ToBlock)
+	}
+	want := map[common.Address]*ValidatorStats{
+		vals[0]: {InTurnSlots: 1, Sealed: 1, SealedOutOfTurn: 1, MissedSlots: 1, MissedBlocks: []uint64{3}, SlashedBlocks: []uint64{}},
+		vals[1]: {InTurnSlots: 1, Sealed: 2, SealedInTurn: 1, SealedOutOfTurn: 1, MissedBlocks: []uint64{}, SlashedBlocks: []uint64{}},
+		vals[2]: {InTurnSlots: 1, MissedSlots: 1, MissedBlocks: []uint64{2}, SlashedBlocks: []uint64{2}},
+	}
+	if len(result.Validators) != len(want) {
+		t.Fatalf("validator count mismatch: have %d, want %d", len(result.Validators), len(want))
+	}
+	for i, val := range vals {
+		if have := result.Validators[val]; !reflect.DeepEqual(have, want[val]) {
+			t.Errorf("validator %d stats mismatch: have %+v, want %+v", i, have, want[val])
+		}
+	}
+	// Ranges past the head are rejected
+	to := rpc.BlockNumber(4)
+	if _, err := api.GetValidatorStats(&from, &to); err != errUnknownBlock {
+		t.Errorf("range past the head: have %v, want %v", err, errUnknownBlock)
+	}
+}
+
+func TestValidatorSetHistory(t *testing.T) {
+	vals := make([]common.Address, 4)
+	for i := range vals {
+		vals[i] = common.BytesToAddress([]byte{byte(i + 1)})
+	}
+	// Announce a validator set change at the epoch blocks 4 and 8
+	sets := map[uint64][]common.Address{
+		0: {vals[0], vals[1], vals[2]},
+		2: {vals[0], vals[1], vals[2]},
+		4: {vals[0], vals[1], vals[3]},
+		6: {vals[0], vals[1], vals[3]},
+		8: {vals[0]},
+	}
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Parlia: &params.ParliaConfig{Period: 3, Epoch: 2}}
+	chain := &testChainReader{config: config}
+	for number := uint64(0); number <= 9; number++ {
+		extra := make([]byte, extraVanity)
+		for _, val := range sets[number] {
+			extra = append(extra, val.Bytes()...)
+		}
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Extra: append(extra, make([]byte, extraSeal)...)}
+		if number > 0 {
+			header.ParentHash = chain.headers[number-1].Hash()
+		}
+		chain.headers = append(chain.headers, header)
+	}
+	db := rawdb.NewMemoryDatabase()
+	engine := New(config, db, nil)
+
+	tests := []struct {
+		from, to uint64
+		want     []*ValidatorSetTransition
+	}{
+		{0, 1, []*ValidatorSetTransition{
+			{Number: 0, Effective: 0, Validators: sets[0], Added: sets[0]},
+		}},
+		{3, 9, []*ValidatorSetTransition{
+			{Number: 4, Effective: 5, Validators: sets[4], Added: []common.Address{vals[3]}, Removed: []common.Address{vals[2]}},
+			{Number: 8, Effective: 9, Validators: sets[8], Removed: []common.Address{vals[1], vals[3]}},
+		}},
+		{5, 7, nil},
+	}
+	for i, tt := range tests {
+		for _, transition := range tt.want {
+			transition.Hash = chain.headers[transition.Number].Hash()
+		}
+		transitions, err := engine.validatorSetHistory(chain, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("test %d: failed to retrieve history: %v", i, err)
+		}
+		if !reflect.DeepEqual(transitions, tt.want) {
+			t.Errorf("test %d: history mismatch:\nhave %+v\nwant %+v", i, transitions, tt.want)
+		}
+	}
+	// The walked epoch blocks are indexed, and served from the index afterwards
+	header := chain.headers[4]
+	if have := rawdb.ReadValidatorSet(db, 4, header.Hash()); !reflect.DeepEqual(have, sets[4]) {
+		t.Errorf("indexed validator set mismatch: have %v, want %v", have, sets[4])
+	}
+	rawdb.WriteValidatorSet(db, 4, header.Hash(), sets[2])
+	transitions, err := engine.validatorSetHistory(chain, 4, 4)
+	if err != nil {
+		t.Fatalf("failed to retrieve indexed history: %v", err)
+	}
+	if len(transitions) != 0 {
+		t.Errorf("history not served from the index: have %+v", transitions)
+	}
+	// Invalid and unavailable ranges are rejected
+	if _, err := engine.validatorSetHistory(chain, 4, 3); err == nil {
+		t.Errorf("inverted range accepted")
+	}
+	if _, err := engine.validatorSetHistory(chain, 0, 2*maxValidatorSetHistoryEpochs); err == nil {
+		t.Errorf("range exceeding the epoch limit accepted")
+	}
+	if _, err := engine.validatorSetHistory(chain, 8, 10); err != errUnknownBlock {
+		t.Errorf("range past the head: have %v, want %v", err, errUnknownBlock)
+	}
+}
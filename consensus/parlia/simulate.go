@@ -0,0 +1,165 @@
+package parlia
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// SimulatedSystemTx is a system transaction Parlia would inject into a block.
+type SimulatedSystemTx struct {
+	*types.SystemTxInfo
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value"`
+	Input   hexutil.Bytes  `json:"input"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+}
+
+// FinalizeSimulation is the outcome of finalizing a hypothetical block.
+type FinalizeSimulation struct {
+	Number     uint64         `json:"number"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Coinbase   common.Address `json:"coinbase"`
+	Authorized bool           `json:"authorized"` // Whether the coinbase may seal the block at all
+	InTurn     bool           `json:"inTurn"`     // Whether the coinbase is the in-turn validator
+	Difficulty *hexutil.Big   `json:"difficulty"`
+	Time       uint64         `json:"time"`      // Earliest timestamp the coinbase may seal the block at
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`   // Gas used by the user and system transactions
+	Fees       *hexutil.Big   `json:"fees"`      // Fees collected from the user transactions
+	StateRoot  common.Hash    `json:"stateRoot"` // State root of the finalized block
+
+	Receipts      []*types.Receipt                `json:"receipts"`          // Receipts of the user transactions
+	SystemTxs     []*SimulatedSystemTx            `json:"systemTxs"`         // System transactions injected by Finalize
+	BalanceDeltas map[common.Address]*hexutil.Big `json:"balanceDeltas"`     // Balance changes of the reward receiving system contracts
+	Slashed       *common.Address                 `json:"slashed,omitempty"` // Validator slashed for missing its turn
+}
+
+// simulator returns a copy of the engine that seals on behalf of the given
+// validator, leaving its system transactions unsigned.
+func (p *Parlia) simulator(val common.Address) *Parlia {
+	return &Parlia{
+		chainConfig:     p.chainConfig,
+		config:          p.config,
+		db:              p.db,
+		recentSnaps:     p.recentSnaps,
+		signatures:      p.signatures,
+		signer:          p.signer,
+		doubleSign:      p.doubleSign,
+		ethAPI:          p.ethAPI,
+		validatorSetABI: p.validatorSetABI,
+		slashABI:        p.slashABI,
+		val:             val,
+		signTxFn: func(_ accounts.Account, tx *types.Transaction, _ *big.Int) (*types.Transaction, error) {
+			return tx, nil
+		},
+	}
+}
+
+// SimulateFinalize runs FinalizeAndAssemble for a hypothetical block sealed by
+// coinbase on top of parent, after applying the given user transactions. The
+// given state must be a throwaway copy of the parent state, it is modified.
+func (p *Parlia) SimulateFinalize(chain consensus.ChainReader, parent *types.Header, coinbase common.Address,
+	statedb *state.StateDB, txs []*types.Transaction) (sim *FinalizeSimulation, err error) {
+	snap, err := p.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	engine := p.simulator(coinbase)
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Coinbase:   coinbase,
+		Difficulty: CalcDifficulty(snap, coinbase),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+	header.Time = engine.blockTimeForRamanujanFork(snap, header, parent)
+
+	// Upgrade the system contracts scheduled at this height, as the state processor does
+	systemcontracts.UpgradeBuildInSystemContract(p.chainConfig, header.Number, statedb)
+
+	_, authorized := snap.Validators[coinbase]
+	sim = &FinalizeSimulation{
+		Number:        header.Number.Uint64(),
+		ParentHash:    header.ParentHash,
+		Coinbase:      coinbase,
+		Authorized:    authorized,
+		InTurn:        snap.inturn(coinbase),
+		Difficulty:    (*hexutil.Big)(header.Difficulty),
+		Time:          header.Time,
+		Receipts:      make([]*types.Receipt, 0, len(txs)),
+		BalanceDeltas: make(map[common.Address]*hexutil.Big),
+	}
+	contracts := []common.Address{
+		common.HexToAddress(systemcontracts.SystemRewardContract),
+		common.HexToAddress(systemcontracts.ValidatorContract),
+	}
+	balances := make(map[common.Address]*big.Int)
+	for _, contract := range contracts {
+		balances[contract] = new(big.Int).Set(statedb.GetBalance(contract))
+	}
+	// Apply the user transactions, collecting their fees like the state processor does
+	var (
+		gp = new(core.GasPool).AddGas(header.GasLimit)
+		cx = chainContext{Chain: chain, parlia: p}
+	)
+	for i, tx := range txs {
+		statedb.Prepare(tx.Hash(), common.Hash{}, i)
+		receipt, err := core.ApplyTransaction(p.chainConfig, cx, &coinbase, gp, statedb, header, tx, &header.GasUsed, vm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d [%x]: %v", i, tx.Hash(), err)
+		}
+		sim.Receipts = append(sim.Receipts, receipt)
+	}
+	sim.Fees = (*hexutil.Big)(new(big.Int).Set(statedb.GetBalance(consensus.SystemAddress)))
+
+	// Finalize the block, turning the panics of the sealing path into errors
+	defer func() {
+		if r := recover(); r != nil {
+			sim, err = nil, fmt.Errorf("finalize failed: %v", r)
+		}
+	}()
+	userTxs := make([]*types.Transaction, len(txs))
+	copy(userTxs, txs)
+	receipts := make([]*types.Receipt, len(sim.Receipts))
+	copy(receipts, sim.Receipts)
+
+	block, receipts, err := engine.FinalizeAndAssemble(chain, header, statedb, userTxs, nil, receipts)
+	if err != nil {
+		return nil, err
+	}
+	for i, tx := range block.Transactions()[len(txs):] {
+		info, err := p.describeSystemCall(*tx.To(), tx.Value(), tx.Data())
+		if err != nil {
+			return nil, err
+		}
+		sim.SystemTxs = append(sim.SystemTxs, &SimulatedSystemTx{
+			SystemTxInfo: info,
+			To:           *tx.To(),
+			Value:        (*hexutil.Big)(tx.Value()),
+			Input:        tx.Data(),
+			GasUsed:      hexutil.Uint64(receipts[len(txs)+i].GasUsed),
+		})
+		if info.Reason == types.SystemTxSlash {
+			if val, ok := info.Args["validator"].(common.Address); ok {
+				sim.Slashed = &val
+			}
+		}
+	}
+	for _, contract := range contracts {
+		sim.BalanceDeltas[contract] = (*hexutil.Big)(new(big.Int).Sub(statedb.GetBalance(contract), balances[contract]))
+	}
+	sim.GasUsed = hexutil.Uint64(header.GasUsed)
+	sim.StateRoot = header.Root
+	return sim, nil
+}
@@ -21,3 +21,15 @@ func TestValidatorSetSort(t *testing.T) {
 		assert.True(t, bytes.Compare(validators[i][:], validators[i+1][:]) < 0)
 	}
 }
+
+func TestDiffValidators(t *testing.T) {
+	a, b, c := randomAddress(), randomAddress(), randomAddress()
+
+	added, removed := diffValidators([]common.Address{a, b}, []common.Address{b, c})
+	assert.Equal(t, []common.Address{c}, added)
+	assert.Equal(t, []common.Address{a}, removed)
+
+	added, removed = diffValidators(nil, []common.Address{a})
+	assert.Equal(t, []common.Address{a}, added)
+	assert.Empty(t, removed)
+}
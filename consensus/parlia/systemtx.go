@@ -0,0 +1,75 @@
+package parlia
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DescribeSystemTransaction implements consensus.PoSA, decoding why a system
+// transaction was injected into the block of the given header. It returns nil
+// for user transactions.
+func (p *Parlia) DescribeSystemTransaction(tx *types.Transaction, header *types.Header) (*types.SystemTxInfo, error) {
+	if isSystemTx, err := p.IsSystemTransaction(tx, header); err != nil || !isSystemTx {
+		return nil, err
+	}
+	return p.describeSystemCall(*tx.To(), tx.Value(), tx.Data())
+}
+
+// describeSystemCall decodes why Parlia calls a system contract with the given
+// value and input.
+func (p *Parlia) describeSystemCall(to common.Address, value *big.Int, data []byte) (*types.SystemTxInfo, error) {
+	// distributeToSystem is a plain transfer to the system reward contract
+	if len(data) == 0 && to == common.HexToAddress(systemcontracts.SystemRewardContract) {
+		return &types.SystemTxInfo{
+			Reason: types.SystemTxSystemReward,
+			Args:   map[string]interface{}{"amount": (*hexutil.Big)(value)},
+		}, nil
+	}
+	unknown := &types.SystemTxInfo{
+		Reason: types.SystemTxUnknown,
+		Args:   map[string]interface{}{"input": hexutil.Bytes(data)},
+	}
+	if len(data) < 4 {
+		return unknown, nil
+	}
+	// The slash contract has its own ABI, every other system call (deposit to the
+	// validator set contract and init of all contracts) matches the validator set one
+	contractABI := p.validatorSetABI
+	if to == common.HexToAddress(systemcontracts.SlashContract) {
+		contractABI = p.slashABI
+	}
+	method, err := contractABI.MethodById(data[:4])
+	if err != nil {
+		return unknown, nil
+	}
+	// Malformed arguments are still a system call, just not one we can decode
+	values, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return unknown, nil
+	}
+	info := &types.SystemTxInfo{Args: make(map[string]interface{})}
+	for i, input := range method.Inputs {
+		if n, ok := values[i].(*big.Int); ok {
+			info.Args[input.Name] = (*hexutil.Big)(n)
+		} else {
+			info.Args[input.Name] = values[i]
+		}
+	}
+	switch method.RawName {
+	case "deposit":
+		info.Reason = types.SystemTxDeposit
+		info.Args["amount"] = (*hexutil.Big)(value)
+	case "slash":
+		info.Reason = types.SystemTxSlash
+	case "init":
+		info.Reason = types.SystemTxInit
+		info.Args["contract"] = to
+	default:
+		info.Reason = method.RawName
+	}
+	return info, nil
+}
@@ -0,0 +1,142 @@
+package parlia
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Names of the verification steps a header goes through.
+const (
+	stepVerifyHeader          = "verifyHeader"
+	stepVerifyCascadingFields = "verifyCascadingFields"
+	stepVerifySeal            = "verifySeal"
+	stepVerifyEpoch           = "finalizeEpoch"
+)
+
+// VerificationStep is the outcome of a single verification step.
+type VerificationStep struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BlockTimeReport details the timestamp rules of the Ramanujan fork.
+type BlockTimeReport struct {
+	Ramanujan   bool   `json:"ramanujan"`   // Whether the back-off rules apply at this height
+	ParentTime  uint64 `json:"parentTime"`  // Timestamp of the parent block
+	Period      uint64 `json:"period"`      // Minimum number of seconds between blocks
+	BackOffTime uint64 `json:"backOffTime"` // Back-off of the coinbase on top of the period
+	Earliest    uint64 `json:"earliest"`    // Earliest timestamp the header may carry
+	Time        uint64 `json:"time"`        // Timestamp carried by the header
+}
+
+// HeaderVerificationReport explains step by step how a header fares against the
+// Parlia consensus rules, along with the data each step based its decision on.
+type HeaderVerificationReport struct {
+	Number     uint64              `json:"number"`
+	Hash       common.Hash         `json:"hash"`
+	ParentHash common.Hash         `json:"parentHash"`
+	Valid      bool                `json:"valid"`
+	Steps      []*VerificationStep `json:"steps"`
+
+	Coinbase           common.Address  `json:"coinbase"`
+	Signer             *common.Address `json:"signer,omitempty"`             // Validator recovered from the seal
+	InTurn             *common.Address `json:"inTurn,omitempty"`             // Validator expected to seal the block in-turn
+	Difficulty         *hexutil.Big    `json:"difficulty"`                   // Difficulty carried by the header
+	ExpectedDifficulty *hexutil.Big    `json:"expectedDifficulty,omitempty"` // Difficulty matching the turn-ness of the signer
+
+	Validators   []common.Address          `json:"validators,omitempty"`   // Validators allowed to seal the block
+	Recents      map[uint64]common.Address `json:"recents,omitempty"`      // Recent signers not allowed to seal the block
+	RecentsLimit uint64                    `json:"recentsLimit,omitempty"` // Number of blocks a signer must wait before sealing again
+
+	Timing *BlockTimeReport `json:"timing,omitempty"`
+
+	ExpectedValidators hexutil.Bytes `json:"expectedValidators,omitempty"` // Validator list the epoch block must carry
+	ActualValidators   hexutil.Bytes `json:"actualValidators,omitempty"`   // Validator list the epoch block carries
+}
+
+// verifyHeaderReport runs the steps of verifyHeader one by one, followed by the
+// epoch check of Finalize, and reports how each of them fared. Unlike
+// verifyHeader, a failing step does not prevent the following ones from running.
+func (p *Parlia) verifyHeaderReport(chain consensus.ChainReader, header *types.Header) *HeaderVerificationReport {
+	report := &HeaderVerificationReport{
+		Number:     header.Number.Uint64(),
+		Hash:       header.Hash(),
+		ParentHash: header.ParentHash,
+		Valid:      true,
+		Coinbase:   header.Coinbase,
+		Difficulty: (*hexutil.Big)(header.Difficulty),
+	}
+	check := func(name string, err error) {
+		step := &VerificationStep{Name: name, Passed: err == nil}
+		if err != nil {
+			step.Error = err.Error()
+			report.Valid = false
+		}
+		report.Steps = append(report.Steps, step)
+	}
+	check(stepVerifyHeader, p.verifyStandaloneFields(chain, header))
+	check(stepVerifyCascadingFields, p.verifyCascadingFields(chain, header, nil))
+
+	// The genesis block is not sealed and has no parent to check against
+	number := report.Number
+	if number == 0 {
+		return report
+	}
+	check(stepVerifySeal, p.verifySeal(chain, header, nil))
+
+	if signer, err := ecrecover(header, p.signatures, p.chainConfig.ChainID); err == nil {
+		report.Signer = &signer
+	}
+	if snap, err := p.snapshot(chain, number-1, header.ParentHash, nil); err == nil {
+		inturn := snap.supposeValidator()
+		report.InTurn = &inturn
+		report.Validators = snap.validators()
+
+		signer := header.Coinbase
+		if report.Signer != nil {
+			signer = *report.Signer
+		}
+		report.ExpectedDifficulty = (*hexutil.Big)(CalcDifficulty(snap, signer))
+
+		report.RecentsLimit = uint64(len(snap.Validators)/2 + 1)
+		report.Recents = make(map[uint64]common.Address)
+		for seen, recent := range snap.Recents {
+			if number < report.RecentsLimit || seen > number-report.RecentsLimit {
+				report.Recents[seen] = recent
+			}
+		}
+		if parent := chain.GetHeader(header.ParentHash, number-1); parent != nil {
+			timing := &BlockTimeReport{
+				Ramanujan:  p.chainConfig.IsRamanujan(header.Number),
+				ParentTime: parent.Time,
+				Period:     p.config.Period,
+				Time:       header.Time,
+			}
+			if timing.Ramanujan {
+				timing.BackOffTime = backOffTime(snap, header.Coinbase)
+			}
+			timing.Earliest = parent.Time + p.config.Period + timing.BackOffTime
+			report.Timing = timing
+		}
+	}
+	// Epoch blocks must carry the validator set of the contract at their parent
+	if number%p.config.Epoch == 0 {
+		if len(header.Extra) >= extraVanity+extraSeal {
+			report.ActualValidators = header.Extra[extraVanity : len(header.Extra)-extraSeal]
+		}
+		expected, err := p.epochValidatorsBytes(header.ParentHash)
+		if err == nil {
+			report.ExpectedValidators = expected
+			if !bytes.Equal(report.ActualValidators, expected) {
+				err = errMismatchingEpochValidators
+			}
+		}
+		check(stepVerifyEpoch, err)
+	}
+	return report
+}
@@ -117,6 +117,7 @@ const (
 type CacheConfig struct {
 	TrieCleanLimit      int           // Memory allowance (MB) to use for caching trie nodes in memory
 	TrieCleanNoPrefetch bool          // Whether to disable heuristic state prefetching for followup blocks
+	TrieAccessPrefetch  bool          // Whether to prefetch the state blocks likely access, derived from their transactions
 	TrieDirtyLimit      int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieDirtyDisabled   bool          // Whether to disable trie write caching and GC altogether (archive node)
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
@@ -177,11 +178,13 @@ type BlockChain struct {
 	procInterrupt int32          // interrupt signaler for block processing
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 
-	engine     consensus.Engine
-	validator  Validator  // Block and state validator interface
-	prefetcher Prefetcher // Block state prefetcher interface
-	processor  Processor  // Block transaction processor interface
-	vmConfig   vm.Config
+	engine           consensus.Engine
+	validator        Validator         // Block and state validator interface
+	prefetcher       Prefetcher        // Block state prefetcher interface
+	accessPrefetcher *accessPrefetcher // Block state prefetcher deriving the accesses from the transactions, if enabled
+	processor        Processor         // Block transaction processor interface
+	vmConfig         vm.Config
+	tracer           vm.Tracer // Tracer of the imported blocks' execution, if any
 
 	badBlocks       *lru.Cache                     // Bad block cache
 	shouldPreserve  func(*types.Block) bool        // Function used to determine whether should preserve the given block.
@@ -229,6 +232,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
+	if cacheConfig.TrieAccessPrefetch {
+		bc.accessPrefetcher = newAccessPrefetcher(chainConfig, bc)
+	}
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
 
 	var err error
@@ -322,6 +328,26 @@ func (bc *BlockChain) GetVMConfig() *vm.Config {
 	return &bc.vmConfig
 }
 
+// SetBlockTracer sets a tracer to run the execution of the imported blocks with,
+// nil to stop tracing. Unlike the tracer of the VM config, it is only invoked by
+// the block import, one transaction at a time.
+func (bc *BlockChain) SetBlockTracer(tracer vm.Tracer) {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	bc.tracer = tracer
+}
+
+// SetParallelProcessing makes the block import execute the transactions of the
+// blocks optimistically in parallel with the given number of workers, or one
+// after the other if there are less than two.
+func (bc *BlockChain) SetParallelProcessing(workers int) {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	bc.processor = NewParallelStateProcessor(bc.chainConfig, bc, bc.engine, workers)
+}
+
 // empty returns an indicator whether the blockchain is empty.
 // Note, it's a special case that we connect a non-empty ancient
 // database with an empty node, so that we can plugin the ancient
@@ -1709,14 +1735,27 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 				}(time.Now(), followup, throwaway, &followupInterrupt)
 			}
 		}
+		// Load the state the block likely accesses concurrently with processing it
+		var accessInterrupt uint32
+		if bc.accessPrefetcher != nil {
+			bc.accessPrefetcher.Prefetch(block, parent.Root, &accessInterrupt)
+		}
 		// Process block using the parent state as reference point
 		substart := time.Now()
-		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		vmConfig := bc.vmConfig
+		if bc.tracer != nil {
+			vmConfig.Debug, vmConfig.Tracer = true, bc.tracer
+		}
+		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, vmConfig)
+		atomic.StoreUint32(&accessInterrupt, 1)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
 		}
+		if bc.accessPrefetcher != nil {
+			bc.accessPrefetcher.Learn(block, statedb)
+		}
 		// Update the metrics touched during block processing
 		accountReadTimer.Update(statedb.AccountReads)                 // Account reads are complete, we can mark them
 		storageReadTimer.Update(statedb.StorageReads)                 // Storage reads are complete, we can mark them
@@ -223,6 +223,12 @@ func gatherForks(config *params.ChainConfig) []uint64 {
 			forks = append(forks, rule.Uint64())
 		}
 	}
+	// Gather the BSC forks scheduled by name, duplicates of the above are dropped below
+	for _, fork := range params.BSCForkTable {
+		if rule := config.BSCForkBlock(fork.Name); rule != nil {
+			forks = append(forks, rule.Uint64())
+		}
+	}
 	// Sort the fork block numbers to permit chronologival XOR
 	for i := 0; i < len(forks); i++ {
 		for j := i + 1; j < len(forks); j++ {
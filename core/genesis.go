@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -160,6 +161,11 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil {
+		if err := systemcontracts.LoadUpgradeManifest(genesis.Config); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
 	// Just commit the new block if there is no stored genesis block.
 	stored := rawdb.ReadCanonicalHash(db, 0)
 	systemcontracts.GenesisHash = stored
@@ -421,6 +427,52 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	}
 }
 
+// DeveloperParliaGenesisBlock returns the 'geth --dev.parlia' genesis block, with
+// the system contracts deployed and the given validators both pre-funded and set
+// in the validator set contract.
+func DeveloperParliaGenesisBlock(period uint64, epoch uint64, validators []common.Address) *Genesis {
+	// Start from the clique developer config, swapping the consensus engine
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = nil
+	config.MuirGlacierBlock = big.NewInt(0)
+	config.RamanujanBlock = big.NewInt(0)
+	config.NielsBlock = big.NewInt(0)
+	config.Parlia = &params.ParliaConfig{Period: period, Epoch: epoch}
+
+	// Parlia expects the validators in ascending order in the extra-data
+	sorted := make([]common.Address, len(validators))
+	copy(sorted, validators)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+
+	extra := make([]byte, 32)
+	for _, val := range sorted {
+		extra = append(extra, val[:]...)
+	}
+	extra = append(extra, make([]byte, crypto.SignatureLength)...)
+
+	// Assemble and return the genesis with the system contracts and validators pre-funded
+	alloc := make(GenesisAlloc)
+	for addr, code := range systemcontracts.DevGenesisCode() {
+		alloc[addr] = GenesisAccount{Code: code, Balance: new(big.Int)}
+	}
+	validatorSet := common.HexToAddress(systemcontracts.ValidatorContract)
+	alloc[validatorSet] = GenesisAccount{
+		Code:    alloc[validatorSet].Code,
+		Storage: systemcontracts.DevValidatorSetStorage(sorted),
+		Balance: new(big.Int),
+	}
+	for _, val := range sorted {
+		alloc[val] = GenesisAccount{Balance: new(big.Int).Lsh(big.NewInt(1), 248)}
+	}
+	return &Genesis{
+		Config:     &config,
+		ExtraData:  extra,
+		GasLimit:   40000000,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	var p []struct{ Addr, Balance *big.Int }
 	if err := rlp.NewStream(strings.NewReader(data), 0).Decode(&p); err != nil {
@@ -41,6 +41,19 @@ func TestDefaultGenesisBlock(t *testing.T) {
 	}
 }
 
+// Tests that the developer Parlia genesis enables its forks in an order the node
+// accepts, otherwise the developer network would not start.
+func TestDeveloperParliaGenesisForkOrder(t *testing.T) {
+	validators := []common.Address{{0x02}, {0x01}}
+	genesis := DeveloperParliaGenesisBlock(3, 200, validators)
+	if err := genesis.Config.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("invalid fork order: %v", err)
+	}
+	if _, _, err := SetupGenesisBlock(rawdb.NewMemoryDatabase(), genesis); err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+}
+
 func TestSetupGenesis(t *testing.T) {
 	var (
 		customghash = common.HexToHash("0x89c99d90b79719238d2645c7642f2c9295246e80775b38cfd162b696817fbd50")
@@ -0,0 +1,51 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadValidatorSet retrieves the validator set announced by the epoch block
+// corresponding to the hash, nil if it was never indexed.
+func ReadValidatorSet(db ethdb.KeyValueReader, number uint64, hash common.Hash) []common.Address {
+	data, _ := db.Get(validatorSetKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var validators []common.Address
+	if err := rlp.DecodeBytes(data, &validators); err != nil {
+		log.Error("Invalid validator set RLP", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	return validators
+}
+
+// WriteValidatorSet stores the validator set announced by an epoch block into
+// the database.
+func WriteValidatorSet(db ethdb.KeyValueWriter, number uint64, hash common.Hash, validators []common.Address) {
+	data, err := rlp.EncodeToBytes(validators)
+	if err != nil {
+		log.Crit("Failed to RLP encode validator set", "err", err)
+	}
+	if err := db.Put(validatorSetKey(number, hash), data); err != nil {
+		log.Crit("Failed to store validator set", "err", err)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests epoch validator set storage and retrieval operations.
+func TestValidatorSetStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	hash := common.HexToHash("0xdeadbeef")
+	validators := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+
+	if entry := ReadValidatorSet(db, 200, hash); entry != nil {
+		t.Fatalf("Non existent validator set returned: %v", entry)
+	}
+	WriteValidatorSet(db, 200, hash, validators)
+	if entry := ReadValidatorSet(db, 200, hash); !reflect.DeepEqual(entry, validators) {
+		t.Fatalf("Retrieved validator set mismatch: have %v, want %v", entry, validators)
+	}
+	if entry := ReadValidatorSet(db, 400, hash); entry != nil {
+		t.Fatalf("Validator set returned for wrong number: %v", entry)
+	}
+}
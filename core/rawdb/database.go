@@ -245,6 +245,7 @@ func InspectDatabase(db ethdb.Database) error {
 		bloomBitsSize   common.StorageSize
 		cliqueSnapsSize common.StorageSize
 		parliaSnapsSize common.StorageSize
+		validatorSets   common.StorageSize
 
 		// Ancient store statistics
 		ancientHeaders  common.StorageSize
@@ -295,6 +296,8 @@ func InspectDatabase(db ethdb.Database) error {
 			cliqueSnapsSize += size
 		case bytes.HasPrefix(key, []byte("parlia-")) && len(key) == 7+common.HashLength:
 			parliaSnapsSize += size
+		case bytes.HasPrefix(key, validatorSetPrefix) && len(key) == (len(validatorSetPrefix)+8+common.HashLength):
+			validatorSets += size
 		case bytes.HasPrefix(key, []byte("cht-")) && len(key) == 4+common.HashLength:
 			chtTrieNodes += size
 		case bytes.HasPrefix(key, []byte("blt-")) && len(key) == 4+common.HashLength:
@@ -344,6 +347,7 @@ func InspectDatabase(db ethdb.Database) error {
 		{"Key-Value store", "Storage snapshot", storageSnapSize.String()},
 		{"Key-Value store", "Clique snapshots", cliqueSnapsSize.String()},
 		{"Key-Value store", "Parlia snapshots", parliaSnapsSize.String()},
+		{"Key-Value store", "Parlia validator sets", validatorSets.String()},
 		{"Key-Value store", "Singleton metadata", metadata.String()},
 		{"Ancient store", "Headers", ancientHeaders.String()},
 		{"Ancient store", "Bodies", ancientBodies.String()},
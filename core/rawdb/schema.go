@@ -64,6 +64,8 @@ var (
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
+	validatorSetPrefix = []byte("parlia-validators-") // validatorSetPrefix + num (uint64 big endian) + hash -> epoch validator set
+
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
@@ -183,6 +185,11 @@ func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
 }
 
+// validatorSetKey = validatorSetPrefix + num (uint64 big endian) + hash
+func validatorSetKey(number uint64, hash common.Hash) []byte {
+	return append(append(validatorSetPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
@@ -0,0 +1,295 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AccessSet is a set of accounts and storage slots.
+type AccessSet struct {
+	Accounts map[common.Address]struct{}
+	Slots    map[common.Address]map[common.Hash]struct{}
+}
+
+func newAccessSet() AccessSet {
+	return AccessSet{
+		Accounts: make(map[common.Address]struct{}),
+		Slots:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (set AccessSet) addAccount(addr common.Address) {
+	set.Accounts[addr] = struct{}{}
+}
+
+func (set AccessSet) addSlot(addr common.Address, key common.Hash) {
+	slots, ok := set.Slots[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		set.Slots[addr] = slots
+	}
+	slots[key] = struct{}{}
+}
+
+// TxAccesses is the state accessed by executing transactions, used to detect the
+// conflicts between transactions executed concurrently.
+//
+// Crediting an account commutes with crediting it again, so accounts the only
+// access to which was adding to their balance, like the receiver of the fees,
+// are tracked apart and never cause conflicts by themselves. Neither does
+// crediting conflict with depending only on the nonce or code of an account,
+// unless it created the account, like a value transfer depending on whether
+// the receiver exists.
+type TxAccesses struct {
+	Reads   AccessSet                   // Accounts and slots the execution depended on
+	Exists  map[common.Address]struct{} // Accounts the existence, nonce or code of which the execution depended on
+	Writes  AccessSet                   // Accounts and slots the execution changed
+	Credits map[common.Address]struct{} // Accounts only ever credited
+	Created map[common.Address]struct{} // Credited accounts created by crediting them
+	Resets  map[common.Address]struct{} // Accounts created or destructed, wiping their storage
+}
+
+// NewTxAccesses creates an empty set of state accesses.
+func NewTxAccesses() *TxAccesses {
+	return &TxAccesses{
+		Reads:   newAccessSet(),
+		Exists:  make(map[common.Address]struct{}),
+		Writes:  newAccessSet(),
+		Credits: make(map[common.Address]struct{}),
+		Created: make(map[common.Address]struct{}),
+		Resets:  make(map[common.Address]struct{}),
+	}
+}
+
+// Conflicts reports whether the executions that recorded a depended on, or
+// partially overwrote, any of the state changed by the ones that recorded
+// changed.
+func (a *TxAccesses) Conflicts(changed *TxAccesses) bool {
+	for addr := range a.Exists {
+		if _, ok := changed.Writes.Accounts[addr]; ok {
+			return true
+		}
+		if _, ok := changed.Created[addr]; ok {
+			return true
+		}
+	}
+	for _, set := range []AccessSet{a.Reads, a.Writes} {
+		for addr := range set.Accounts {
+			if _, ok := changed.Writes.Accounts[addr]; ok {
+				return true
+			}
+			if _, ok := changed.Credits[addr]; ok {
+				return true
+			}
+		}
+		for addr, slots := range set.Slots {
+			if _, ok := changed.Resets[addr]; ok {
+				return true
+			}
+			if changedSlots, ok := changed.Writes.Slots[addr]; ok {
+				for key := range slots {
+					if _, ok := changedSlots[key]; ok {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Include adds the state changed by the executions that recorded b to the one
+// changed by the executions that recorded a.
+func (a *TxAccesses) Include(b *TxAccesses) {
+	for addr := range b.Writes.Accounts {
+		a.Writes.addAccount(addr)
+	}
+	for addr, slots := range b.Writes.Slots {
+		for key := range slots {
+			a.Writes.addSlot(addr, key)
+		}
+	}
+	for addr := range b.Credits {
+		a.Credits[addr] = struct{}{}
+	}
+	for addr := range b.Created {
+		a.Created[addr] = struct{}{}
+	}
+	for addr := range b.Resets {
+		a.Resets[addr] = struct{}{}
+	}
+}
+
+// StartAccessRecording starts recording the accounts and storage slots accessed
+// through the state, dropping any earlier recording.
+func (s *StateDB) StartAccessRecording() {
+	s.accesses = NewTxAccesses()
+}
+
+// StopAccessRecording stops recording the state accesses and returns the ones
+// recorded since StartAccessRecording.
+func (s *StateDB) StopAccessRecording() *TxAccesses {
+	accesses := s.accesses
+	s.accesses = nil
+
+	// The final balance of the accounts both credited and otherwise accessed
+	// depends on the starting one, they need to be copied over as a whole.
+	for addr := range accesses.Credits {
+		_, read := accesses.Reads.Accounts[addr]
+		_, written := accesses.Writes.Accounts[addr]
+		if read || written {
+			accesses.Writes.addAccount(addr)
+			delete(accesses.Credits, addr)
+			delete(accesses.Created, addr)
+		}
+	}
+	return accesses
+}
+
+func (s *StateDB) recordAccountRead(addr common.Address) {
+	if s.accesses != nil {
+		s.accesses.Reads.addAccount(addr)
+	}
+}
+
+func (s *StateDB) recordSlotRead(addr common.Address, key common.Hash) {
+	if s.accesses != nil {
+		s.accesses.Reads.addSlot(addr, key)
+	}
+}
+
+func (s *StateDB) recordAccountWrite(addr common.Address) {
+	if s.accesses != nil {
+		s.accesses.Writes.addAccount(addr)
+	}
+}
+
+func (s *StateDB) recordSlotWrite(addr common.Address, key common.Hash) {
+	if s.accesses != nil {
+		s.accesses.Writes.addSlot(addr, key)
+	}
+}
+
+func (s *StateDB) recordExistsRead(addr common.Address) {
+	if s.accesses != nil {
+		s.accesses.Exists[addr] = struct{}{}
+	}
+}
+
+func (s *StateDB) recordCredit(addr common.Address, amount *big.Int) {
+	if s.accesses == nil {
+		return
+	}
+	// Crediting nothing deletes empty accounts, changing more than their balance
+	obj := s.getStateObject(addr)
+	if obj != nil && obj.empty() && amount.Sign() == 0 {
+		s.accesses.Writes.addAccount(addr)
+		return
+	}
+	if obj == nil && amount.Sign() > 0 {
+		s.accesses.Created[addr] = struct{}{}
+	}
+	s.accesses.Credits[addr] = struct{}{}
+}
+
+func (s *StateDB) recordReset(addr common.Address) {
+	if s.accesses != nil {
+		s.accesses.Writes.addAccount(addr)
+		s.accesses.Resets[addr] = struct{}{}
+	}
+}
+
+// TxCopy creates a copy of the state to execute a transaction in isolation on.
+// Unlike Copy, the copy keeps reading through the snapshot, if any, without
+// ever updating it.
+func (s *StateDB) TxCopy() *StateDB {
+	state := s.Copy()
+	if s.snap != nil {
+		state.snap = s.snap
+		state.snapDestructs = make(map[common.Hash]struct{}, len(s.snapDestructs))
+		for hash := range s.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte)
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+	return state
+}
+
+// LoadedStorage returns the storage slots of an account read or written since
+// the state was created.
+func (s *StateDB) LoadedStorage(addr common.Address) map[common.Hash]struct{} {
+	slots := make(map[common.Hash]struct{})
+	if obj := s.stateObjects[addr]; obj != nil {
+		for _, storage := range []Storage{obj.originStorage, obj.pendingStorage, obj.dirtyStorage} {
+			for key := range storage {
+				slots[key] = struct{}{}
+			}
+		}
+	}
+	return slots
+}
+
+// Merge applies the changes made by a single transaction executed on src, a
+// TxCopy of base, with its state accesses recorded. The transaction must not
+// conflict with any change made to the state since base, in which case merging
+// is equivalent to executing the transaction on the state itself, provided it
+// was prepared for the transaction. The state still needs to be finalised.
+func (s *StateDB) Merge(src, base *StateDB, accesses *TxAccesses) {
+	destructed := make(map[common.Address]bool)
+	for addr := range accesses.Writes.Accounts {
+		obj := src.getStateObject(addr)
+		if obj == nil {
+			destructed[addr] = true
+			continue
+		}
+		if _, ok := accesses.Resets[addr]; ok {
+			s.CreateAccount(addr)
+		}
+		s.SetBalance(addr, obj.Balance())
+		s.SetNonce(addr, obj.Nonce())
+		if code := obj.Code(src.db); !bytes.Equal(code, s.GetCode(addr)) {
+			s.SetCode(addr, code)
+		}
+	}
+	for addr, slots := range accesses.Writes.Slots {
+		obj := src.getStateObject(addr)
+		if obj == nil {
+			destructed[addr] = true
+			continue
+		}
+		for key := range slots {
+			s.SetState(addr, key, obj.GetState(src.db, key))
+		}
+	}
+	for addr := range destructed {
+		s.Suicide(addr)
+	}
+	for addr := range accesses.Credits {
+		s.AddBalance(addr, new(big.Int).Sub(src.GetBalance(addr), base.GetBalance(addr)))
+	}
+	for _, log := range src.GetLogs(src.thash) {
+		s.AddLog(log)
+	}
+	for hash, preimage := range src.preimages {
+		s.AddPreimage(hash, preimage)
+	}
+}
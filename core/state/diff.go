@@ -0,0 +1,252 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DiffAccount is the state of an account at either end of a state diff. Only
+// the storage slots that were changed are included.
+type DiffAccount struct {
+	Balance  *hexutil.Big                `json:"balance"`
+	Nonce    hexutil.Uint64              `json:"nonce"`
+	CodeHash common.Hash                 `json:"codeHash"`
+	Storage  map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// AccountDiff is the change of a single account.
+type AccountDiff struct {
+	Pre  *DiffAccount `json:"pre"`  // State before the change, nil if the account didn't exist
+	Post *DiffAccount `json:"post"` // State after the change, nil if the account was deleted
+}
+
+// StateDiff is the set of accounts changed in between two states.
+type StateDiff map[common.Address]*AccountDiff
+
+// diffOrigin tracks the pre-state of an account while walking the journal.
+type diffOrigin struct {
+	object *stateObject // Object holding the untouched fields, nil if the account didn't exist
+	fixed  bool         // Whether the object was replaced, making later journal entries irrelevant
+
+	balance  *big.Int // Balance before the first balance change
+	nonce    *uint64  // Nonce before the first nonce change
+	codeHash []byte   // Code hash before the first code change
+
+	storage map[common.Hash]common.Hash // Storage slots before their first change
+}
+
+// JournalDiff returns the changes made to the state since the last Finalise,
+// assembled from the state journal: the pre-state from the values the journal
+// would revert to, the post-state from the live objects. Accounts and storage
+// slots left unchanged are omitted. Like Finalise, accounts left empty count as
+// deleted if deleteEmptyObjects is set.
+func (s *StateDB) JournalDiff(deleteEmptyObjects bool) StateDiff {
+	var (
+		origins = make(map[common.Address]*diffOrigin)
+		order   []common.Address
+	)
+	track := func(addr common.Address) *diffOrigin {
+		if origin, ok := origins[addr]; ok {
+			return origin
+		}
+		origin := &diffOrigin{object: s.stateObjects[addr], storage: make(map[common.Hash]common.Hash)}
+		origins[addr] = origin
+		order = append(order, addr)
+		return origin
+	}
+	for _, entry := range s.journal.entries {
+		switch ch := entry.(type) {
+		case createObjectChange:
+			if origin := track(*ch.account); !origin.fixed {
+				origin.object, origin.fixed = nil, true
+			}
+		case resetObjectChange:
+			if origin := track(ch.prev.address); !origin.fixed {
+				origin.object, origin.fixed = ch.prev, true
+				if ch.prev.deleted {
+					origin.object = nil
+				}
+			}
+		case suicideChange:
+			if origin := track(*ch.account); !origin.fixed && origin.balance == nil {
+				origin.balance = ch.prevbalance
+			}
+		case balanceChange:
+			if origin := track(*ch.account); !origin.fixed && origin.balance == nil {
+				origin.balance = ch.prev
+			}
+		case nonceChange:
+			if origin := track(*ch.account); !origin.fixed && origin.nonce == nil {
+				nonce := ch.prev
+				origin.nonce = &nonce
+			}
+		case codeChange:
+			if origin := track(*ch.account); !origin.fixed && origin.codeHash == nil {
+				origin.codeHash = ch.prevhash
+			}
+		case storageChange:
+			origin := track(*ch.account)
+			if _, ok := origin.storage[ch.key]; ok {
+				break
+			}
+			// Slots first changed on a replacement object start out from the original
+			switch {
+			case !origin.fixed:
+				origin.storage[ch.key] = ch.prevalue
+			case origin.object != nil:
+				origin.storage[ch.key] = origin.object.GetState(s.db, ch.key)
+			default:
+				origin.storage[ch.key] = common.Hash{}
+			}
+		case touchChange:
+			track(*ch.account)
+		}
+	}
+	diff := make(StateDiff)
+	for _, addr := range order {
+		origin := origins[addr]
+
+		var pre *DiffAccount
+		if obj := origin.object; obj != nil {
+			pre = &DiffAccount{
+				Balance:  (*hexutil.Big)(new(big.Int).Set(obj.Balance())),
+				Nonce:    hexutil.Uint64(obj.Nonce()),
+				CodeHash: common.BytesToHash(obj.CodeHash()),
+				Storage:  origin.storage,
+			}
+			if origin.balance != nil {
+				pre.Balance = (*hexutil.Big)(new(big.Int).Set(origin.balance))
+			}
+			if origin.nonce != nil {
+				pre.Nonce = hexutil.Uint64(*origin.nonce)
+			}
+			if origin.codeHash != nil {
+				pre.CodeHash = common.BytesToHash(origin.codeHash)
+			}
+		}
+		var post *DiffAccount
+		if obj := s.getStateObject(addr); obj != nil && !obj.suicided && !(deleteEmptyObjects && obj.empty()) {
+			post = s.diffAccount(obj, origin.storage)
+		}
+		if change := newAccountDiff(pre, post); change != nil {
+			diff[addr] = change
+		}
+	}
+	return diff
+}
+
+// DiffStates returns the changes in between the base and head states of the
+// accounts and storage slots changed in any of the touched diffs. It is meant
+// to combine the diffs of consecutive Finalise rounds, which the journal alone
+// cannot do.
+func DiffStates(base, head *StateDB, touched ...StateDiff) StateDiff {
+	slots := make(map[common.Address]map[common.Hash]common.Hash)
+	for _, d := range touched {
+		for addr, change := range d {
+			if slots[addr] == nil {
+				slots[addr] = make(map[common.Hash]common.Hash)
+			}
+			for _, acc := range []*DiffAccount{change.Pre, change.Post} {
+				if acc != nil {
+					for key := range acc.Storage {
+						slots[addr][key] = common.Hash{}
+					}
+				}
+			}
+		}
+	}
+	diff := make(StateDiff)
+	for addr, keys := range slots {
+		var pre, post *DiffAccount
+		if obj := base.getStateObject(addr); obj != nil {
+			pre = base.diffAccount(obj, keys)
+		}
+		if obj := head.getStateObject(addr); obj != nil {
+			post = head.diffAccount(obj, keys)
+		}
+		if change := newAccountDiff(pre, post); change != nil {
+			diff[addr] = change
+		}
+	}
+	return diff
+}
+
+// diffAccount returns the current state of the given account, along with the
+// current values of the given storage slots.
+func (s *StateDB) diffAccount(obj *stateObject, slots map[common.Hash]common.Hash) *DiffAccount {
+	acc := &DiffAccount{
+		Balance:  (*hexutil.Big)(new(big.Int).Set(obj.Balance())),
+		Nonce:    hexutil.Uint64(obj.Nonce()),
+		CodeHash: common.BytesToHash(obj.CodeHash()),
+		Storage:  make(map[common.Hash]common.Hash, len(slots)),
+	}
+	for key := range slots {
+		acc.Storage[key] = obj.GetState(s.db, key)
+	}
+	return acc
+}
+
+// newAccountDiff drops the storage slots left unchanged in between the pre and
+// post states of an account, returning nil if the account didn't change at all.
+// Missing accounts are treated as having empty storage.
+func newAccountDiff(pre, post *DiffAccount) *AccountDiff {
+	if pre == nil && post == nil {
+		return nil
+	}
+	// Copy the storage of the pre-state, as it may be shared with the caller
+	if pre != nil {
+		storage := make(map[common.Hash]common.Hash, len(pre.Storage))
+		for key, val := range pre.Storage {
+			storage[key] = val
+		}
+		pre.Storage = storage
+	}
+	keys := make(map[common.Hash]struct{})
+	for _, acc := range []*DiffAccount{pre, post} {
+		if acc != nil {
+			for key := range acc.Storage {
+				keys[key] = struct{}{}
+			}
+		}
+	}
+	for key := range keys {
+		var prev, next common.Hash
+		if pre != nil {
+			prev = pre.Storage[key]
+		}
+		if post != nil {
+			next = post.Storage[key]
+		}
+		if prev == next {
+			if pre != nil {
+				delete(pre.Storage, key)
+			}
+			if post != nil {
+				delete(post.Storage, key)
+			}
+		}
+	}
+	if pre != nil && post != nil && len(pre.Storage) == 0 && len(post.Storage) == 0 &&
+		pre.Balance.ToInt().Cmp(post.Balance.ToInt()) == 0 && pre.Nonce == post.Nonce && pre.CodeHash == post.CodeHash {
+		return nil
+	}
+	return &AccountDiff{Pre: pre, Post: post}
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the state diffs assembled from the journal contain exactly the
+// changes that survived, and that consecutive diffs combine correctly.
+func TestJournalDiff(t *testing.T) {
+	var (
+		addr1 = common.HexToAddress("0x01")
+		addr2 = common.HexToAddress("0x02")
+		addr3 = common.HexToAddress("0x03")
+		addr4 = common.HexToAddress("0x04")
+
+		key1 = common.HexToHash("0x01")
+		key2 = common.HexToHash("0x02")
+		val1 = common.HexToHash("0x11")
+		val2 = common.HexToHash("0x22")
+	)
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	state.SetBalance(addr1, big.NewInt(100))
+	state.SetNonce(addr1, 1)
+	state.SetState(addr1, key1, val1)
+	state.SetBalance(addr2, big.NewInt(5))
+
+	root, _ := state.Commit(true)
+	state, _ = New(root, state.db, nil)
+	base := state.Copy()
+
+	// Change some accounts, revert one of the changes and touch an empty account
+	state.AddBalance(addr1, big.NewInt(10))
+	state.SetState(addr1, key1, val2)
+	snap := state.Snapshot()
+	state.SetState(addr1, key2, val2)
+	state.RevertToSnapshot(snap)
+	state.Suicide(addr2)
+	state.AddBalance(addr3, big.NewInt(7))
+	state.AddBalance(addr4, new(big.Int))
+
+	first := state.JournalDiff(true)
+	checkDiff(t, "first", first, StateDiff{
+		addr1: {
+			Pre:  diffAccount(100, 1, map[common.Hash]common.Hash{key1: val1}),
+			Post: diffAccount(110, 1, map[common.Hash]common.Hash{key1: val2}),
+		},
+		addr2: {Pre: diffAccount(5, 0, nil)},
+		addr3: {Post: diffAccount(7, 0, nil)},
+	})
+	state.Finalise(true)
+
+	// Restore the changed slot, which the combined diff must not report
+	state.SetState(addr1, key1, val1)
+	state.AddBalance(addr1, big.NewInt(1))
+
+	second := state.JournalDiff(true)
+	checkDiff(t, "second", second, StateDiff{
+		addr1: {
+			Pre:  diffAccount(110, 1, map[common.Hash]common.Hash{key1: val2}),
+			Post: diffAccount(111, 1, map[common.Hash]common.Hash{key1: val1}),
+		},
+	})
+	state.Finalise(true)
+
+	checkDiff(t, "combined", DiffStates(base, state, first, second), StateDiff{
+		addr1: {Pre: diffAccount(100, 1, nil), Post: diffAccount(111, 1, nil)},
+		addr2: {Pre: diffAccount(5, 0, nil)},
+		addr3: {Post: diffAccount(7, 0, nil)},
+	})
+}
+
+func diffAccount(balance int64, nonce uint64, storage map[common.Hash]common.Hash) *DiffAccount {
+	return &DiffAccount{
+		Balance:  (*hexutil.Big)(big.NewInt(balance)),
+		Nonce:    hexutil.Uint64(nonce),
+		CodeHash: common.BytesToHash(emptyCodeHash),
+		Storage:  storage,
+	}
+}
+
+func checkDiff(t *testing.T, name string, have, want StateDiff) {
+	haveJSON, _ := json.Marshal(have)
+	wantJSON, _ := json.Marshal(want)
+	if string(haveJSON) != string(wantJSON) {
+		t.Errorf("%s diff mismatch:\nhave %s\nwant %s", name, haveJSON, wantJSON)
+	}
+}
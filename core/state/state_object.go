@@ -176,6 +176,7 @@ func (s *stateObject) GetState(db Database, key common.Hash) common.Hash {
 	// If we have a dirty value for this state entry, return it
 	value, dirty := s.dirtyStorage[key]
 	if dirty {
+		s.db.StorageCacheHits++
 		return value
 	}
 	// Otherwise return the entry's original value
@@ -190,9 +191,11 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 	}
 	// If we have a pending write or clean cached, return that
 	if value, pending := s.pendingStorage[key]; pending {
+		s.db.StorageCacheHits++
 		return value
 	}
 	if value, cached := s.originStorage[key]; cached {
+		s.db.StorageCacheHits++
 		return value
 	}
 	// If no live objects are available, attempt to use snapshots
@@ -200,6 +203,7 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 		enc []byte
 		err error
 	)
+	s.db.StorageCacheMisses++
 	if s.db.snap != nil {
 		if metrics.EnabledExpensive {
 			defer func(start time.Time) { s.db.SnapshotStorageReads += time.Since(start) }(time.Now())
@@ -0,0 +1,300 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	accessPrefetchContracts = 4096 // Number of contracts to remember the storage accesses of
+	accessPrefetchMaxBase   = 8    // Number of mapping slot indexes tried to explain the accesses
+	accessPrefetchMaxSlots  = 64   // Maximum number of state variable slots remembered per contract
+)
+
+var (
+	accessPrefetchAccountMeter = metrics.NewRegisteredMeter("chain/prefetch/access/accounts", nil)
+	accessPrefetchSlotMeter    = metrics.NewRegisteredMeter("chain/prefetch/access/slots", nil)
+)
+
+// slotPattern derives a storage slot accessed by a call from the addresses it
+// involves, the sender being address 0 and the i-th word of the call data, if it
+// holds an address, address i.
+type slotPattern struct {
+	outer int    // Address keying the mapping
+	inner int    // Address keying the nested mapping, -1 if not nested
+	base  uint64 // Slot of the mapping
+}
+
+// slot derives the storage slot from the addresses of a call, if they include
+// the ones needed.
+func (pattern slotPattern) slot(addrs map[int]common.Address) (common.Hash, bool) {
+	outer, ok := addrs[pattern.outer]
+	if !ok {
+		return common.Hash{}, false
+	}
+	slot := mappingSlot(outer, common.BigToHash(new(big.Int).SetUint64(pattern.base)))
+	if pattern.inner < 0 {
+		return slot, true
+	}
+	inner, ok := addrs[pattern.inner]
+	if !ok {
+		return common.Hash{}, false
+	}
+	return mappingSlot(inner, slot), true
+}
+
+// Token methods with known storage accesses.
+var (
+	transferSelector     = [4]byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	transferFromSelector = [4]byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+	approveSelector      = [4]byte{0x09, 0x5e, 0xa7, 0xb3} // approve(address,uint256)
+)
+
+// defaultSlotPatterns are the storage accesses of the token methods in the most
+// common token layouts, guessed for contracts never seen called before. The
+// OpenZeppelin ERC20 keeps the balances and the allowances in the first two
+// slots, the BEP20 template keeps them after the owner.
+var defaultSlotPatterns = map[[4]byte][]slotPattern{
+	transferSelector: {
+		{outer: 0, inner: -1, base: 0}, {outer: 1, inner: -1, base: 0},
+		{outer: 0, inner: -1, base: 1}, {outer: 1, inner: -1, base: 1},
+	},
+	transferFromSelector: {
+		{outer: 1, inner: -1, base: 0}, {outer: 2, inner: -1, base: 0}, {outer: 1, inner: 0, base: 1},
+		{outer: 1, inner: -1, base: 1}, {outer: 2, inner: -1, base: 1}, {outer: 1, inner: 0, base: 2},
+	},
+	approveSelector: {
+		{outer: 0, inner: 1, base: 1}, {outer: 0, inner: 1, base: 2},
+	},
+}
+
+// contractAccesses are the storage accesses seen in the calls to a contract.
+type contractAccesses struct {
+	slots    map[common.Hash]struct{}  // State variable slots, accessed whoever calls
+	patterns map[[4]byte][]slotPattern // Slots derived from the addresses of the calls, by method
+}
+
+// accessPrefetcher is a prefetcher which, unlike the statePrefetcher, does not
+// execute the blocks. It derives the accounts and storage slots the transactions
+// are likely to access from the senders, the recipients, the token methods they
+// call and the accesses seen in earlier calls to the same contracts, and loads
+// them concurrently into the trie caches.
+type accessPrefetcher struct {
+	config *params.ChainConfig // Chain configuration options
+	bc     *BlockChain         // Canonical block chain
+
+	workers   int        // Number of goroutines loading the state
+	contracts *lru.Cache // Storage accesses seen by contract address
+}
+
+// newAccessPrefetcher initialises a new accessPrefetcher.
+func newAccessPrefetcher(config *params.ChainConfig, bc *BlockChain) *accessPrefetcher {
+	contracts, _ := lru.New(accessPrefetchContracts)
+	return &accessPrefetcher{
+		config:    config,
+		bc:        bc,
+		workers:   runtime.NumCPU(),
+		contracts: contracts,
+	}
+}
+
+// Prefetch starts loading the state the block is likely to access on top of the
+// given root in the background, until done or interrupted.
+func (p *accessPrefetcher) Prefetch(block *types.Block, root common.Hash, interrupt *uint32) {
+	accesses := p.accesses(block)
+
+	tasks := make(chan common.Address, len(accesses))
+	for addr := range accesses {
+		tasks <- addr
+	}
+	close(tasks)
+
+	for i := 0; i < p.workers && i < len(accesses); i++ {
+		go func() {
+			statedb, err := state.New(root, p.bc.stateCache, nil)
+			if err != nil {
+				return
+			}
+			for addr := range tasks {
+				if atomic.LoadUint32(interrupt) == 1 {
+					return
+				}
+				accessPrefetchAccountMeter.Mark(1)
+				statedb.GetCode(addr)
+				for slot := range accesses[addr] {
+					statedb.GetState(addr, slot)
+				}
+				accessPrefetchSlotMeter.Mark(int64(len(accesses[addr])))
+			}
+		}()
+	}
+}
+
+// accesses derives the accounts and storage slots a block is likely to access.
+func (p *accessPrefetcher) accesses(block *types.Block) map[common.Address]map[common.Hash]struct{} {
+	var (
+		signer   = types.MakeSigner(p.config, block.Number())
+		accesses = make(map[common.Address]map[common.Hash]struct{})
+	)
+	touch := func(addr common.Address) map[common.Hash]struct{} {
+		slots, ok := accesses[addr]
+		if !ok {
+			slots = make(map[common.Hash]struct{})
+			accesses[addr] = slots
+		}
+		return slots
+	}
+	touch(block.Coinbase())
+	if p.config.Parlia != nil {
+		touch(consensus.SystemAddress)
+	}
+	for _, tx := range block.Transactions() {
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		touch(sender)
+		if tx.To() == nil {
+			continue
+		}
+		slots := touch(*tx.To())
+
+		data := tx.Data()
+		if len(data) < 4 {
+			continue
+		}
+		var selector [4]byte
+		copy(selector[:], data)
+
+		patterns := defaultSlotPatterns[selector]
+		if cached, ok := p.contracts.Get(*tx.To()); ok {
+			contract := cached.(*contractAccesses)
+			for slot := range contract.slots {
+				slots[slot] = struct{}{}
+			}
+			if learnt, ok := contract.patterns[selector]; ok {
+				patterns = learnt
+			}
+		}
+		addrs := callAddresses(sender, data)
+		for _, pattern := range patterns {
+			if slot, ok := pattern.slot(addrs); ok {
+				slots[slot] = struct{}{}
+			}
+		}
+	}
+	return accesses
+}
+
+// Learn records the storage accesses of the calls in a block processed on top of
+// statedb, for prefetching them in the calls to the same contracts and methods.
+// The slots the contracts accessed are attributed to the mappings keyed by the
+// addresses involved in the first call of each method, other slots with small
+// indexes being state variables.
+func (p *accessPrefetcher) Learn(block *types.Block, statedb *state.StateDB) {
+	signer := types.MakeSigner(p.config, block.Number())
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil || len(tx.Data()) < 4 || statedb.GetCodeSize(*tx.To()) == 0 {
+			continue
+		}
+		var selector [4]byte
+		copy(selector[:], tx.Data())
+
+		var contract *contractAccesses
+		if cached, ok := p.contracts.Get(*tx.To()); ok {
+			contract = cached.(*contractAccesses)
+		} else {
+			contract = &contractAccesses{
+				slots:    make(map[common.Hash]struct{}),
+				patterns: make(map[[4]byte][]slotPattern),
+			}
+			p.contracts.Add(*tx.To(), contract)
+		}
+		if _, ok := contract.patterns[selector]; ok {
+			continue
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		loaded := statedb.LoadedStorage(*tx.To())
+		for slot := range loaded {
+			if len(contract.slots) < accessPrefetchMaxSlots && new(big.Int).SetBytes(slot[:]).IsUint64() {
+				contract.slots[slot] = struct{}{}
+			}
+		}
+		var (
+			addrs    = callAddresses(sender, tx.Data())
+			patterns = make([]slotPattern, 0)
+		)
+		explains := func(pattern slotPattern) bool {
+			slot, ok := pattern.slot(addrs)
+			if ok {
+				_, ok = loaded[slot]
+			}
+			return ok
+		}
+		for outer := range addrs {
+			for base := uint64(0); base < accessPrefetchMaxBase; base++ {
+				if pattern := (slotPattern{outer: outer, inner: -1, base: base}); explains(pattern) {
+					patterns = append(patterns, pattern)
+				}
+				for inner := range addrs {
+					if pattern := (slotPattern{outer: outer, inner: inner, base: base}); inner != outer && explains(pattern) {
+						patterns = append(patterns, pattern)
+					}
+				}
+			}
+		}
+		contract.patterns[selector] = patterns
+	}
+}
+
+// callAddresses returns the addresses involved in a call: the sender and the
+// words of the call data holding addresses, by index.
+func callAddresses(sender common.Address, data []byte) map[int]common.Address {
+	var (
+		addrs  = map[int]common.Address{0: sender}
+		prefix = make([]byte, 12)
+	)
+	for i := 1; 4+32*i <= len(data); i++ {
+		word := data[4+32*(i-1) : 4+32*i]
+		if addr := common.BytesToAddress(word[12:]); bytes.Equal(word[:12], prefix) && addr != (common.Address{}) {
+			addrs[i] = addr
+		}
+	}
+	return addrs
+}
+
+// mappingSlot returns the storage slot of the value keyed by an address in the
+// Solidity mapping at the given slot.
+func mappingSlot(key common.Address, slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(key[:], 32), slot[:])
+}
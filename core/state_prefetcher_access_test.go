@@ -0,0 +1,92 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the access prefetcher derives the storage slots of token transfers
+// from the common layouts, and learns the ones of other methods from the slots
+// the calls to them accessed.
+func TestAccessPrefetcherDerivation(t *testing.T) {
+	var (
+		token    = common.HexToAddress("0x70c3e")
+		other    = common.HexToAddress("0x07e4")
+		receiver = common.HexToAddress("0x4ec")
+		spender  = common.HexToAddress("0x5e4d")
+		selector = []byte{0x12, 0x34, 0x56, 0x78}
+
+		prefetcher = newAccessPrefetcher(params.TestChainConfig, nil)
+		signer     = types.MakeSigner(params.TestChainConfig, common.Big1)
+	)
+	call := func(key *ecdsa.PrivateKey, to common.Address, data []byte) *types.Block {
+		tx, _ := types.SignTx(types.NewTransaction(0, to, new(big.Int), 100000, new(big.Int), data), signer, key)
+		return types.NewBlock(&types.Header{Number: common.Big1}, []*types.Transaction{tx}, nil, nil)
+	}
+	word := func(addr common.Address) []byte {
+		return common.LeftPadBytes(addr[:], 32)
+	}
+	base := func(n int64) common.Hash {
+		return common.BigToHash(big.NewInt(n))
+	}
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	sender1, sender2 := crypto.PubkeyToAddress(key1.PublicKey), crypto.PubkeyToAddress(key2.PublicKey)
+
+	// Token transfers to unknown contracts prefetch the balances in the common layouts
+	transfer := append(append(append([]byte{}, transferSelector[:]...), word(receiver)...), make([]byte, 32)...)
+	accesses := prefetcher.accesses(call(key1, token, transfer))
+	for _, addr := range []common.Address{sender1, token} {
+		if _, ok := accesses[addr]; !ok {
+			t.Errorf("account %x not prefetched", addr)
+		}
+	}
+	for _, slot := range []common.Hash{mappingSlot(sender1, base(0)), mappingSlot(receiver, base(0)), mappingSlot(sender1, base(1)), mappingSlot(receiver, base(1))} {
+		if _, ok := accesses[token][slot]; !ok {
+			t.Errorf("token balance slot %x not prefetched", slot)
+		}
+	}
+	// Calls to a known method prefetch the slots the earlier call accessed
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(other, []byte{0x00})
+	for _, slot := range []common.Hash{base(3), mappingSlot(sender1, base(5)), mappingSlot(spender, mappingSlot(sender1, base(6)))} {
+		statedb.GetState(other, slot)
+	}
+	method := append(append([]byte{}, selector...), word(spender)...)
+	prefetcher.Learn(call(key1, other, method), statedb)
+
+	accesses = prefetcher.accesses(call(key2, other, method))
+	want := []common.Hash{base(3), mappingSlot(sender2, base(5)), mappingSlot(spender, mappingSlot(sender2, base(6)))}
+	if len(accesses[other]) != len(want) {
+		t.Errorf("prefetched slot count mismatch: have %d, want %d", len(accesses[other]), len(want))
+	}
+	for _, slot := range want {
+		if _, ok := accesses[other][slot]; !ok {
+			t.Errorf("learnt slot %x not prefetched", slot)
+		}
+	}
+}
@@ -36,6 +36,8 @@ type StateProcessor struct {
 	config *params.ChainConfig // Chain configuration options
 	bc     *BlockChain         // Canonical block chain
 	engine consensus.Engine    // Consensus engine used for block rewards
+
+	parallel int // Number of workers executing the transactions optimistically in parallel
 }
 
 // NewStateProcessor initialises a new StateProcessor.
@@ -47,6 +49,17 @@ func NewStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consen
 	}
 }
 
+// NewParallelStateProcessor initialises a new StateProcessor executing the
+// transactions of the blocks optimistically in parallel with the given number
+// of workers, before applying them in order. The transactions conflicting with
+// the ones before them are executed again, with identical results to executing
+// them one after the other. System transactions are always executed serially.
+func NewParallelStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consensus.Engine, workers int) *StateProcessor {
+	processor := NewStateProcessor(config, bc, engine)
+	processor.parallel = workers
+	return processor
+}
+
 // Process processes the state changes according to the Ethereum rules by running
 // the transaction messages using the statedb and applying any rewards to both
 // the processor (coinbase) and any included uncles.
@@ -73,6 +86,25 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	commonTxs := make([]*types.Transaction, 0, len(block.Transactions()))
 	// usually do have two tx, one for validator set contract, another for system reward contract.
 	systemTxs := make([]*types.Transaction, 0, 2)
+
+	// Execute the common transactions in parallel ahead if enabled. Tracing needs
+	// the transactions executed in order, and the receipts before Byzantium the
+	// intermediate roots.
+	var parallel *parallelExecution
+	if p.parallel > 1 && !cfg.Debug && p.config.IsByzantium(block.Number()) && len(block.Transactions()) > 1 {
+		execute := make([]bool, len(block.Transactions()))
+		for i, tx := range block.Transactions() {
+			if isPoSA {
+				if isSystemTx, err := posa.IsSystemTransaction(tx, block.Header()); err != nil {
+					return nil, nil, 0, err
+				} else if isSystemTx {
+					continue
+				}
+			}
+			execute[i] = true
+		}
+		parallel = executeParallel(p.config, p.bc, block, statedb, execute, p.parallel, cfg)
+	}
 	for i, tx := range block.Transactions() {
 		if isPoSA {
 			if isSystemTx, err := posa.IsSystemTransaction(tx, block.Header()); err != nil {
@@ -83,7 +115,15 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 			}
 		}
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
-		receipt, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
+		var (
+			receipt *types.Receipt
+			err     error
+		)
+		if parallel != nil {
+			receipt, err = parallel.apply(i, tx, gp, statedb, usedGas)
+		} else {
+			receipt, err = ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
+		}
 		if err != nil {
 			return nil, nil, 0, err
 		}
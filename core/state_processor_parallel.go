@@ -0,0 +1,139 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	parallelMergedMeter     = metrics.NewRegisteredMeter("chain/parallel/merged", nil)
+	parallelReexecutedMeter = metrics.NewRegisteredMeter("chain/parallel/reexecuted", nil)
+)
+
+// parallelResult is the outcome of executing a transaction on its own copy of
+// the state at the start of the block.
+type parallelResult struct {
+	state    *state.StateDB
+	accesses *state.TxAccesses
+	receipt  *types.Receipt
+	err      error
+}
+
+// parallelExecution is a block whose transactions were executed optimistically
+// in parallel, to be applied in order on top of the state at the start of the
+// block. The transactions depending on the changes of the ones before them are
+// executed again, so that applying them is equivalent to executing them one
+// after the other.
+type parallelExecution struct {
+	config  *params.ChainConfig
+	bc      *BlockChain
+	header  *types.Header
+	cfg     vm.Config
+	base    *state.StateDB    // State at the start of the block
+	results []*parallelResult // Outcome of the transactions, nil for the system ones
+	changed *state.TxAccesses // State changed by the transactions applied so far
+}
+
+// executeParallel executes the transactions of a block marked in txs on copies
+// of the state with the given number of workers.
+func executeParallel(config *params.ChainConfig, bc *BlockChain, block *types.Block, statedb *state.StateDB, txs []bool, workers int, cfg vm.Config) *parallelExecution {
+	exec := &parallelExecution{
+		config:  config,
+		bc:      bc,
+		header:  block.Header(),
+		cfg:     cfg,
+		base:    statedb.TxCopy(),
+		results: make([]*parallelResult, len(txs)),
+		changed: state.NewTxAccesses(),
+	}
+	// Copy the state for every transaction up front, the state of the block is
+	// not safe for concurrent use
+	tasks := make(chan int, len(txs))
+	for i, execute := range txs {
+		if execute {
+			exec.results[i] = &parallelResult{state: statedb.TxCopy()}
+			tasks <- i
+		}
+	}
+	close(tasks)
+
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				exec.execute(block, i)
+			}
+		}()
+	}
+	wg.Wait()
+	return exec
+}
+
+// execute executes a transaction on its copy of the state, recording the state
+// it accesses.
+func (exec *parallelExecution) execute(block *types.Block, index int) {
+	var (
+		tx  = block.Transactions()[index]
+		res = exec.results[index]
+	)
+	res.state.Prepare(tx.Hash(), block.Hash(), index)
+	res.state.StartAccessRecording()
+	res.receipt, res.err = ApplyTransaction(exec.config, exec.bc, nil, new(GasPool).AddGas(block.GasLimit()), res.state, exec.header, tx, new(uint64), exec.cfg)
+	res.accesses = res.state.StopAccessRecording()
+}
+
+// apply applies the next transaction of the block to the state prepared for it,
+// merging the result of its parallel execution if it is still valid, executing
+// it again otherwise.
+func (exec *parallelExecution) apply(index int, tx *types.Transaction, gp *GasPool, statedb *state.StateDB, usedGas *uint64) (*types.Receipt, error) {
+	res := exec.results[index]
+	if res.err != nil || res.accesses.Conflicts(exec.changed) {
+		parallelReexecutedMeter.Mark(1)
+
+		statedb.StartAccessRecording()
+		receipt, err := ApplyTransaction(exec.config, exec.bc, nil, gp, statedb, exec.header, tx, usedGas, exec.cfg)
+		accesses := statedb.StopAccessRecording()
+		if err != nil {
+			return nil, err
+		}
+		exec.changed.Include(accesses)
+		return receipt, nil
+	}
+	parallelMergedMeter.Mark(1)
+
+	if err := gp.SubGas(tx.Gas()); err != nil {
+		return nil, err
+	}
+	gp.AddGas(tx.Gas() - res.receipt.GasUsed)
+
+	statedb.Merge(res.state, exec.base, res.accesses)
+	statedb.Finalise(true)
+	*usedGas += res.receipt.GasUsed
+
+	res.receipt.CumulativeGasUsed = *usedGas
+	exec.changed.Include(res.accesses)
+	return res.receipt, nil
+}
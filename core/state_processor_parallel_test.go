@@ -0,0 +1,139 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that executing the transactions of blocks in parallel produces the same
+// state, receipts and logs as executing them one after the other, both for the
+// independent and the conflicting transactions.
+func TestParallelStateProcessor(t *testing.T) {
+	var (
+		keys     = make([]*ecdsa.PrivateKey, 11)
+		addrs    = make([]common.Address, len(keys))
+		counter  = common.HexToAddress("0xc0")   // Increments slot 0, logging the new value
+		suicidal = common.HexToAddress("0xdead") // Self destructs to the caller
+		shared   = common.HexToAddress("0xaa")   // Only ever credited
+		alloc    = GenesisAlloc{
+			counter:  {Code: hexutil.MustDecode("0x6000546001018060005560006000a100"), Balance: new(big.Int)},
+			suicidal: {Code: hexutil.MustDecode("0x33ff"), Balance: big.NewInt(1000)},
+		}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = GenesisAccount{Balance: big.NewInt(params.Ether)}
+	}
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: alloc}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, receipts := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{1})
+
+		send := func(key *ecdsa.PrivateKey, to *common.Address, value int64, gas uint64, data []byte) {
+			nonce := b.TxNonce(crypto.PubkeyToAddress(key.PublicKey))
+			var tx *types.Transaction
+			if to == nil {
+				tx = types.NewContractCreation(nonce, big.NewInt(value), gas, big.NewInt(1), data)
+			} else {
+				tx = types.NewTransaction(nonce, *to, big.NewInt(value), gas, big.NewInt(1), data)
+			}
+			tx, _ = types.SignTx(tx, signer, key)
+			b.AddTx(tx)
+		}
+		for j, key := range keys[:9] {
+			switch j % 3 {
+			case 0:
+				fresh := common.Address{byte(i + 1), byte(j + 1)}
+				send(key, &fresh, 1000, params.TxGas, nil)
+			case 1:
+				send(key, &shared, 1000, params.TxGas, nil)
+			case 2:
+				send(key, &counter, 0, 100000, nil)
+			}
+		}
+		switch i {
+		case 1:
+			send(keys[9], &suicidal, 0, 100000, nil)
+			send(keys[10], &suicidal, 1000, 100000, nil)
+		case 2:
+			send(keys[9], nil, 0, 100000, hexutil.MustDecode("0x600160005500"))
+		}
+	})
+	diskdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(diskdb)
+
+	chain, _ := NewBlockChain(diskdb, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	processor := NewParallelStateProcessor(gspec.Config, chain, chain.engine, 4)
+	statedb, _ := state.New(genesis.Root(), state.NewDatabase(diskdb), nil)
+	for i, block := range blocks {
+		have, logs, usedGas, err := processor.Process(block, statedb, vm.Config{})
+		if err != nil {
+			t.Fatalf("block %d: processing failed: %v", i, err)
+		}
+		if usedGas != block.GasUsed() {
+			t.Errorf("block %d: gas used mismatch: have %d, want %d", i, usedGas, block.GasUsed())
+		}
+		want := receipts[i]
+		if len(have) != len(want) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", i, len(have), len(want))
+		}
+		for j := range want {
+			if have[j].Status != want[j].Status || have[j].CumulativeGasUsed != want[j].CumulativeGasUsed || have[j].ContractAddress != want[j].ContractAddress || have[j].Bloom != want[j].Bloom {
+				t.Errorf("block %d: receipt %d mismatch: have %+v, want %+v", i, j, have[j], want[j])
+			}
+			if len(have[j].Logs) != len(want[j].Logs) {
+				t.Fatalf("block %d: receipt %d log count mismatch: have %d, want %d", i, j, len(have[j].Logs), len(want[j].Logs))
+			}
+			for k := range want[j].Logs {
+				if have, want := have[j].Logs[k], want[j].Logs[k]; have.Index != want.Index || have.TxIndex != want.TxIndex || have.Topics[0] != want.Topics[0] {
+					t.Errorf("block %d: receipt %d log %d mismatch: have %+v, want %+v", i, j, k, have, want)
+				}
+			}
+		}
+		if len(logs) != 3 {
+			t.Errorf("block %d: log count mismatch: have %d, want %d", i, len(logs), 3)
+		}
+		root, err := statedb.Commit(true)
+		if err != nil {
+			t.Fatalf("block %d: commit failed: %v", i, err)
+		}
+		if root != block.Root() {
+			t.Fatalf("block %d: state root mismatch: have %x, want %x", i, root, block.Root())
+		}
+		statedb.Reset(root)
+	}
+}
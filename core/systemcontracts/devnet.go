@@ -0,0 +1,80 @@
+package systemcontracts
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Storage layout of the system contracts, as compiled from bsc-genesis-contract.
+var (
+	alreadyInitSlot = common.Hash{} // System.alreadyInit, inherited by every system contract
+
+	validatorSetSlot        = common.BigToHash(big.NewInt(1)) // BSCValidatorSet.currentValidatorSet
+	expireTimeSecondGapSlot = common.BigToHash(big.NewInt(2)) // BSCValidatorSet.expireTimeSecondGap
+	validatorSetMapSlot     = common.BigToHash(big.NewInt(4)) // BSCValidatorSet.currentValidatorSetMap
+)
+
+const (
+	validatorSlots      = 4             // Storage slots taken by a BSCValidatorSet.Validator struct
+	expireTimeSecondGap = 1000          // BSCValidatorSet.EXPIRE_TIME_SECOND_GAP
+	devVotingPower      = 0x48c27395000 // Voting power of every developer network validator
+)
+
+// IsInitialized reports whether the init method of a system contract has already
+// run, either at the first block or because the genesis pre-initialized it.
+func IsInitialized(statedb *state.StateDB, contract common.Address) bool {
+	return statedb.GetState(contract, alreadyInitSlot)[common.HashLength-1] != 0
+}
+
+// DevGenesisCode returns the bytecode of every system contract as of the latest
+// built-in upgrade, for developer networks to deploy in their genesis.
+func DevGenesisCode() map[common.Address][]byte {
+	code := make(map[common.Address][]byte)
+	for _, fork := range params.BSCForkTable {
+		upgrade := builtinUpgrades[fork.Name][chapelNet]
+		if upgrade == nil {
+			continue
+		}
+		for _, cfg := range upgrade.Configs {
+			blob, err := hex.DecodeString(cfg.Code)
+			if err != nil {
+				panic(err)
+			}
+			code[cfg.ContractAddr] = blob
+		}
+	}
+	return code
+}
+
+// DevValidatorSetStorage returns the storage of a validator set contract whose
+// init method already ran with the given validators, each of them acting as its
+// own fee address. The genesis of developer networks uses it instead of the
+// validator set compiled into the contract.
+func DevValidatorSetStorage(validators []common.Address) map[common.Hash]common.Hash {
+	storage := map[common.Hash]common.Hash{
+		alreadyInitSlot:         common.BigToHash(common.Big1),
+		validatorSetSlot:        common.BigToHash(big.NewInt(int64(len(validators)))),
+		expireTimeSecondGapSlot: common.BigToHash(big.NewInt(expireTimeSecondGap)),
+	}
+	base := new(big.Int).SetBytes(crypto.Keccak256(validatorSetSlot[:]))
+	for i, val := range validators {
+		slot := new(big.Int).Add(base, big.NewInt(int64(i*validatorSlots)))
+
+		// consensusAddress, feeAddress, then BBCFeeAddress packed with votingPower and jailed
+		storage[common.BigToHash(slot)] = val.Hash()
+		storage[common.BigToHash(slot.Add(slot, common.Big1))] = val.Hash()
+
+		packed := new(big.Int).Lsh(big.NewInt(devVotingPower), common.AddressLength*8)
+		storage[common.BigToHash(slot.Add(slot, common.Big1))] = common.BigToHash(packed.Or(packed, val.Hash().Big()))
+
+		// currentValidatorSetMap holds the 1-based index of every validator
+		key := crypto.Keccak256Hash(val.Hash().Bytes(), validatorSetMapSlot[:])
+		storage[key] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+	return storage
+}
@@ -0,0 +1,68 @@
+package systemcontracts
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestDevValidatorSetStorage(t *testing.T) {
+	validators := []common.Address{
+		common.HexToAddress("0x1284214b9b9c85549ab3d2b972df0deef66ac2c9"),
+		common.HexToAddress("0xb71b214cb885500844365e95cd9942c7276e7fd8"),
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	contract := common.HexToAddress(ValidatorContract)
+	if IsInitialized(statedb, contract) {
+		t.Fatalf("empty contract reported as initialized")
+	}
+	statedb.SetCode(contract, DevGenesisCode()[contract])
+	for key, value := range DevValidatorSetStorage(validators) {
+		statedb.SetState(contract, key, value)
+	}
+	if !IsInitialized(statedb, contract) {
+		t.Fatalf("pre-initialized contract reported as uninitialized")
+	}
+
+	ctx := vm.Context{
+		CanTransfer: func(vm.StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(vm.StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  big.NewInt(1),
+		GasLimit:    1e8,
+		GasPrice:    big.NewInt(0),
+		Coinbase:    validators[1],
+	}
+	evm := vm.NewEVM(ctx, statedb, params.TestChainConfig, vm.Config{})
+	caller := vm.AccountRef(validators[1])
+
+	// getValidators must return the developer validators, not the compiled-in ones
+	ret, _, err := evm.Call(caller, contract, common.FromHex("b7ab4db5"), 1e8, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("getValidators failed: %v", err)
+	}
+	if len(ret) != 64+len(validators)*32 {
+		t.Fatalf("getValidators returned %d bytes", len(ret))
+	}
+	for i, val := range validators {
+		if have := common.BytesToAddress(ret[64+i*32 : 96+i*32]); have != val {
+			t.Errorf("validator %d mismatch: have %x, want %x", i, have, val)
+		}
+	}
+	// deposit must accept the fees of a listed validator
+	input := append(common.FromHex("f340fa01"), validators[1].Hash().Bytes()...)
+	if _, _, err := evm.Call(caller, contract, input, 1e8, big.NewInt(1000)); err != nil {
+		t.Errorf("deposit failed: %v", err)
+	}
+	// init must refuse to run a second time
+	if _, _, err := evm.Call(caller, contract, common.FromHex("e1c7392a"), 1e8, big.NewInt(0)); err == nil {
+		t.Errorf("init succeeded on a pre-initialized contract")
+	}
+}
@@ -0,0 +1,109 @@
+package systemcontracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/naoina/toml"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// upgradeManifest is the on-disk layout of a system contract upgrade manifest.
+type upgradeManifest struct {
+	Upgrades []*params.SystemContractUpgrade `json:"upgrades"`
+}
+
+// upgradeHooks are the hooks run around the contract upgrades of a configured
+// upgrade.
+type upgradeHooks struct {
+	before upgradeHook
+	after  upgradeHook
+}
+
+var (
+	// configuredHooks are the hooks attached to the configured upgrades, by name.
+	configuredHooks     = make(map[string]upgradeHooks)
+	configuredHooksLock sync.RWMutex
+)
+
+// RegisterUpgradeHook attaches hooks to the upgrade with the given name scheduled
+// in the chain config, run before and after the upgrade of each of its contracts.
+// Either hook may be nil. Hooks must be registered before any block is processed,
+// ideally from an init function, as they change the outcome of the upgrade.
+func RegisterUpgradeHook(name string, before, after func(blockNumber *big.Int, contractAddr common.Address, statedb *state.StateDB) error) {
+	configuredHooksLock.Lock()
+	defer configuredHooksLock.Unlock()
+
+	configuredHooks[name] = upgradeHooks{before: before, after: after}
+}
+
+// upgradeHooksOf returns the hooks attached to the configured upgrade with the
+// given name.
+func upgradeHooksOf(name string) upgradeHooks {
+	configuredHooksLock.RLock()
+	defer configuredHooksLock.RUnlock()
+
+	return configuredHooks[name]
+}
+
+// LoadUpgradeManifest schedules the upgrades of the JSON or TOML manifest the
+// chain config references, after the ones already in the config, and drops the
+// reference so that the config holds the upgrades themselves from then on. It is
+// a noop if the chain config references no manifest. The upgrades are checked
+// along with the rest of the config by CheckConfigForkOrder.
+func LoadUpgradeManifest(config *params.ChainConfig) error {
+	if config == nil || config.SystemContractUpgradesFile == "" {
+		return nil
+	}
+	path := config.SystemContractUpgradesFile
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read upgrade manifest: %v", err)
+	}
+	upgrades, err := parseUpgradeManifest(blob, strings.ToLower(filepath.Ext(path)) == ".toml")
+	if err != nil {
+		return fmt.Errorf("invalid upgrade manifest %s: %v", path, err)
+	}
+	config.SystemContractUpgrades = append(config.SystemContractUpgrades, upgrades...)
+	config.SystemContractUpgradesFile = ""
+	return nil
+}
+
+// parseUpgradeManifest decodes and checks a JSON or TOML manifest, normalising
+// the fork names to the ones of params.BSCForkTable.
+func parseUpgradeManifest(blob []byte, isTOML bool) ([]*params.SystemContractUpgrade, error) {
+	var manifest upgradeManifest
+	if isTOML {
+		if err := toml.Unmarshal(blob, &manifest); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := json.Unmarshal(blob, &manifest); err != nil {
+			return nil, err
+		}
+	}
+	if len(manifest.Upgrades) == 0 {
+		return nil, errors.New("no upgrades")
+	}
+	for _, upgrade := range manifest.Upgrades {
+		for _, fork := range params.BSCForkTable {
+			if strings.EqualFold(fork.Name, upgrade.Fork) {
+				upgrade.Fork = fork.Name
+			}
+		}
+	}
+	if err := (&params.ChainConfig{SystemContractUpgrades: manifest.Upgrades}).CheckConfigForkOrder(); err != nil {
+		return nil, err
+	}
+	return manifest.Upgrades, nil
+}
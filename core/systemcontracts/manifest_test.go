@@ -0,0 +1,123 @@
+package systemcontracts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestParseUpgradeManifest(t *testing.T) {
+	code := []byte{0x60, 0x80, 0x60, 0x40}
+	hash := crypto.Keccak256Hash(code)
+
+	manifest := fmt.Sprintf(`{"upgrades": [{"name": "custom", "fork": "Niels", "configs": [
+		{"contractAddr": "%s", "codeHash": "%s", "code": "0x%x"}
+	]}]}`, ValidatorContract, hash.Hex(), code)
+
+	upgrades, err := parseUpgradeManifest([]byte(manifest), false)
+	if err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	if len(upgrades) != 1 || upgrades[0].Fork != params.NielsFork || len(upgrades[0].Configs) != 1 {
+		t.Fatalf("unexpected upgrades: %v", upgrades)
+	}
+	if cfg := upgrades[0].Configs[0]; cfg.ContractAddr != common.HexToAddress(ValidatorContract) || cfg.CodeHash != hash {
+		t.Errorf("unexpected upgrade config: %+v", cfg)
+	}
+
+	tomlManifest := fmt.Sprintf(`
+[[upgrades]]
+name = "custom"
+fork = "ramanujan"
+
+[[upgrades.configs]]
+contractAddr = "%s"
+codeHash = "%s"
+code = "0x%x"
+`, SlashContract, hash.Hex(), code)
+	if upgrades, err = parseUpgradeManifest([]byte(tomlManifest), true); err != nil {
+		t.Fatalf("failed to parse TOML manifest: %v", err)
+	}
+	if len(upgrades) != 1 || upgrades[0].Fork != params.RamanujanFork {
+		t.Fatalf("unexpected TOML upgrades: %v", upgrades)
+	}
+
+	invalid := []string{
+		// No upgrades
+		`{"upgrades": []}`,
+		// Code hash mismatch
+		fmt.Sprintf(`{"upgrades": [{"name": "bad", "fork": "niels", "configs": [{"contractAddr": "%s", "codeHash": "%s", "code": "0x%x"}]}]}`,
+			ValidatorContract, common.Hash{0x01}.Hex(), code),
+		// Unknown fork
+		fmt.Sprintf(`{"upgrades": [{"name": "bad", "fork": "shanghai", "configs": [{"contractAddr": "%s", "codeHash": "%s", "code": "0x%x"}]}]}`,
+			ValidatorContract, hash.Hex(), code),
+	}
+	for i, manifest := range invalid {
+		if _, err := parseUpgradeManifest([]byte(manifest), false); err == nil {
+			t.Errorf("manifest %d: expected error", i)
+		}
+	}
+}
+
+func TestLoadUpgradeManifest(t *testing.T) {
+	code := []byte{0x60, 0x80, 0x60, 0x40}
+
+	dir, err := ioutil.TempDir("", "upgrades")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "upgrades.json")
+	manifest := fmt.Sprintf(`{"upgrades": [{"name": "hooked", "fork": "niels", "configs": [
+		{"contractAddr": "%s", "codeHash": "%s", "code": "0x%x"}
+	]}]}`, SlashContract, crypto.Keccak256Hash(code).Hex(), code)
+	if err := ioutil.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	config := &params.ChainConfig{NielsBlock: big.NewInt(10), SystemContractUpgradesFile: path}
+	if err := LoadUpgradeManifest(config); err != nil {
+		t.Fatalf("failed to load manifest: %v", err)
+	}
+	if len(config.SystemContractUpgrades) != 1 || config.SystemContractUpgradesFile != "" {
+		t.Fatalf("manifest not moved into the config: %d upgrades, file %q", len(config.SystemContractUpgrades), config.SystemContractUpgradesFile)
+	}
+	// The hooks registered under the upgrade name run around its contracts
+	var before, after []common.Address
+	RegisterUpgradeHook("hooked",
+		func(blockNumber *big.Int, contractAddr common.Address, statedb *state.StateDB) error {
+			if len(statedb.GetCode(contractAddr)) != 0 {
+				t.Errorf("before hook run after the upgrade of %x", contractAddr)
+			}
+			before = append(before, contractAddr)
+			return nil
+		},
+		func(blockNumber *big.Int, contractAddr common.Address, statedb *state.StateDB) error {
+			after = append(after, contractAddr)
+			return nil
+		},
+	)
+	defer RegisterUpgradeHook("hooked", nil, nil)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	UpgradeBuildInSystemContract(config, big.NewInt(10), statedb)
+	if len(before) != 1 || before[0] != common.HexToAddress(SlashContract) {
+		t.Errorf("before hook calls mismatch: %v", before)
+	}
+	if len(after) != 1 || after[0] != common.HexToAddress(SlashContract) {
+		t.Errorf("after hook calls mismatch: %v", after)
+	}
+	// A missing manifest is an error
+	if err := LoadUpgradeManifest(&params.ChainConfig{SystemContractUpgradesFile: filepath.Join(dir, "missing.json")}); err == nil {
+		t.Errorf("missing manifest loaded")
+	}
+}
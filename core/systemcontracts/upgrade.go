@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -16,6 +17,7 @@ type UpgradeConfig struct {
 	AfterUpgrade  upgradeHook
 	ContractAddr  common.Address
 	CommitUrl     string
+	CodeHash      common.Hash // Optional keccak256 of the code, verified before the upgrade
 	Code          string
 }
 
@@ -39,6 +41,12 @@ var (
 	ramanujanUpgrade = make(map[string]*Upgrade)
 
 	nielsUpgrade = make(map[string]*Upgrade)
+
+	// builtinUpgrades maps the name of a fork to its upgrades per network
+	builtinUpgrades = map[string]map[string]*Upgrade{
+		params.RamanujanFork: ramanujanUpgrade,
+		params.NielsFork:     nielsUpgrade,
+	}
 )
 
 func init() {
@@ -215,6 +223,33 @@ func UpgradeBuildInSystemContract(config *params.ChainConfig, blockNumber *big.I
 	if config == nil || blockNumber == nil || statedb == nil {
 		return
 	}
+	logger := log.New("system-contract-upgrade")
+	for _, upgrade := range upgradesAt(config, blockNumber) {
+		applySystemContractUpgrade(upgrade, blockNumber, statedb, logger)
+	}
+}
+
+// UpgradedContracts returns the addresses of the system contracts whose code
+// UpgradeBuildInSystemContract replaces at the given block.
+func UpgradedContracts(config *params.ChainConfig, blockNumber *big.Int) []common.Address {
+	if config == nil || blockNumber == nil {
+		return nil
+	}
+	var contracts []common.Address
+	for _, upgrade := range upgradesAt(config, blockNumber) {
+		if upgrade == nil {
+			continue
+		}
+		for _, cfg := range upgrade.Configs {
+			contracts = append(contracts, cfg.ContractAddr)
+		}
+	}
+	return contracts
+}
+
+// upgradesAt returns the built-in and configured upgrades scheduled at the given
+// block, in application order. Built-in upgrades missing for the network are nil.
+func upgradesAt(config *params.ChainConfig, blockNumber *big.Int) []*Upgrade {
 	var network string
 	switch GenesisHash {
 	/* Add mainnet genesis hash */
@@ -226,18 +261,41 @@ func UpgradeBuildInSystemContract(config *params.ChainConfig, blockNumber *big.I
 		network = defaultNet
 	}
 
-	logger := log.New("system-contract-upgrade")
-	if config.IsOnRamanujan(blockNumber) {
-		applySystemContractUpgrade(ramanujanUpgrade[network], blockNumber, statedb, logger)
+	var scheduled []*Upgrade
+	for _, fork := range params.BSCForkTable {
+		if !config.IsOnBSCFork(fork.Name, blockNumber) {
+			continue
+		}
+		if upgrades, ok := builtinUpgrades[fork.Name]; ok {
+			scheduled = append(scheduled, upgrades[network])
+		}
+		for _, upgrade := range config.SystemContractUpgrades {
+			if upgrade.Fork == fork.Name {
+				scheduled = append(scheduled, configuredUpgrade(upgrade))
+			}
+		}
 	}
+	return scheduled
+}
 
-	if config.IsOnNiels(blockNumber) {
-		applySystemContractUpgrade(nielsUpgrade[network], blockNumber, statedb, logger)
-	}
+// configuredUpgrade converts an upgrade scheduled in the chain config, checked
+// when the config was set up, into the format of the built-in ones, with the
+// hooks registered for it attached.
+func configuredUpgrade(upgrade *params.SystemContractUpgrade) *Upgrade {
+	hooks := upgradeHooksOf(upgrade.Name)
 
-	/*
-		apply other upgrades
-	*/
+	converted := &Upgrade{UpgradeName: upgrade.Name}
+	for _, cfg := range upgrade.Configs {
+		converted.Configs = append(converted.Configs, &UpgradeConfig{
+			BeforeUpgrade: hooks.before,
+			AfterUpgrade:  hooks.after,
+			ContractAddr:  cfg.ContractAddr,
+			CommitUrl:     cfg.CommitUrl,
+			CodeHash:      cfg.CodeHash,
+			Code:          hex.EncodeToString(cfg.Code),
+		})
+	}
+	return converted
 }
 
 func applySystemContractUpgrade(upgrade *Upgrade, blockNumber *big.Int, statedb *state.StateDB, logger log.Logger) {
@@ -261,6 +319,11 @@ func applySystemContractUpgrade(upgrade *Upgrade, blockNumber *big.Int, statedb
 		if err != nil {
 			panic(fmt.Errorf("failed to decode new contract code: %s", err.Error()))
 		}
+		if cfg.CodeHash != (common.Hash{}) {
+			if hash := crypto.Keccak256Hash(newContractCode); hash != cfg.CodeHash {
+				panic(fmt.Errorf("contract address: %s, code hash mismatch: have %s, want %s", cfg.ContractAddr.String(), hash.String(), cfg.CodeHash.String()))
+			}
+		}
 		statedb.SetCode(cfg.ContractAddr, newContractCode)
 
 		if cfg.AfterUpgrade != nil {
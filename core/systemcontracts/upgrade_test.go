@@ -0,0 +1,62 @@
+package systemcontracts
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestUpgradedContracts(t *testing.T) {
+	defer func(hash common.Hash) { GenesisHash = hash }(GenesisHash)
+	GenesisHash = params.RialtoGenesisHash
+
+	config := &params.ChainConfig{RamanujanBlock: big.NewInt(10)}
+	if contracts := UpgradedContracts(config, big.NewInt(9)); len(contracts) != 0 {
+		t.Fatalf("upgrades reported before the fork: %v", contracts)
+	}
+	contracts := UpgradedContracts(config, big.NewInt(10))
+	if len(contracts) != len(ramanujanUpgrade[rialtoNet].Configs) {
+		t.Fatalf("upgraded contract count mismatch: have %d, want %d", len(contracts), len(ramanujanUpgrade[rialtoNet].Configs))
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	UpgradeBuildInSystemContract(config, big.NewInt(10), statedb)
+	for _, addr := range contracts {
+		if len(statedb.GetCode(addr)) == 0 {
+			t.Errorf("contract %x reported upgraded but has no code", addr)
+		}
+	}
+}
+
+func TestConfiguredUpgrades(t *testing.T) {
+	code := []byte{0x60, 0x80, 0x60, 0x40}
+	config := &params.ChainConfig{
+		NielsBlock: big.NewInt(10),
+		SystemContractUpgrades: []*params.SystemContractUpgrade{{
+			Name: "custom",
+			Fork: params.NielsFork,
+			Configs: []*params.SystemContractUpgradeConfig{{
+				ContractAddr: common.HexToAddress(SlashContract),
+				CodeHash:     crypto.Keccak256Hash(code),
+				Code:         code,
+			}},
+		}},
+	}
+	if contracts := UpgradedContracts(config, big.NewInt(9)); len(contracts) != 0 {
+		t.Fatalf("upgrades reported before the fork: %v", contracts)
+	}
+	// The configured upgrade applies after the built-in ones of the fork
+	contracts := UpgradedContracts(config, big.NewInt(10))
+	if len(contracts) == 0 || contracts[len(contracts)-1] != common.HexToAddress(SlashContract) {
+		t.Fatalf("configured upgrade not scheduled last: %v", contracts)
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	UpgradeBuildInSystemContract(config, big.NewInt(10), statedb)
+	if have := statedb.GetCode(common.HexToAddress(SlashContract)); string(have) != string(code) {
+		t.Errorf("configured upgrade code mismatch: have %x, want %x", have, code)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// txHistoryLimit is the number of transactions the pool remembers the latest
+// state of, including the ones which left the pool.
+const txHistoryLimit = 16384
+
+// States of a transaction in the pool, the last three final.
+const (
+	TxStateQueued   = "queued"   // Waiting in the queue for the nonce gap to be filled
+	TxStatePending  = "pending"  // Executable, waiting to be included in a block
+	TxStateReplaced = "replaced" // Replaced by a transaction with the same nonce paying more
+	TxStateDropped  = "dropped"  // Dropped from the pool, see the reason
+	TxStateIncluded = "included" // Included in a block
+)
+
+// Reasons for dropping a transaction from the pool.
+const (
+	TxDropUnderpriced   = "underpriced"         // Pool full, discarded for a better paying transaction
+	TxDropPriceLimit    = "price limit"         // Below the minimum gas price enforced by the pool
+	TxDropNonceTooLow   = "nonce too low"       // Nonce used by another transaction included in a block
+	TxDropUnpayable     = "insufficient funds"  // Sender balance or block gas limit too low to execute
+	TxDropAccountQueue  = "account queue limit" // Too many queued transactions of the sender
+	TxDropPendingLimit  = "pending limit"       // Too many executable transactions in the pool
+	TxDropQueueLimit    = "queue limit"         // Too many queued transactions in the pool
+	TxDropExpired       = "expired"             // Queued longer than the pool lifetime
+	TxDropPrivateExpiry = "private expiry"      // Private transaction not included by its last block
+)
+
+// TxStateChange is a state transition of a transaction in the pool.
+type TxStateChange struct {
+	Hash        common.Hash  `json:"hash"`
+	State       string       `json:"state"`
+	Reason      string       `json:"reason,omitempty"`      // Why the transaction was dropped
+	ReplacedBy  *common.Hash `json:"replacedBy,omitempty"`  // Transaction replacing the replaced one
+	BlockNumber uint64       `json:"blockNumber,omitempty"` // Block the transaction was included in
+	Time        uint64       `json:"time"`                  // Unix time of the transition
+}
+
+// txHistoryEntry is the latest state of a transaction the pool remembers, along
+// with whether it is a private one, to keep hiding it once out of the pool.
+type txHistoryEntry struct {
+	change  *TxStateChange
+	private bool
+}
+
+// txChanged records a state transition of a transaction, to be posted with the
+// next batch of changes unless the transaction is a private one.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) txChanged(change TxStateChange) {
+	change.Time = uint64(time.Now().Unix())
+
+	_, private := pool.private[change.Hash]
+	if cached, ok := pool.history.Peek(change.Hash); ok {
+		private = private || cached.(*txHistoryEntry).private
+	}
+	if !private {
+		pool.changes = append(pool.changes, &change)
+	}
+	pool.history.Add(change.Hash, &txHistoryEntry{change: &change, private: private})
+}
+
+// txsDropped records the given transactions as dropped for the given reason, or
+// as included if they were in the blocks the pool was last reset to.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) txsDropped(txs types.Transactions, reason string) {
+	for _, tx := range txs {
+		hash := tx.Hash()
+		if number, ok := pool.included[hash]; ok {
+			pool.txChanged(TxStateChange{Hash: hash, State: TxStateIncluded, BlockNumber: number})
+		} else {
+			pool.txChanged(TxStateChange{Hash: hash, State: TxStateDropped, Reason: reason})
+		}
+	}
+}
+
+// postTxChanges posts the state transitions recorded since last posted.
+func (pool *TxPool) postTxChanges() {
+	pool.mu.Lock()
+	changes := pool.changes
+	pool.changes = nil
+	pool.mu.Unlock()
+
+	if len(changes) > 0 {
+		pool.changeFeed.Send(TxStateChangeEvent{Changes: changes})
+	}
+}
+
+// includedTxs maps the transactions in the blocks from the old head, exclusive,
+// to the new one to the number of the block including them, looking at no more
+// than 64 blocks.
+func (pool *TxPool) includedTxs(oldHead, newHead *types.Header) map[common.Hash]uint64 {
+	included := make(map[common.Hash]uint64)
+	if newHead == nil {
+		return included
+	}
+	block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64())
+	for i := 0; block != nil && i < 64; i++ {
+		if oldHead != nil && block.Hash() == oldHead.Hash() {
+			break
+		}
+		for _, tx := range block.Transactions() {
+			included[tx.Hash()] = block.NumberU64()
+		}
+		if oldHead == nil || block.NumberU64() == 0 {
+			break
+		}
+		block = pool.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	return included
+}
+
+// TxState returns the latest state of a transaction in the pool or which left
+// it recently, nil if unknown. Private transactions are only reported if asked
+// for, even after they left the pool.
+func (pool *TxPool) TxState(hash common.Hash, private bool) *TxStateChange {
+	if cached, ok := pool.history.Get(hash); ok {
+		entry := cached.(*txHistoryEntry)
+		if entry.private && !private {
+			return nil
+		}
+		change := *entry.change
+		return &change
+	}
+	if !private && pool.IsPrivate(hash) {
+		return nil
+	}
+	switch pool.Status([]common.Hash{hash})[0] {
+	case TxStatusPending:
+		return &TxStateChange{Hash: hash, State: TxStatePending}
+	case TxStatusQueued:
+		return &TxStateChange{Hash: hash, State: TxStateQueued}
+	}
+	return nil
+}
+
+// SubscribeTxStateChangeEvent registers a subscription of TxStateChangeEvent
+// and starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxStateChangeEvent(ch chan<- TxStateChangeEvent) event.Subscription {
+	return pool.scope.Track(pool.changeFeed.Subscribe(ch))
+}
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"math"
 	"math/big"
@@ -32,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 const (
@@ -91,6 +93,14 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrPeerRateLimited is returned if a transaction is received from a peer
+	// sending transactions faster than the rate allowed per peer.
+	ErrPeerRateLimited = errors.New("peer transaction rate exceeded")
+
+	// ErrSenderRateLimited is returned if a remote transaction is signed by a
+	// sender sending transactions faster than the rate allowed per sender.
+	ErrSenderRateLimited = errors.New("sender transaction rate exceeded")
 )
 
 var (
@@ -116,10 +126,12 @@ var (
 	validTxMeter       = metrics.NewRegisteredMeter("txpool/valid", nil)
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
+	ratelimitedTxMeter = metrics.NewRegisteredMeter("txpool/ratelimited", nil)
 
 	pendingGauge = metrics.NewRegisteredGauge("txpool/pending", nil)
 	queuedGauge  = metrics.NewRegisteredGauge("txpool/queued", nil)
 	localGauge   = metrics.NewRegisteredGauge("txpool/local", nil)
+	privateGauge = metrics.NewRegisteredGauge("txpool/private", nil)
 	slotsGauge   = metrics.NewRegisteredGauge("txpool/slots", nil)
 )
 
@@ -148,7 +160,7 @@ type TxPoolConfig struct {
 	Locals    []common.Address // Addresses that should be treated by default as local
 	NoLocals  bool             // Whether local transaction handling should be disabled
 	Journal   string           // Journal of local transactions to survive node restarts
-	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
+	Rejournal time.Duration    // Time interval to regenerate the local transaction journal and the snapshot
 
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
@@ -159,6 +171,12 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	PeerRate   uint64 // Maximum number of transactions admitted per second from a peer (0 = unlimited)
+	SenderRate uint64 // Maximum number of remote transactions admitted per second from a sender (0 = unlimited)
+
+	Snapshot      string // Snapshot of the remote transactions to refill the pool after node restarts (empty = disabled)
+	SnapshotLimit uint64 // Maximum number of remote transactions kept in the snapshot
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -176,6 +194,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	SnapshotLimit: 5120,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -214,6 +234,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.Snapshot != "" && conf.SnapshotLimit < 1 {
+		log.Warn("Sanitizing invalid txpool snapshot limit", "provided", conf.SnapshotLimit, "updated", DefaultTxPoolConfig.SnapshotLimit)
+		conf.SnapshotLimit = DefaultTxPoolConfig.SnapshotLimit
+	}
 	return conf
 }
 
@@ -230,6 +254,7 @@ type TxPool struct {
 	chain       blockChain
 	gasPrice    *big.Int
 	txFeed      event.Feed
+	changeFeed  event.Feed
 	scope       event.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
@@ -240,8 +265,20 @@ type TxPool struct {
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals  *accountSet            // Set of local transaction to exempt from eviction rules
+	journal *txJournal             // Journal of local transaction to back up to disk
+	private map[common.Hash]uint64 // Transactions kept from the network, by the last block to include them in (0 = any)
+
+	snapshot *txSnapshot              // Snapshot of the remote transactions to back up to disk
+	origins  map[common.Hash]txOrigin // Arrival of the remote transactions, only tracked if snapshotting
+
+	peerLimiter   *txRateLimiter // Admission rate limits of the peers, nil if unlimited
+	senderLimiter *txRateLimiter // Admission rate limits of the remote senders, nil if unlimited
+	reputation    *txReputation  // Track record of the remote senders
+
+	changes  []*TxStateChange       // State transitions of transactions not yet posted
+	history  *lru.Cache             // Latest state of the transactions recently seen, by hash
+	included map[common.Hash]uint64 // Transactions included in the blocks of the last reset, by hash
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -278,6 +315,9 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
+		private:         make(map[common.Hash]uint64),
+		reputation:      newTxReputation(),
+		included:        make(map[common.Hash]uint64),
 		all:             newTxLookup(),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
@@ -287,6 +327,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		reorgShutdownCh: make(chan struct{}),
 		gasPrice:        new(big.Int).SetUint64(config.PriceLimit),
 	}
+	pool.history, _ = lru.New(txHistoryLimit)
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
@@ -310,6 +351,18 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote transaction snapshotting is enabled, refill the pool from disk
+	if config.Snapshot != "" {
+		pool.snapshot = newTxSnapshot(config.Snapshot, int(config.SnapshotLimit))
+		pool.origins = make(map[common.Hash]txOrigin)
+
+		if err := pool.snapshot.load(config.Lifetime, pool.addSnapshotted); err != nil {
+			log.Warn("Failed to load transaction pool snapshot", "err", err)
+		}
+	}
+	// Rate limit the transactions arriving from now on, the reloaded ones exempt
+	pool.peerLimiter = newTxRateLimiter(config.PeerRate)
+	pool.senderLimiter = newTxRateLimiter(config.SenderRate)
 
 	// Subscribe events from blockchain and start the main event loop.
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
@@ -374,12 +427,20 @@ func (pool *TxPool) loop() {
 				}
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
-					for _, tx := range pool.queue[addr].Flatten() {
+					txs := pool.queue[addr].Flatten()
+					for _, tx := range txs {
 						pool.removeTx(tx.Hash(), true)
 					}
+					pool.reputation.record(addr, txExpired, len(txs))
+					pool.txsDropped(txs, TxDropExpired)
 				}
 			}
+			pool.pruneOrigins()
 			pool.mu.Unlock()
+			pool.postTxChanges()
+
+			pool.peerLimiter.prune()
+			pool.senderLimiter.prune()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
@@ -390,6 +451,7 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			pool.writeSnapshot()
 		}
 	}
 }
@@ -406,6 +468,7 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	pool.writeSnapshot()
 	log.Info("Transaction pool stopped")
 }
 
@@ -426,13 +489,17 @@ func (pool *TxPool) GasPrice() *big.Int {
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
+	defer pool.postTxChanges()
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
 	pool.gasPrice = price
-	for _, tx := range pool.priced.Cap(price, pool.locals) {
+	drop := pool.priced.Cap(price, pool.locals)
+	for _, tx := range drop {
 		pool.removeTx(tx.Hash(), false)
 	}
+	pool.txsDropped(drop, TxDropPriceLimit)
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
@@ -509,15 +576,20 @@ func (pool *TxPool) Locals() []common.Address {
 
 // local retrieves all currently known local transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
-// freely modified by calling code.
+// freely modified by calling code. Private transactions are never journaled, so
+// they are left out.
 func (pool *TxPool) local() map[common.Address]types.Transactions {
 	txs := make(map[common.Address]types.Transactions)
 	for addr := range pool.locals.accounts {
-		if pending := pool.pending[addr]; pending != nil {
-			txs[addr] = append(txs[addr], pending.Flatten()...)
-		}
-		if queued := pool.queue[addr]; queued != nil {
-			txs[addr] = append(txs[addr], queued.Flatten()...)
+		for _, list := range []*txList{pool.pending[addr], pool.queue[addr]} {
+			if list == nil {
+				continue
+			}
+			for _, tx := range list.Flatten() {
+				if _, ok := pool.private[tx.Hash()]; !ok {
+					txs[addr] = append(txs[addr], tx)
+				}
+			}
 		}
 	}
 	return txs
@@ -575,8 +647,9 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 //
 // If a newly added transaction is marked as local, its sending account will be
 // whitelisted, preventing any associated transaction from being dropped out of the pool
-// due to pricing constraints.
-func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err error) {
+// due to pricing constraints. If limit is set, remote senders are held to their
+// admission rate, as befits transactions newly arriving from RPC or the network.
+func (pool *TxPool) add(tx *types.Transaction, local, limit bool) (replaced bool, err error) {
 	// If the transaction is already known, discard it
 	hash := tx.Hash()
 	if pool.all.Get(hash) != nil {
@@ -590,6 +663,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// If the remote sender exceeded its admission rate, discard it
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if limit && !local && !pool.locals.contains(from) && !pool.senderLimiter.allow(from.Hex()) {
+		log.Trace("Discarding rate limited transaction", "hash", hash, "from", from)
+		ratelimitedTxMeter.Mark(1)
+		return false, ErrSenderRateLimited
+	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -604,10 +684,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxMeter.Mark(1)
 			pool.removeTx(tx.Hash(), false)
+
+			dropped, _ := types.Sender(pool.signer, tx)
+			pool.recordReputation(dropped, txDropped, 1)
+			pool.txChanged(TxStateChange{Hash: tx.Hash(), State: TxStateDropped, Reason: TxDropUnderpriced})
 		}
 	}
 	// Try to replace an existing transaction in the pending pool
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -620,7 +703,10 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.recordReputation(from, txReplaced, 1)
+			pool.txChanged(TxStateChange{Hash: old.Hash(), State: TxStateReplaced, ReplacedBy: &hash})
 		}
+		pool.txChanged(TxStateChange{Hash: hash, State: TxStatePending})
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
 		pool.journalTx(from, tx)
@@ -633,14 +719,15 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
-	// Mark local addresses and journal local transactions
-	if local {
+	// Mark local addresses and journal local transactions. Private transactions
+	// don't mark their senders, which would be exempt from the limits for good
+	if _, private := pool.private[hash]; local && !private {
 		if !pool.locals.contains(from) {
 			log.Info("Setting new local account", "address", from)
 			pool.locals.add(from)
 		}
 	}
-	if local || pool.locals.contains(from) {
+	if pool.locals.contains(from) {
 		localGauge.Inc(1)
 	}
 	pool.journalTx(from, tx)
@@ -669,10 +756,13 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.recordReputation(from, txReplaced, 1)
+		pool.txChanged(TxStateChange{Hash: old.Hash(), State: TxStateReplaced, ReplacedBy: &hash})
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
 	}
+	pool.txChanged(TxStateChange{Hash: hash, State: TxStateQueued})
 	if pool.all.Get(hash) == nil {
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
@@ -680,13 +770,121 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	return old != nil, nil
 }
 
+// recordReputation counts the given outcome of count transactions of a sender
+// in its reputation, unless it is a local one.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) recordReputation(addr common.Address, event reputationEvent, count int) {
+	if !pool.locals.contains(addr) {
+		pool.reputation.record(addr, event, count)
+	}
+}
+
+// Reputation returns the track record of the remote senders recently active in
+// the pool.
+func (pool *TxPool) Reputation() map[common.Address]SenderReputation {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.reputation.dump()
+}
+
+// addSnapshotted adds a batch of snapshotted transactions to the pool, restoring
+// their origins. Having been admitted before the restart, they are not held to
+// the sender admission rates again.
+func (pool *TxPool) addSnapshotted(entries []*txSnapshotEntry) []error {
+	txs := make([]*types.Transaction, len(entries))
+	for i, entry := range entries {
+		txs[i] = entry.Tx
+	}
+	errs := pool.addTxs(txs, "", false, false, true)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for i, err := range errs {
+		if err == nil {
+			pool.origins[txs[i].Hash()] = entries[i].Origin
+		}
+	}
+	return errs
+}
+
+// pruneOrigins forgets the origins of the transactions no longer in the pool.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) pruneOrigins() {
+	for hash := range pool.origins {
+		if pool.all.Get(hash) == nil {
+			delete(pool.origins, hash)
+		}
+	}
+}
+
+// writeSnapshot regenerates the snapshot of the remote transactions, if enabled.
+// The limit is filled by whole accounts in address order, taking the pending
+// transactions before the queued ones, so the stored set doesn't depend on the
+// order the pool is walked in.
+func (pool *TxPool) writeSnapshot() {
+	if pool.snapshot == nil {
+		return
+	}
+	pool.mu.RLock()
+	var (
+		entries []*txSnapshotEntry
+		skipped = make(map[common.Address]struct{})
+	)
+	for _, set := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		addrs := make([]common.Address, 0, len(set))
+		for addr := range set {
+			if !pool.locals.contains(addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+		sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+
+		for _, addr := range addrs {
+			// Queued transactions are of no use without the pending ones of the account
+			if _, ok := skipped[addr]; ok {
+				continue
+			}
+			var account []*txSnapshotEntry
+			for _, tx := range set[addr].Flatten() {
+				// Private transactions don't mark their senders local, never let
+				// them out as public ones after a restart
+				if _, ok := pool.private[tx.Hash()]; ok {
+					continue
+				}
+				origin, ok := pool.origins[tx.Hash()]
+				if !ok {
+					origin = txOrigin{Time: uint64(time.Now().Unix())}
+				}
+				account = append(account, &txSnapshotEntry{Tx: tx, Origin: origin})
+			}
+			if len(entries)+len(account) > pool.snapshot.limit {
+				skipped[addr] = struct{}{}
+				continue
+			}
+			entries = append(entries, account...)
+		}
+	}
+	pool.mu.RUnlock()
+
+	if err := pool.snapshot.write(entries); err != nil {
+		log.Warn("Failed to regenerate transaction pool snapshot", "err", err)
+	}
+}
+
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
-	// Only journal if it's enabled and the transaction is local
+	// Only journal if it's enabled and the transaction is local and public
 	if pool.journal == nil || !pool.locals.contains(from) {
 		return
 	}
+	if _, ok := pool.private[tx.Hash()]; ok {
+		return
+	}
 	if err := pool.journal.insert(tx); err != nil {
 		log.Warn("Failed to journal local transaction", "err", err)
 	}
@@ -710,6 +908,8 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed(1)
 
 		pendingDiscardMeter.Mark(1)
+		existing := list.txs.Get(tx.Nonce()).Hash()
+		pool.txChanged(TxStateChange{Hash: hash, State: TxStateReplaced, ReplacedBy: &existing})
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -718,6 +918,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed(1)
 
 		pendingReplaceMeter.Mark(1)
+		pool.txChanged(TxStateChange{Hash: old.Hash(), State: TxStateReplaced, ReplacedBy: &hash})
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
@@ -730,6 +931,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
+	pool.txChanged(TxStateChange{Hash: hash, State: TxStatePending})
 
 	return true
 }
@@ -740,7 +942,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 // This method is used to add transactions from the RPC API and performs synchronous pool
 // reorganization and event propagation.
 func (pool *TxPool) AddLocals(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, !pool.config.NoLocals, true)
+	return pool.addTxs(txs, "", !pool.config.NoLocals, true, true)
 }
 
 // AddLocal enqueues a single local transaction into the pool if it is valid. This is
@@ -750,18 +952,88 @@ func (pool *TxPool) AddLocal(tx *types.Transaction) error {
 	return errs[0]
 }
 
+// AddPrivate enqueues a single local transaction into the pool if it is valid,
+// keeping it from the network: it is neither announced to peers nor sent to the
+// ones asking for it, and only ever included in the blocks built locally. If
+// maxBlock is not zero, the transaction is dropped once the chain reaches that
+// block without including it. Unlike AddLocal, the sender is not marked local.
+func (pool *TxPool) AddPrivate(tx *types.Transaction, maxBlock uint64) error {
+	// Transactions already known may have been propagated already
+	hash := tx.Hash()
+	if pool.all.Get(hash) != nil {
+		knownTxMeter.Mark(1)
+		return ErrAlreadyKnown
+	}
+	pool.mu.Lock()
+	pool.private[hash] = maxBlock
+	pool.mu.Unlock()
+
+	if err := pool.AddLocal(tx); err != nil {
+		pool.mu.Lock()
+		if pool.all.Get(hash) == nil {
+			delete(pool.private, hash)
+		}
+		pool.mu.Unlock()
+		return err
+	}
+	pool.mu.Lock()
+	privateGauge.Update(int64(len(pool.private)))
+	pool.mu.Unlock()
+	return nil
+}
+
+// IsPrivate reports whether the transaction with the given hash is a private one
+// in the pool, to be kept from the network.
+func (pool *TxPool) IsPrivate(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	_, ok := pool.private[hash]
+	return ok
+}
+
 // AddRemotes enqueues a batch of transactions into the pool if they are valid. If the
 // senders are not among the locally tracked ones, full pricing constraints will apply.
 //
 // This method is used to add transactions from the p2p network and does not wait for pool
 // reorganization and internal event propagation.
 func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, false, false)
+	return pool.addTxs(txs, "", false, true, false)
+}
+
+// AddRemotesFrom is like AddRemotes for transactions received from the given peer,
+// rejecting the ones beyond the admission rate of the peer.
+func (pool *TxPool) AddRemotesFrom(peer string, txs []*types.Transaction) []error {
+	if pool.peerLimiter == nil {
+		return pool.addTxs(txs, peer, false, true, false)
+	}
+	var (
+		errs    = make([]error, len(txs))
+		allowed = make([]*types.Transaction, 0, len(txs))
+		slots   = make([]int, 0, len(txs))
+	)
+	for i, tx := range txs {
+		// Known transactions are cheap to reject, don't count them against the peer
+		if pool.all.Get(tx.Hash()) == nil && !pool.peerLimiter.allow(peer) {
+			errs[i] = ErrPeerRateLimited
+			ratelimitedTxMeter.Mark(1)
+			continue
+		}
+		allowed = append(allowed, tx)
+		slots = append(slots, i)
+	}
+	if len(allowed) == 0 {
+		return errs
+	}
+	for i, err := range pool.addTxs(allowed, peer, false, true, false) {
+		errs[slots[i]] = err
+	}
+	return errs
 }
 
 // This is like AddRemotes, but waits for pool reorganization. Tests use this method.
 func (pool *TxPool) AddRemotesSync(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, false, true)
+	return pool.addTxs(txs, "", false, true, true)
 }
 
 // This is like AddRemotes with a single transaction, but waits for pool reorganization. Tests use this method.
@@ -779,8 +1051,10 @@ func (pool *TxPool) AddRemote(tx *types.Transaction) error {
 	return errs[0]
 }
 
-// addTxs attempts to queue a batch of transactions if they are valid.
-func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
+// addTxs attempts to queue a batch of transactions if they are valid, received
+// from the given peer if any. Remote senders are held to their admission rate if
+// limit is set.
+func (pool *TxPool) addTxs(txs []*types.Transaction, peer string, local, limit, sync bool) []error {
 	// Filter out known ones without obtaining the pool lock or recovering signatures
 	var (
 		errs = make([]error, len(txs))
@@ -805,8 +1079,9 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 	}
 	// Process all the new transaction and merge any errors into the original slice
 	pool.mu.Lock()
-	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
+	newErrs, dirtyAddrs := pool.addTxsLocked(news, peer, local, limit)
 	pool.mu.Unlock()
+	pool.postTxChanges()
 
 	var nilSlot = 0
 	for _, err := range newErrs {
@@ -814,6 +1089,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 			nilSlot++
 		}
 		errs[nilSlot] = err
+		nilSlot++
 	}
 	// Reorg the pool internals if needed and return
 	done := pool.requestPromoteExecutables(dirtyAddrs)
@@ -825,15 +1101,18 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 
 // addTxsLocked attempts to queue a batch of transactions if they are valid.
 // The transaction pool lock must be held.
-func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
+func (pool *TxPool) addTxsLocked(txs []*types.Transaction, peer string, local, limit bool) ([]error, *accountSet) {
 	dirty := newAccountSet(pool.signer)
 	errs := make([]error, len(txs))
 	for i, tx := range txs {
-		replaced, err := pool.add(tx, local)
+		replaced, err := pool.add(tx, local, limit)
 		errs[i] = err
 		if err == nil && !replaced {
 			dirty.addTx(tx)
 		}
+		if err == nil && !local && pool.origins != nil {
+			pool.origins[tx.Hash()] = txOrigin{Time: uint64(time.Now().Unix()), Peer: peer}
+		}
 	}
 	validTxMeter.Mark(int64(len(dirty.accounts)))
 	return errs, dirty
@@ -1064,6 +1343,9 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	// because of another transaction (e.g. higher gas price).
 	if reset != nil {
 		pool.demoteUnexecutables()
+		if reset.newHead != nil {
+			pool.expirePrivate(reset.newHead.Number.Uint64())
+		}
 	}
 	// Ensure pool.queue and pool.pending sizes stay within the configured limits.
 	pool.truncatePending()
@@ -1074,16 +1356,43 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 		txs := list.Flatten() // Heavy but will be cached and is needed by the miner anyway
 		pool.pendingNonces.set(addr, txs[len(txs)-1].Nonce()+1)
 	}
+	// Gather the newly added transactions, keeping the private ones from the
+	// subsystems propagating them
+	var txs []*types.Transaction
+	for _, set := range events {
+		for _, tx := range set.Flatten() {
+			if _, ok := pool.private[tx.Hash()]; !ok {
+				txs = append(txs, tx)
+			}
+		}
+	}
 	pool.mu.Unlock()
 
 	// Notify subsystems for newly added transactions
-	if len(events) > 0 {
-		var txs []*types.Transaction
-		for _, set := range events {
-			txs = append(txs, set.Flatten()...)
-		}
+	if len(txs) > 0 {
 		pool.txFeed.Send(NewTxsEvent{txs})
 	}
+	pool.postTxChanges()
+}
+
+// expirePrivate drops the private transactions not included in time for the
+// given head, and forgets the ones which left the pool.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) expirePrivate(head uint64) {
+	for hash, maxBlock := range pool.private {
+		if pool.all.Get(hash) == nil {
+			delete(pool.private, hash)
+			continue
+		}
+		if maxBlock != 0 && head >= maxBlock {
+			log.Trace("Dropping expired private transaction", "hash", hash, "maxBlock", maxBlock)
+			pool.removeTx(hash, true)
+			pool.txChanged(TxStateChange{Hash: hash, State: TxStateDropped, Reason: TxDropPrivateExpiry})
+			delete(pool.private, hash)
+		}
+	}
+	privateGauge.Update(int64(len(pool.private)))
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
@@ -1155,6 +1464,8 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	if newHead == nil {
 		newHead = pool.chain.CurrentBlock().Header() // Special case during testing
 	}
+	pool.included = pool.includedTxs(oldHead, newHead)
+
 	statedb, err := pool.chain.StateAt(newHead.Root)
 	if err != nil {
 		log.Error("Failed to reset txpool state", "err", err)
@@ -1164,10 +1475,11 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.currentMaxGas = newHead.GasLimit
 
-	// Inject any transactions discarded due to reorgs
+	// Inject any transactions discarded due to reorgs, these are not new arrivals
+	// to be held to the sender admission rates
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	senderCacher.recover(pool.signer, reinject)
-	pool.addTxsLocked(reinject, false)
+	pool.addTxsLocked(reinject, "", false, false)
 
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
@@ -1194,6 +1506,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			pool.all.Remove(hash)
 			log.Trace("Removed old queued transaction", "hash", hash)
 		}
+		pool.txsDropped(forwards, TxDropNonceTooLow)
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
 		for _, tx := range drops {
@@ -1201,6 +1514,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			pool.all.Remove(hash)
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
 		}
+		pool.txsDropped(drops, TxDropUnpayable)
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
 		// Gather all executable transactions and promote them
@@ -1224,6 +1538,8 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
 			queuedRateLimitMeter.Mark(int64(len(caps)))
+			pool.reputation.record(addr, txDropped, len(caps))
+			pool.txsDropped(caps, TxDropAccountQueue)
 		}
 		// Mark all the items dropped as removed
 		pool.priced.Removed(len(forwards) + len(drops) + len(caps))
@@ -1252,6 +1568,10 @@ func (pool *TxPool) truncatePending() {
 	}
 
 	pendingBeforeCap := pending
+
+	// Cap the senders with a bad reputation to their allowance first, worst first
+	pending = pool.truncateDisreputable(pending)
+
 	// Assemble a spam order to penalize large transactors first
 	spammers := prque.New(nil)
 	for addr, list := range pool.pending {
@@ -1289,6 +1609,8 @@ func (pool *TxPool) truncatePending() {
 					}
 					pool.priced.Removed(len(caps))
 					pendingGauge.Dec(int64(len(caps)))
+					pool.recordReputation(offenders[i], txDropped, len(caps))
+					pool.txsDropped(caps, TxDropPendingLimit)
 					if pool.locals.contains(offenders[i]) {
 						localGauge.Dec(int64(len(caps)))
 					}
@@ -1316,6 +1638,8 @@ func (pool *TxPool) truncatePending() {
 				}
 				pool.priced.Removed(len(caps))
 				pendingGauge.Dec(int64(len(caps)))
+				pool.recordReputation(addr, txDropped, len(caps))
+				pool.txsDropped(caps, TxDropPendingLimit)
 				if pool.locals.contains(addr) {
 					localGauge.Dec(int64(len(caps)))
 				}
@@ -1326,6 +1650,49 @@ func (pool *TxPool) truncatePending() {
 	pendingRateLimitMeter.Mark(int64(pendingBeforeCap - pending))
 }
 
+// truncateDisreputable drops the pending transactions of the remote senders with
+// a negative score beyond their guaranteed slots, lowest score first, until the
+// given pending count is within the pending limit. It returns the count left.
+func (pool *TxPool) truncateDisreputable(pending uint64) uint64 {
+	var offenders []common.Address
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots && pool.reputation.score(addr) < 0 {
+			offenders = append(offenders, addr)
+		}
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		return pool.reputation.score(offenders[i]) < pool.reputation.score(offenders[j])
+	})
+	for _, addr := range offenders {
+		if pending <= pool.config.GlobalSlots {
+			break
+		}
+		list := pool.pending[addr]
+
+		// Keep the guaranteed slots, or as many as fit under the limit
+		keep := int(pool.config.AccountSlots)
+		if excess := int(pending - pool.config.GlobalSlots); list.Len()-excess > keep {
+			keep = list.Len() - excess
+		}
+		caps := list.Cap(keep)
+		for _, tx := range caps {
+			// Drop the transaction from the global pools too
+			hash := tx.Hash()
+			pool.all.Remove(hash)
+
+			// Update the account nonce to the dropped transaction
+			pool.pendingNonces.setIfLower(addr, tx.Nonce())
+			log.Trace("Removed disreputable pending transaction", "hash", hash)
+		}
+		pool.priced.Removed(len(caps))
+		pendingGauge.Dec(int64(len(caps)))
+		pool.reputation.record(addr, txDropped, len(caps))
+		pool.txsDropped(caps, TxDropPendingLimit)
+		pending -= uint64(len(caps))
+	}
+	return pending
+}
+
 // truncateQueue drops the oldes transactions in the queue if the pool is above the global queue limit.
 func (pool *TxPool) truncateQueue() {
 	queued := uint64(0)
@@ -1345,6 +1712,10 @@ func (pool *TxPool) truncateQueue() {
 	}
 	sort.Sort(addresses)
 
+	// Drop the transactions of the senders with the lowest score first
+	sort.SliceStable(addresses, func(i, j int) bool {
+		return pool.reputation.score(addresses[i].address) > pool.reputation.score(addresses[j].address)
+	})
 	// Drop transactions until the total is below the limit or only locals remain
 	for drop := queued - pool.config.GlobalQueue; drop > 0 && len(addresses) > 0; {
 		addr := addresses[len(addresses)-1]
@@ -1354,19 +1725,24 @@ func (pool *TxPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true)
 			}
+			pool.txsDropped(txs, TxDropQueueLimit)
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
+			pool.reputation.record(addr.address, txDropped, int(size))
 			continue
 		}
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
+			pool.txsDropped(txs[i:i+1], TxDropQueueLimit)
 			drop--
 			queuedRateLimitMeter.Mark(1)
+			pool.reputation.record(addr.address, txDropped, 1)
 		}
 	}
 }
@@ -1386,6 +1762,8 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
+		pool.recordReputation(addr, txMined, len(olds))
+		pool.txsDropped(olds, TxDropNonceTooLow)
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
 		for _, tx := range drops {
@@ -1393,6 +1771,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 		}
+		pool.txsDropped(drops, TxDropUnpayable)
 		pool.priced.Removed(len(olds) + len(drops))
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
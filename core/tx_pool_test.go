@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
 	"io/ioutil"
@@ -354,14 +355,14 @@ func TestTransactionChainFork(t *testing.T) {
 	resetState()
 
 	tx := transaction(0, 100000, key)
-	if _, err := pool.add(tx, false); err != nil {
+	if _, err := pool.add(tx, false, true); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	pool.removeTx(tx.Hash(), true)
 
 	// reset the pool's internal state
 	resetState()
-	if _, err := pool.add(tx, false); err != nil {
+	if _, err := pool.add(tx, false, true); err != nil {
 		t.Error("didn't expect error", err)
 	}
 }
@@ -388,10 +389,10 @@ func TestTransactionDoubleNonce(t *testing.T) {
 	tx3, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 1000000, big.NewInt(1), nil), signer, key)
 
 	// Add the first two transaction, ensure higher priced stays only
-	if replace, err := pool.add(tx1, false); err != nil || replace {
+	if replace, err := pool.add(tx1, false, true); err != nil || replace {
 		t.Errorf("first transaction insert failed (%v) or reported replacement (%v)", err, replace)
 	}
-	if replace, err := pool.add(tx2, false); err != nil || !replace {
+	if replace, err := pool.add(tx2, false, true); err != nil || !replace {
 		t.Errorf("second transaction insert failed (%v) or not reported replacement (%v)", err, replace)
 	}
 	<-pool.requestPromoteExecutables(newAccountSet(signer, addr))
@@ -403,7 +404,7 @@ func TestTransactionDoubleNonce(t *testing.T) {
 	}
 
 	// Add the third transaction and ensure it's not saved (smaller price)
-	pool.add(tx3, false)
+	pool.add(tx3, false, true)
 	<-pool.requestPromoteExecutables(newAccountSet(signer, addr))
 	if pool.pending[addr].Len() != 1 {
 		t.Error("expected 1 pending transactions, got", pool.pending[addr].Len())
@@ -426,7 +427,7 @@ func TestTransactionMissingNonce(t *testing.T) {
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	pool.currentState.AddBalance(addr, big.NewInt(100000000000000))
 	tx := transaction(1, 100000, key)
-	if _, err := pool.add(tx, false); err != nil {
+	if _, err := pool.add(tx, false, true); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	if len(pool.pending) != 0 {
@@ -1571,6 +1572,44 @@ func TestTransactionDeduplication(t *testing.T) {
 	}
 }
 
+// Tests that the errors of a batch mixing known and new transactions are reported
+// in the slots of the transactions causing them.
+func TestTransactionBatchErrors(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	known := transaction(0, 100000, key)
+	if err := pool.AddRemote(known); err != nil {
+		t.Fatalf("failed to add known transaction: %v", err)
+	}
+	txs := []*types.Transaction{
+		known,
+		transaction(1, 100000, key),
+		transaction(2, pool.currentMaxGas+1, key),
+		transaction(3, 100000, key),
+		known,
+		transaction(4, 100000, key),
+	}
+	want := []error{ErrAlreadyKnown, nil, ErrGasLimit, nil, ErrAlreadyKnown, nil}
+
+	errs := pool.AddRemotesSync(txs)
+	if len(errs) != len(want) {
+		t.Fatalf("error count mismatch: have %d, want %d", len(errs), len(want))
+	}
+	for i, err := range errs {
+		if err != want[i] {
+			t.Errorf("transaction %d: error mismatch: have %v, want %v", i, err, want[i])
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that the pool rejects replacement transactions that don't meet the minimum
 // price bump required.
 func TestTransactionReplacement(t *testing.T) {
@@ -1914,3 +1953,494 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddRemotes(batch)
 	}
 }
+
+// Tests that private transactions are kept from the subsystems propagating the
+// pool content and from the journal, and that they are dropped once their max
+// block is reached.
+func TestTransactionPrivate(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	events := make(chan NewTxsEvent, 32)
+	sub := pool.txFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	// Add a private transaction and a public one depending on it
+	private, public := transaction(0, 100000, key), transaction(1, 100000, key)
+	if err := pool.AddPrivate(private, 5); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if pool.locals.contains(account) {
+		t.Fatalf("private transaction sender marked local")
+	}
+	if err := pool.AddLocal(public); err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	if err := validateEvents(events, 1); err != nil {
+		t.Fatalf("event firing failed: %v", err)
+	}
+	if !pool.IsPrivate(private.Hash()) || pool.IsPrivate(public.Hash()) {
+		t.Fatalf("private flags mismatch: have %v/%v, want true/false", pool.IsPrivate(private.Hash()), pool.IsPrivate(public.Hash()))
+	}
+	if err := pool.AddPrivate(public, 0); err != ErrAlreadyKnown {
+		t.Fatalf("public transaction made private: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	if txs := pool.local()[account]; len(txs) != 1 || txs[0].Hash() != public.Hash() {
+		t.Fatalf("journaled transactions mismatch: have %v, want only the public one", txs)
+	}
+	// Advance the chain and ensure the private transaction is dropped at its max block
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(4), GasLimit: 10000000})
+	if pool.pending[account].Len() != 2 {
+		t.Fatalf("pending transaction mismatch: have %d, want %d", pool.pending[account].Len(), 2)
+	}
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(5), GasLimit: 10000000})
+	if pool.Has(private.Hash()) || pool.IsPrivate(private.Hash()) {
+		t.Fatalf("expired private transaction not dropped")
+	}
+	if pool.pending[account] != nil || pool.queue[account].Len() != 1 {
+		t.Fatalf("dependent transaction not postponed")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the transactions received from a peer, or signed by a remote sender,
+// are rejected beyond the configured admission rates, locals being exempt.
+func TestTransactionRateLimiting(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.PeerRate = 1
+	config.SenderRate = 1
+	config.AccountSlots = 16
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	// A peer may only send a burst worth of transactions, known ones not counted
+	txs := make([]*types.Transaction, txRateBurst+2)
+	for i := range txs {
+		key, _ := crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+		txs[i] = transaction(0, 100000, key)
+	}
+	if err := pool.AddRemotesFrom("peer", txs[:1])[0]; err != nil {
+		t.Fatalf("failed to add first peer transaction: %v", err)
+	}
+	for i, err := range pool.AddRemotesFrom("peer", txs) {
+		var want error
+		switch {
+		case i == 0:
+			want = ErrAlreadyKnown
+		case i >= txRateBurst:
+			want = ErrPeerRateLimited
+		}
+		if err != want {
+			t.Errorf("peer transaction %d: error mismatch: have %v, want %v", i, err, want)
+		}
+	}
+	if err := pool.AddRemotesFrom("other", txs[txRateBurst:])[0]; err != nil {
+		t.Errorf("other peer rate limited: %v", err)
+	}
+	// A remote sender may only send a burst worth of transactions, unless local
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	for i := 0; i < txRateBurst; i++ {
+		if err := pool.AddRemote(transaction(uint64(i), 100000, key)); err != nil {
+			t.Fatalf("sender transaction %d: failed to add: %v", i, err)
+		}
+	}
+	if err := pool.AddRemote(transaction(txRateBurst, 100000, key)); err != ErrSenderRateLimited {
+		t.Fatalf("sender transaction %d: error mismatch: have %v, want %v", txRateBurst, err, ErrSenderRateLimited)
+	}
+	if err := pool.AddLocal(transaction(txRateBurst, 100000, key)); err != nil {
+		t.Fatalf("local transaction rate limited: %v", err)
+	}
+}
+
+// reorgBlockChain is a testBlockChain serving a set of blocks by hash, to reorg
+// the pool between them.
+type reorgBlockChain struct {
+	*testBlockChain
+	blocks map[common.Hash]*types.Block
+}
+
+func (bc *reorgBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.blocks[hash]
+}
+
+// Tests that the transactions reinjected by a reorg are not held to the admission
+// rate of their sender, only the newly arriving ones are.
+func TestTransactionRateLimitingReorg(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &reorgBlockChain{
+		testBlockChain: &testBlockChain{statedb, 1000000, new(event.Feed)},
+		blocks:         make(map[common.Hash]*types.Block),
+	}
+	config := testTxPoolConfig
+	config.SenderRate = 1
+	config.AccountSlots = 2 * txRateBurst
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	// Exhaust the admission rate of a remote sender
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	for i := 0; i < txRateBurst; i++ {
+		if err := pool.AddRemote(transaction(uint64(i), 100000, key)); err != nil {
+			t.Fatalf("sender transaction %d: failed to add: %v", i, err)
+		}
+	}
+	// Reorg away a block carrying more transactions of the same sender
+	reorged := types.Transactions{transaction(txRateBurst, 100000, key), transaction(txRateBurst+1, 100000, key)}
+
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0), GasLimit: 1000000}, nil, nil, nil)
+	oldHead := types.NewBlock(&types.Header{Number: big.NewInt(1), ParentHash: genesis.Hash(), GasLimit: 1000000}, reorged, nil, nil)
+	newHead := types.NewBlock(&types.Header{Number: big.NewInt(1), ParentHash: genesis.Hash(), GasLimit: 1000001}, nil, nil, nil)
+	for _, block := range []*types.Block{genesis, oldHead, newHead} {
+		blockchain.blocks[block.Hash()] = block
+	}
+	<-pool.requestReset(oldHead.Header(), newHead.Header())
+
+	for i, tx := range reorged {
+		if pool.Get(tx.Hash()) == nil {
+			t.Errorf("reorged transaction %d: not reinjected", i)
+		}
+	}
+	if err := pool.AddRemote(transaction(txRateBurst+2, 100000, key)); err != ErrSenderRateLimited {
+		t.Errorf("new sender transaction: error mismatch: have %v, want %v", err, ErrSenderRateLimited)
+	}
+}
+
+// Tests that the replaced, dropped and mined transactions of the remote senders
+// are counted in their reputation, and that the senders with a bad one are the
+// first to lose their transactions when the pool overflows.
+func TestTransactionReputation(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+	config.GlobalSlots = 8
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	bad, _ := crypto.GenerateKey()
+	good, _ := crypto.GenerateKey()
+	badAddr, goodAddr := crypto.PubkeyToAddress(bad.PublicKey), crypto.PubkeyToAddress(good.PublicKey)
+	pool.currentState.AddBalance(badAddr, big.NewInt(1000000000))
+	pool.currentState.AddBalance(goodAddr, big.NewInt(1000000000))
+
+	// Replace the first transaction of a sender twice, ruining its reputation
+	for price := int64(1); price <= 3; price++ {
+		if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(price), bad)); err != nil {
+			t.Fatalf("failed to add transaction priced %d: %v", price, err)
+		}
+	}
+	if score := pool.reputation.score(badAddr); score != -2 {
+		t.Fatalf("bad sender score mismatch: have %d, want %d", score, -2)
+	}
+	// Overflow the pending limit, the bad sender alone should pay for it
+	var txs []*types.Transaction
+	for i := uint64(1); i < 5; i++ {
+		txs = append(txs, pricedTransaction(i, 100000, big.NewInt(3), bad))
+	}
+	pool.AddRemotesSync(txs)
+
+	txs = txs[:0]
+	for i := uint64(0); i < 5; i++ {
+		txs = append(txs, pricedTransaction(i, 100000, big.NewInt(1), good))
+	}
+	pool.AddRemotesSync(txs)
+
+	if pending := pool.pending[badAddr].Len(); pending != 3 {
+		t.Errorf("bad sender pending mismatch: have %d, want %d", pending, 3)
+	}
+	if pending := pool.pending[goodAddr].Len(); pending != 5 {
+		t.Errorf("good sender pending mismatch: have %d, want %d", pending, 5)
+	}
+	// Mine a few transactions of the good sender and check the track records
+	pool.currentState.SetNonce(goodAddr, 2)
+	<-pool.requestReset(nil, nil)
+
+	reputation := pool.Reputation()
+	if want := (SenderReputation{Replaced: 2, Dropped: 2}); reputation[badAddr] != want {
+		t.Errorf("bad sender reputation mismatch: have %+v, want %+v", reputation[badAddr], want)
+	}
+	if want := (SenderReputation{Mined: 2}); reputation[goodAddr] != want {
+		t.Errorf("good sender reputation mismatch: have %+v, want %+v", reputation[goodAddr], want)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the remote transactions are snapshotted along with their origins,
+// up to the snapshot limit, and that they are reloaded on restart. The limit is
+// filled by whole accounts, pending transactions first.
+func TestTransactionSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the snapshot
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary snapshot: %v", err)
+	}
+	snapshot := file.Name()
+	defer os.Remove(snapshot)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(snapshot)
+
+	// Create the original pool to inject transactions into the snapshot
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Snapshot = snapshot
+	config.SnapshotLimit = 3
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000000))
+
+	// Add a local transaction, two pending remote ones and two queued ones, and two
+	// pending ones of another remote account
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	remotes := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), remote),
+		pricedTransaction(1, 100000, big.NewInt(1), remote),
+		pricedTransaction(3, 100000, big.NewInt(1), remote),
+		pricedTransaction(4, 100000, big.NewInt(1), remote),
+		pricedTransaction(0, 100000, big.NewInt(1), other),
+		pricedTransaction(1, 100000, big.NewInt(1), other),
+	}
+	for i, err := range pool.AddRemotesFrom("peer", remotes) {
+		if err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", i, err)
+		}
+	}
+	<-pool.requestPromoteExecutables(newAccountSet(pool.signer))
+
+	pending, queued := pool.Stats()
+	if pending != 5 || queued != 2 {
+		t.Fatalf("transaction count mismatch: have %d/%d, want %d/%d", pending, queued, 5, 2)
+	}
+	pool.Stop()
+
+	// Restart the pool, only the pending transactions of the lowest remote account
+	// should be reloaded, no other account fitting in the limit whole
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued = pool.Stats()
+	if pending != 2 || queued != 0 {
+		t.Fatalf("reloaded transaction count mismatch: have %d/%d, want %d/%d", pending, queued, 2, 0)
+	}
+	stored := remotes[:2]
+	if bytes.Compare(crypto.PubkeyToAddress(other.PublicKey).Bytes(), crypto.PubkeyToAddress(remote.PublicKey).Bytes()) < 0 {
+		stored = remotes[4:]
+	}
+	for _, tx := range stored {
+		if !pool.Has(tx.Hash()) {
+			t.Fatalf("transaction %x not reloaded", tx.Hash())
+		}
+		if origin := pool.origins[tx.Hash()]; origin.Peer != "peer" || origin.Time == 0 {
+			t.Errorf("transaction %x origin mismatch: have %+v, want peer %q", tx.Hash(), origin, "peer")
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that private transactions held as remote ones, with locals disabled, are
+// not snapshotted and don't come back as public transactions after a restart.
+func TestTransactionSnapshotPrivate(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the snapshot
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary snapshot: %v", err)
+	}
+	snapshot := file.Name()
+	defer os.Remove(snapshot)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(snapshot)
+
+	// Create the original pool with a private and a public remote transaction
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.NoLocals = true
+	config.Snapshot = snapshot
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	private := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.AddPrivate(private, 0); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	public := pricedTransaction(1, 100000, big.NewInt(1), key)
+	if err := pool.AddRemote(public); err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	pool.Stop()
+
+	// Restart the pool, only the public transaction should be reloaded
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pool.Has(private.Hash()) {
+		t.Errorf("private transaction reloaded from the snapshot")
+	}
+	if !pool.Has(public.Hash()) {
+		t.Errorf("public transaction not reloaded from the snapshot")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the state transitions of the transactions are posted as they move
+// through the pool, and that the latest state of each is remembered.
+func TestTransactionLifecycle(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(account, big.NewInt(1000000000))
+
+	changes := make(chan TxStateChangeEvent, 32)
+	sub := pool.SubscribeTxStateChangeEvent(changes)
+	defer sub.Unsubscribe()
+
+	// Queue a transaction, fill the nonce gap, replace the filler and mine its nonce
+	var (
+		tx0  = pricedTransaction(0, 100000, big.NewInt(1), key)
+		tx0b = pricedTransaction(0, 100000, big.NewInt(2), key)
+		tx1  = pricedTransaction(1, 100000, big.NewInt(1), key)
+	)
+	for i, tx := range []*types.Transaction{tx1, tx0, tx0b} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	pool.currentState.SetNonce(account, 1)
+	<-pool.requestReset(nil, nil)
+
+	want := []TxStateChange{
+		{Hash: tx1.Hash(), State: TxStateQueued},
+		{Hash: tx0.Hash(), State: TxStateQueued},
+		{Hash: tx0.Hash(), State: TxStatePending},
+		{Hash: tx1.Hash(), State: TxStatePending},
+		{Hash: tx0.Hash(), State: TxStateReplaced},
+		{Hash: tx0b.Hash(), State: TxStatePending},
+		{Hash: tx0b.Hash(), State: TxStateDropped, Reason: TxDropNonceTooLow},
+	}
+	var have []*TxStateChange
+	for len(have) < len(want) {
+		select {
+		case ev := <-changes:
+			have = append(have, ev.Changes...)
+		case <-time.After(time.Second):
+			t.Fatalf("state change count mismatch: have %d, want %d", len(have), len(want))
+		}
+	}
+	for i := range want {
+		if have[i].Hash != want[i].Hash || have[i].State != want[i].State || have[i].Reason != want[i].Reason {
+			t.Errorf("state change %d mismatch: have %x %s (%s), want %x %s (%s)", i, have[i].Hash, have[i].State, have[i].Reason, want[i].Hash, want[i].State, want[i].Reason)
+		}
+	}
+	// Check the latest state of every transaction
+	if state := pool.TxState(tx0.Hash(), false); state.State != TxStateReplaced || state.ReplacedBy == nil || *state.ReplacedBy != tx0b.Hash() {
+		t.Errorf("replaced transaction state mismatch: have %+v", state)
+	}
+	if state := pool.TxState(tx0b.Hash(), false); state.State != TxStateDropped || state.Reason != TxDropNonceTooLow {
+		t.Errorf("dropped transaction state mismatch: have %+v", state)
+	}
+	if state := pool.TxState(tx1.Hash(), false); state.State != TxStatePending {
+		t.Errorf("pending transaction state mismatch: have %+v", state)
+	}
+	if state := pool.TxState(common.Hash{}, false); state != nil {
+		t.Errorf("unknown transaction state mismatch: have %+v, want nil", state)
+	}
+}
+
+// Tests that the state transitions of private transactions are neither posted
+// nor reported unless asked for, even after they left the pool.
+func TestTransactionLifecyclePrivate(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	changes := make(chan TxStateChangeEvent, 32)
+	sub := pool.SubscribeTxStateChangeEvent(changes)
+	defer sub.Unsubscribe()
+
+	private := transaction(0, 100000, key)
+	if err := pool.AddPrivate(private, 5); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if state := pool.TxState(private.Hash(), false); state != nil {
+		t.Errorf("pooled private transaction reported: %+v", state)
+	}
+	if state := pool.TxState(private.Hash(), true); state == nil || state.State != TxStatePending {
+		t.Errorf("pooled private transaction state mismatch: have %+v", state)
+	}
+	// Expire the private transaction and ensure it is still hidden
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(5), GasLimit: 10000000})
+	if pool.Has(private.Hash()) {
+		t.Fatalf("expired private transaction not dropped")
+	}
+	if state := pool.TxState(private.Hash(), false); state != nil {
+		t.Errorf("expired private transaction reported: %+v", state)
+	}
+	if state := pool.TxState(private.Hash(), true); state == nil || state.State != TxStateDropped || state.Reason != TxDropPrivateExpiry {
+		t.Errorf("expired private transaction state mismatch: have %+v", state)
+	}
+	select {
+	case ev := <-changes:
+		t.Errorf("private transaction state changes posted: %v", ev.Changes)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
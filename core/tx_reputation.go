@@ -0,0 +1,187 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// txRateBurst is the number of seconds worth of transactions a peer or sender
+	// may send at once after being idle.
+	txRateBurst = 10
+
+	// reputationLimit is the number of remote senders the pool keeps the track
+	// record of.
+	reputationLimit = 16384
+)
+
+// txRateLimiter is a token bucket rate limiter for every peer or sender seen.
+// A nil limiter allows everything.
+type txRateLimiter struct {
+	rate    float64 // Tokens credited per second
+	burst   float64 // Maximum number of tokens a bucket holds
+	buckets map[string]*txBucket
+	lock    sync.Mutex
+}
+
+// txBucket is the token bucket of a single peer or sender.
+type txBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newTxRateLimiter creates a limiter allowing rate transactions per second for
+// every key, or nil if rate is 0.
+func newTxRateLimiter(rate uint64) *txRateLimiter {
+	if rate == 0 {
+		return nil
+	}
+	return &txRateLimiter{
+		rate:    float64(rate),
+		burst:   float64(rate * txRateBurst),
+		buckets: make(map[string]*txBucket),
+	}
+}
+
+// allow reports whether a transaction may be admitted for the given key, taking
+// a token from its bucket if so.
+func (l *txRateLimiter) allow(key string) bool {
+	if l == nil {
+		return true
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &txBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// prune forgets the buckets refilled since last used, which are no different
+// from new ones.
+func (l *txRateLimiter) prune() {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// SenderReputation is the track record of a remote sender in the pool: how many
+// of its transactions were mined, and how many never made it into a block as
+// they were replaced, dropped to make room for others, or expired in the queue.
+type SenderReputation struct {
+	Mined    uint64 `json:"mined"`
+	Replaced uint64 `json:"replaced"`
+	Dropped  uint64 `json:"dropped"`
+	Expired  uint64 `json:"expired"`
+}
+
+// Score rates the sender, every mined transaction counting for one point and
+// every replaced, dropped or expired one against. Senders with a negative score
+// lose their transactions first when the pool overflows.
+func (r *SenderReputation) Score() int64 {
+	return int64(r.Mined) - int64(r.Replaced) - int64(r.Dropped) - int64(r.Expired)
+}
+
+// reputationEvent is an outcome of a transaction counted in the reputation of
+// its sender.
+type reputationEvent int
+
+const (
+	txMined reputationEvent = iota
+	txReplaced
+	txDropped
+	txExpired
+)
+
+// txReputation tracks the reputation of the most recently active remote senders.
+type txReputation struct {
+	senders *lru.Cache // SenderReputation of the senders by address
+}
+
+func newTxReputation() *txReputation {
+	senders, _ := lru.New(reputationLimit)
+	return &txReputation{senders: senders}
+}
+
+// record counts the given outcome of count transactions of a sender.
+func (r *txReputation) record(addr common.Address, event reputationEvent, count int) {
+	if count == 0 {
+		return
+	}
+	var rep *SenderReputation
+	if cached, ok := r.senders.Get(addr); ok {
+		rep = cached.(*SenderReputation)
+	} else {
+		rep = new(SenderReputation)
+		r.senders.Add(addr, rep)
+	}
+	switch event {
+	case txMined:
+		rep.Mined += uint64(count)
+	case txReplaced:
+		rep.Replaced += uint64(count)
+	case txDropped:
+		rep.Dropped += uint64(count)
+	case txExpired:
+		rep.Expired += uint64(count)
+	}
+}
+
+// score returns the score of a sender, 0 if it has no track record.
+func (r *txReputation) score(addr common.Address) int64 {
+	if cached, ok := r.senders.Peek(addr); ok {
+		return cached.(*SenderReputation).Score()
+	}
+	return 0
+}
+
+// dump returns a copy of the reputation of all the tracked senders.
+func (r *txReputation) dump() map[common.Address]SenderReputation {
+	dump := make(map[common.Address]SenderReputation)
+	for _, key := range r.senders.Keys() {
+		if cached, ok := r.senders.Peek(key); ok {
+			dump[key.(common.Address)] = *cached.(*SenderReputation)
+		}
+	}
+	return dump
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// txOrigin is the arrival time and origin peer of a remote transaction.
+type txOrigin struct {
+	Time uint64 // Unix time the transaction arrived at
+	Peer string // Id of the peer the transaction was received from, empty if unknown
+}
+
+// txSnapshotEntry is a remote transaction stored in the snapshot.
+type txSnapshotEntry struct {
+	Tx     *types.Transaction
+	Origin txOrigin
+}
+
+// txSnapshot is a dump of the remote transactions of the pool, with the aim of
+// refilling the pool quickly after a node restart instead of waiting for the
+// peers to resend them. Unlike the journal it is regenerated as a whole, never
+// appended to.
+type txSnapshot struct {
+	path  string // Filesystem path to store the transactions at
+	limit int    // Maximum number of transactions to store
+}
+
+// newTxSnapshot creates a new transaction snapshot stored at path, holding at
+// most limit transactions.
+func newTxSnapshot(path string, limit int) *txSnapshot {
+	return &txSnapshot{
+		path:  path,
+		limit: limit,
+	}
+}
+
+// load parses a transaction snapshot from disk, loading the transactions which
+// arrived within the given lifetime into the specified pool.
+func (snapshot *txSnapshot) load(lifetime time.Duration, add func([]*txSnapshotEntry) []error) error {
+	// Skip the parsing if the snapshot file doesn't exist at all
+	if _, err := os.Stat(snapshot.path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(snapshot.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var (
+		stream  = rlp.NewStream(input, 0)
+		expiry  = uint64(time.Now().Add(-lifetime).Unix())
+		total   int
+		expired int
+		dropped int
+		failure error
+		batch   []*txSnapshotEntry
+	)
+	loadBatch := func(entries []*txSnapshotEntry) {
+		for _, err := range add(entries) {
+			if err != nil {
+				log.Trace("Failed to add snapshotted transaction", "err", err)
+				dropped++
+			}
+		}
+	}
+	for total < snapshot.limit {
+		// Parse the next transaction and terminate on error
+		entry := new(txSnapshotEntry)
+		if err = stream.Decode(entry); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		total++
+
+		if entry.Origin.Time < expiry {
+			expired++
+			continue
+		}
+		if batch = append(batch, entry); len(batch) > 1024 {
+			loadBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		loadBatch(batch)
+	}
+	log.Info("Loaded transaction pool snapshot", "transactions", total, "expired", expired, "dropped", dropped)
+
+	return failure
+}
+
+// write regenerates the snapshot with the given transactions, in order. It is up
+// to the caller to keep them within the snapshot limit.
+func (snapshot *txSnapshot) write(entries []*txSnapshotEntry) error {
+	replacement, err := os.OpenFile(snapshot.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err = rlp.Encode(replacement, entry); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	replacement.Close()
+
+	// Replace the live snapshot with the newly generated one
+	if err = os.Rename(snapshot.path+".new", snapshot.path); err != nil {
+		return err
+	}
+	log.Info("Regenerated transaction pool snapshot", "transactions", len(entries))
+	return nil
+}
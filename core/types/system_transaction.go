@@ -0,0 +1,33 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+// Reasons for which a consensus engine injects a system transaction into a block.
+const (
+	SystemTxDeposit      = "deposit"      // Block fees deposited to the validator set contract
+	SystemTxSystemReward = "systemReward" // Share of the block fees sent to the system reward contract
+	SystemTxSlash        = "slash"        // Slashing of the in-turn validator that missed its block
+	SystemTxInit         = "init"         // Initialization of a system contract at the first block
+	SystemTxUnknown      = "unknown"      // Call to a system contract method that can't be decoded
+)
+
+// SystemTxInfo describes why the consensus engine injected a system transaction
+// into a block, along with the decoded arguments of the system contract call.
+type SystemTxInfo struct {
+	Reason string                 `json:"reason"`
+	Args   map[string]interface{} `json:"args,omitempty"`
+}
@@ -17,28 +17,99 @@ const (
 	merkleProofValidateResultLength      uint64 = 32
 )
 
+// Stages of the light client contracts at which an input can be rejected.
+const (
+	LightClientStageInput          = "input"                // Framing of the input
+	LightClientStageConsensusState = "consensusState"       // Decoding of the trusted consensus state
+	LightClientStageHeader         = "header"               // Decoding of the header
+	LightClientStageApplyHeader    = "applyHeader"          // Verification of the header against the consensus state
+	LightClientStageEncode         = "encodeConsensusState" // Encoding of the new consensus state
+	LightClientStageProof          = "proof"                // Decoding of the key/value merkle proof
+	LightClientStageVerifyProof    = "verifyProof"          // Verification of the key/value merkle proof
+)
+
+// LightClientError is the failure of a light client contract, recording the
+// stage of the validation the input was rejected at.
+type LightClientError struct {
+	Stage string
+	Err   error
+}
+
+// Error implements the error interface, reporting the underlying failure only.
+func (e *LightClientError) Error() string {
+	return e.Err.Error()
+}
+
+// TendermintHeaderValidate runs the input through the tendermint header
+// validation contract of the light client gas fork without charging any gas,
+// for verifying headers the same way outside of the EVM. Failures are reported
+// as *LightClientError.
+func TendermintHeaderValidate(input []byte) ([]byte, error) {
+	return runTmHeaderValidate(input, true)
+}
+
+// IAVLMerkleProofValidate runs the input through the IAVL merkle proof validation
+// contract of the light client gas fork without charging any gas, for verifying
+// proofs the same way outside of the EVM. Failures are reported as
+// *LightClientError.
+func IAVLMerkleProofValidate(input []byte) ([]byte, error) {
+	return runIavlMerkleProofValidate(input, true)
+}
+
+// input:
+// | payload length | payload    |
+// | 32 bytes       |            |
+func decodePrecompileInput(input []byte) ([]byte, error) {
+	if uint64(len(input)) <= precompileContractInputMetaDataLength {
+		return nil, fmt.Errorf("invalid input: input should include %d bytes payload length and payload", precompileContractInputMetaDataLength)
+	}
+
+	payloadLength := binary.BigEndian.Uint64(input[precompileContractInputMetaDataLength-uint64TypeLength : precompileContractInputMetaDataLength])
+	if payloadLength != uint64(len(input))-precompileContractInputMetaDataLength {
+		return nil, fmt.Errorf("invalid input: input size should be %d, actual the size is %d", payloadLength+precompileContractInputMetaDataLength, len(input))
+	}
+	return input[precompileContractInputMetaDataLength:], nil
+}
+
 // input:
 // consensus state length | consensus state | tendermint header |
 // 32 bytes               |                 |                   |
-func decodeTendermintHeaderValidationInput(input []byte) (*lightclient.ConsensusState, *lightclient.Header, error) {
+func splitTendermintHeaderValidationInput(input []byte) ([]byte, []byte, error) {
+	if uint64(len(input)) <= consensusStateLengthBytesLength {
+		return nil, nil, fmt.Errorf("expected payload size larger than %d, actual size: %d", consensusStateLengthBytesLength, len(input))
+	}
 	csLen := binary.BigEndian.Uint64(input[consensusStateLengthBytesLength-uint64TypeLength : consensusStateLengthBytesLength])
-	if uint64(len(input)) <= consensusStateLengthBytesLength+csLen {
+	if csLen >= uint64(len(input))-consensusStateLengthBytesLength {
 		return nil, nil, fmt.Errorf("expected payload size %d, actual size: %d", consensusStateLengthBytesLength+csLen, len(input))
 	}
+	return input[consensusStateLengthBytesLength : consensusStateLengthBytesLength+csLen], input[consensusStateLengthBytesLength+csLen:], nil
+}
 
-	cs, err := lightclient.DecodeConsensusState(input[consensusStateLengthBytesLength : consensusStateLengthBytesLength+csLen])
+// decodeTendermintHeaderValidationInput decodes the consensus state and the
+// header, strictly as of the light client gas fork, in the lenient way of the
+// earlier blocks otherwise.
+func decodeTendermintHeaderValidationInput(input []byte, strict bool) (*lightclient.ConsensusState, *lightclient.Header, error) {
+	csBytes, headerBytes, err := splitTendermintHeaderValidationInput(input)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, &LightClientError{Stage: LightClientStageInput, Err: err}
 	}
-	header, err := lightclient.DecodeHeader(input[consensusStateLengthBytesLength+csLen:])
+	decodeConsensusState, decodeHeader := lightclient.DecodeConsensusState, lightclient.DecodeHeader
+	if strict {
+		decodeConsensusState, decodeHeader = lightclient.DecodeConsensusStateStrict, lightclient.DecodeHeaderStrict
+	}
+	cs, err := decodeConsensusState(csBytes)
+	if err != nil {
+		return nil, nil, &LightClientError{Stage: LightClientStageConsensusState, Err: err}
+	}
+	header, err := decodeHeader(headerBytes)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, &LightClientError{Stage: LightClientStageHeader, Err: err}
 	}
 
 	return &cs, header, nil
 }
 
-// tmHeaderValidate implemented as a native contract.
+// tmHeaderValidate implemented as a native contract, charging a flat price.
 type tmHeaderValidate struct{}
 
 func (c *tmHeaderValidate) RequiredGas(input []byte) uint64 {
@@ -46,34 +117,69 @@ func (c *tmHeaderValidate) RequiredGas(input []byte) uint64 {
 }
 
 func (c *tmHeaderValidate) Run(input []byte) (result []byte, err error) {
+	// Blocks before the light client gas fork turned any panic into a failure,
+	// keep doing so for them to be replayed the same way.
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("internal error: %v\n", r)
 		}
 	}()
+	return runTmHeaderValidate(input, false)
+}
 
-	if uint64(len(input)) <= precompileContractInputMetaDataLength {
-		return nil, fmt.Errorf("invalid input")
+// tmHeaderValidateScaled implemented as a native contract, charging by the size
+// of the trusted validator set and of the input.
+type tmHeaderValidateScaled struct{}
+
+func (c *tmHeaderValidateScaled) RequiredGas(input []byte) uint64 {
+	gas := params.TendermintHeaderValidateGas + toWordSize(uint64(len(input)))*params.TendermintHeaderValidateWordGas
+
+	// If the input is malformed, charge the base price and let the actual call fail
+	payload, err := decodePrecompileInput(input)
+	if err != nil {
+		return gas
+	}
+	csBytes, _, err := splitTendermintHeaderValidationInput(payload)
+	if err != nil {
+		return gas
 	}
+	cs, err := lightclient.DecodeConsensusStateStrict(csBytes)
+	if err != nil {
+		return gas
+	}
+	return gas + uint64(len(cs.NextValidatorSet.Validators))*params.TendermintHeaderValidateValidatorGas
+}
 
-	payloadLength := binary.BigEndian.Uint64(input[precompileContractInputMetaDataLength-uint64TypeLength : precompileContractInputMetaDataLength])
-	if uint64(len(input)) != payloadLength+precompileContractInputMetaDataLength {
-		return nil, fmt.Errorf("invalid input: input size should be %d, actual the size is %d", payloadLength+precompileContractInputMetaDataLength, len(input))
+func (c *tmHeaderValidateScaled) Run(input []byte) (result []byte, err error) {
+	// Decoding is strict, but a panic deep in the light client must still fail
+	// the call rather than take the node down.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return runTmHeaderValidate(input, true)
+}
+
+func runTmHeaderValidate(input []byte, strict bool) ([]byte, error) {
+	payload, err := decodePrecompileInput(input)
+	if err != nil {
+		return nil, &LightClientError{Stage: LightClientStageInput, Err: err}
 	}
 
-	cs, header, err := decodeTendermintHeaderValidationInput(input[precompileContractInputMetaDataLength:])
+	cs, header, err := decodeTendermintHeaderValidationInput(payload, strict)
 	if err != nil {
 		return nil, err
 	}
 
 	validatorSetChanged, err := cs.ApplyHeader(header)
 	if err != nil {
-		return nil, err
+		return nil, &LightClientError{Stage: LightClientStageApplyHeader, Err: err}
 	}
 
 	consensusStateBytes, err := cs.EncodeConsensusState()
 	if err != nil {
-		return nil, err
+		return nil, &LightClientError{Stage: LightClientStageEncode, Err: err}
 	}
 
 	// result
@@ -86,50 +192,83 @@ func (c *tmHeaderValidate) Run(input []byte) (result []byte, err error) {
 	consensusStateBytesLength := uint64(len(consensusStateBytes))
 	binary.BigEndian.PutUint64(lengthBytes[tmHeaderValidateResultMetaDataLength-uint64TypeLength:], consensusStateBytesLength)
 
-	result = append(lengthBytes, consensusStateBytes...)
-
-	return result, nil
+	return append(lengthBytes, consensusStateBytes...), nil
 }
 
 //------------------------------------------------------------------------------------------------------------------------------------------------
 
-// tmHeaderValidate implemented as a native contract.
+// iavlMerkleProofValidate implemented as a native contract, charging a flat price.
 type iavlMerkleProofValidate struct{}
 
 func (c *iavlMerkleProofValidate) RequiredGas(input []byte) uint64 {
 	return params.IAVLMerkleProofValidateGas
 }
 
-// input:
-// | payload length | payload    |
-// | 32 bytes       |            |
 func (c *iavlMerkleProofValidate) Run(input []byte) (result []byte, err error) {
+	// Blocks before the light client gas fork turned any panic into a failure,
+	// keep doing so for them to be replayed the same way.
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("internal error: %v\n", r)
 		}
 	}()
+	return runIavlMerkleProofValidate(input, false)
+}
 
-	if uint64(len(input)) <= precompileContractInputMetaDataLength {
-		return nil, fmt.Errorf("invalid input: input should include %d bytes payload length and payload", precompileContractInputMetaDataLength)
+// iavlMerkleProofValidateScaled implemented as a native contract, charging by
+// the number of proof operations and the size of the input.
+type iavlMerkleProofValidateScaled struct{}
+
+func (c *iavlMerkleProofValidateScaled) RequiredGas(input []byte) uint64 {
+	gas := params.IAVLMerkleProofValidateGas + toWordSize(uint64(len(input)))*params.IAVLMerkleProofValidateWordGas
+
+	// If the input is malformed, charge the base price and let the actual call fail
+	payload, err := decodePrecompileInput(input)
+	if err != nil {
+		return gas
 	}
+	kvmp, err := lightclient.DecodeKeyValueMerkleProofStrict(payload)
+	if err != nil {
+		return gas
+	}
+	return gas + uint64(len(kvmp.Proof.Ops))*params.IAVLMerkleProofValidateOpGas
+}
 
-	payloadLength := binary.BigEndian.Uint64(input[precompileContractInputMetaDataLength-uint64TypeLength : precompileContractInputMetaDataLength])
-	if uint64(len(input)) != payloadLength+precompileContractInputMetaDataLength {
-		return nil, fmt.Errorf("invalid input: input size should be %d, actual the size is %d", payloadLength+precompileContractInputMetaDataLength, len(input))
+func (c *iavlMerkleProofValidateScaled) Run(input []byte) (result []byte, err error) {
+	// Decoding is strict, but a panic deep in the light client must still fail
+	// the call rather than take the node down.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return runIavlMerkleProofValidate(input, true)
+}
+
+// input:
+// | payload length | payload    |
+// | 32 bytes       |            |
+func runIavlMerkleProofValidate(input []byte, strict bool) ([]byte, error) {
+	payload, err := decodePrecompileInput(input)
+	if err != nil {
+		return nil, &LightClientError{Stage: LightClientStageInput, Err: err}
 	}
 
-	kvmp, err := lightclient.DecodeKeyValueMerkleProof(input[precompileContractInputMetaDataLength:])
+	decodeKeyValueMerkleProof := lightclient.DecodeKeyValueMerkleProof
+	if strict {
+		decodeKeyValueMerkleProof = lightclient.DecodeKeyValueMerkleProofStrict
+	}
+	kvmp, err := decodeKeyValueMerkleProof(payload)
 	if err != nil {
-		return nil, err
+		return nil, &LightClientError{Stage: LightClientStageProof, Err: err}
 	}
 
 	valid := kvmp.Validate()
 	if !valid {
-		return nil, fmt.Errorf("invalid merkle proof")
+		return nil, &LightClientError{Stage: LightClientStageVerifyProof, Err: fmt.Errorf("invalid merkle proof")}
 	}
 
-	result = make([]byte, merkleProofValidateResultLength)
+	result := make([]byte, merkleProofValidateResultLength)
 	binary.BigEndian.PutUint64(result[merkleProofValidateResultLength-uint64TypeLength:], 0x01)
 	return result, nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/core/vm/lightclient"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,6 +48,11 @@ func TestTmHeaderValidateAndMerkleProofValidate(t *testing.T) {
 	var tmHeaderValidateContract tmHeaderValidate
 	syncedConsensusStateBytes, err := tmHeaderValidateContract.Run(input)
 	require.NoError(t, err)
+
+	// The scaled pricing charges for every trusted validator
+	scaledGas := (&tmHeaderValidateScaled{}).RequiredGas(input)
+	require.Equal(t, params.TendermintHeaderValidateGas+toWordSize(uint64(len(input)))*params.TendermintHeaderValidateWordGas+
+		uint64(len(cs.NextValidatorSet.Validators))*params.TendermintHeaderValidateValidatorGas, scaledGas)
 	syncedConsensusState, err := lightclient.DecodeConsensusState(syncedConsensusStateBytes[32:])
 	require.NoError(t, err)
 	require.Equal(t, testHeight+1, syncedConsensusState.Height)
@@ -102,4 +108,52 @@ func TestTmHeaderValidateAndMerkleProofValidate(t *testing.T) {
 	expectedResult := make([]byte, 32)
 	binary.BigEndian.PutUint64(expectedResult[24:], 0x01)
 	require.Equal(t, expectedResult, success)
+
+	// The scaled pricing charges for both the IAVL and the multistore proof operations
+	scaledGas = (&iavlMerkleProofValidateScaled{}).RequiredGas(input)
+	require.Equal(t, params.IAVLMerkleProofValidateGas+toWordSize(uint64(len(input)))*params.IAVLMerkleProofValidateWordGas+
+		2*params.IAVLMerkleProofValidateOpGas, scaledGas)
+}
+
+// Tests that malformed inputs are rejected by the light client contracts with
+// an error, without relying on recovering from a panic.
+func TestLightClientMalformedInput(t *testing.T) {
+	// wrap prefixes the payload with its 32 bytes length
+	wrap := func(payload []byte) []byte {
+		input := make([]byte, 32+len(payload))
+		binary.BigEndian.PutUint64(input[24:32], uint64(len(payload)))
+		copy(input[32:], payload)
+		return input
+	}
+	// overflowing returns a 32 bytes length field holding the maximum length
+	overflowing := func() []byte {
+		field := make([]byte, 32)
+		binary.BigEndian.PutUint64(field[24:], ^uint64(0))
+		return field
+	}
+	inputs := map[string][]byte{
+		"empty":             nil,
+		"length only":       make([]byte, 32),
+		"length mismatch":   append(overflowing(), 0x01),
+		"short payload":     wrap([]byte{0x01}),
+		"consensus state":   wrap(append(overflowing(), make([]byte, 64)...)),
+		"merkle proof key":  wrap(append(append(make([]byte, 32), overflowing()...), make([]byte, 128)...)),
+		"merkle proof data": wrap(make([]byte, 200)),
+	}
+	contracts := map[string]PrecompiledContract{
+		"tmHeaderValidate":        &tmHeaderValidateScaled{},
+		"iavlMerkleProofValidate": &iavlMerkleProofValidateScaled{},
+	}
+	for name, contract := range contracts {
+		for desc, input := range inputs {
+			if gas := contract.RequiredGas(input); gas == 0 {
+				t.Errorf("%s, %s: no gas charged", name, desc)
+			}
+			if _, err := contract.Run(input); err == nil {
+				t.Errorf("%s, %s: malformed input accepted", name, desc)
+			} else if _, ok := err.(*LightClientError); !ok {
+				t.Errorf("%s, %s: failure without validation stage: %v", name, desc, err)
+			}
+		}
+	}
 }
@@ -0,0 +1,138 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// precompileRegistration is a precompiled contract registered at an address,
+// active as of a fork of the chain configuration.
+type precompileRegistration struct {
+	fork     string // Name of the activating fork, empty if active from genesis
+	contract PrecompiledContract
+}
+
+var (
+	precompileRegistry     atomic.Value // map[common.Address][]precompileRegistration, copied on write
+	precompileRegistryLock sync.Mutex   // Serialises the registrations
+)
+
+func init() {
+	// The BSC light client contracts are registered on top of the Ethereum ones
+	RegisterPrecompile(common.BytesToAddress([]byte{100}), params.IstanbulFork, &tmHeaderValidate{})
+	RegisterPrecompile(common.BytesToAddress([]byte{101}), params.IstanbulFork, &iavlMerkleProofValidate{})
+
+	RegisterPrecompile(common.BytesToAddress([]byte{100}), params.LightClientGasFork, &tmHeaderValidateScaled{})
+	RegisterPrecompile(common.BytesToAddress([]byte{101}), params.LightClientGasFork, &iavlMerkleProofValidateScaled{})
+}
+
+// RegisterPrecompile registers a precompiled contract at addr, active as of the
+// named Ethereum or BSC fork of the chain configuration, or from genesis if the
+// fork is empty. Forks for custom contracts on private networks can be added to
+// params.BSCForkTable and scheduled through the bscForks chain config.
+//
+// The registrations at an address are consulted latest first, the first active
+// one superseding the built-in contract at the address, if any. Contracts must
+// be registered before any EVM using them is created, ideally from an init
+// function, as the registrations change the outcome of executing blocks.
+func RegisterPrecompile(addr common.Address, fork string, p PrecompiledContract) {
+	if fork != "" && !params.IsKnownFork(fork) {
+		panic(fmt.Sprintf("precompile %x registered at unknown fork %q", addr, fork))
+	}
+	precompileRegistryLock.Lock()
+	defer precompileRegistryLock.Unlock()
+
+	current, _ := precompileRegistry.Load().(map[common.Address][]precompileRegistration)
+	registry := make(map[common.Address][]precompileRegistration, len(current)+1)
+	for a, regs := range current {
+		registry[a] = regs
+	}
+	regs := make([]precompileRegistration, len(registry[addr]), len(registry[addr])+1)
+	copy(regs, registry[addr])
+	registry[addr] = append(regs, precompileRegistration{fork: fork, contract: p})
+
+	precompileRegistry.Store(registry)
+}
+
+// registeredPrecompile returns the latest registered precompiled contract at
+// addr that is active at the given block.
+func registeredPrecompile(config *params.ChainConfig, num *big.Int, addr common.Address) (PrecompiledContract, bool) {
+	registry, _ := precompileRegistry.Load().(map[common.Address][]precompileRegistration)
+	regs := registry[addr]
+	for i := len(regs) - 1; i >= 0; i-- {
+		if regs[i].fork == "" || config.IsFork(regs[i].fork, num) {
+			return regs[i].contract, true
+		}
+	}
+	return nil, false
+}
+
+// builtinPrecompiles returns the set of built-in Ethereum precompiled contracts
+// active under the given rules.
+func builtinPrecompiles(rules params.Rules) map[common.Address]PrecompiledContract {
+	switch {
+	case rules.IsIstanbul:
+		return PrecompiledContractsIstanbul
+	case rules.IsByzantium:
+		return PrecompiledContractsByzantium
+	default:
+		return PrecompiledContractsHomestead
+	}
+}
+
+// ActivePrecompiles returns all the precompiled contracts active at the given
+// block, both built-in and registered.
+func ActivePrecompiles(config *params.ChainConfig, num *big.Int) map[common.Address]PrecompiledContract {
+	active := make(map[common.Address]PrecompiledContract)
+	for addr, p := range builtinPrecompiles(config.Rules(num)) {
+		active[addr] = p
+	}
+	registry, _ := precompileRegistry.Load().(map[common.Address][]precompileRegistration)
+	for addr := range registry {
+		if p, ok := registeredPrecompile(config, num, addr); ok {
+			active[addr] = p
+		}
+	}
+	return active
+}
+
+// IsPrecompileAddress reports whether a precompiled contract is built in or
+// registered at addr as of any fork, for callers not knowing the block yet.
+func IsPrecompileAddress(addr common.Address) bool {
+	if _, ok := PrecompiledContractsIstanbul[addr]; ok {
+		return true
+	}
+	registry, _ := precompileRegistry.Load().(map[common.Address][]precompileRegistration)
+	return len(registry[addr]) > 0
+}
+
+// Precompile returns the precompiled contract active at addr in the block the
+// EVM executes, if any.
+func (evm *EVM) Precompile(addr common.Address) (PrecompiledContract, bool) {
+	if p, ok := registeredPrecompile(evm.chainConfig, evm.BlockNumber, addr); ok {
+		return p, true
+	}
+	p, ok := builtinPrecompiles(evm.chainRules)[addr]
+	return p, ok
+}
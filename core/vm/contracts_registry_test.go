@@ -0,0 +1,106 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// restorePrecompilesOnCleanup drops the precompiled contracts registered by a
+// test once it finishes, the registry being shared by the whole package.
+func restorePrecompilesOnCleanup(t *testing.T) {
+	precompileRegistryLock.Lock()
+	saved := precompileRegistry.Load()
+	precompileRegistryLock.Unlock()
+
+	t.Cleanup(func() {
+		precompileRegistryLock.Lock()
+		defer precompileRegistryLock.Unlock()
+
+		precompileRegistry.Store(saved)
+	})
+}
+
+// Tests that registered precompiled contracts are active as of their fork only,
+// superseding the earlier registrations and the built-in contracts.
+func TestRegisterPrecompile(t *testing.T) {
+	config := *params.AllEthashProtocolChanges
+	config.BSCForks = map[string]*big.Int{params.LightClientGasFork: big.NewInt(10)}
+
+	custom := common.HexToAddress("0x0000000000000000000000000000000000c0ffee")
+	restorePrecompilesOnCleanup(t)
+	RegisterPrecompile(custom, params.LightClientGasFork, &dataCopy{})
+
+	var (
+		sha256Addr      = common.BytesToAddress([]byte{2})
+		tmHeaderAddr    = common.BytesToAddress([]byte{100})
+		iavlProofAddr   = common.BytesToAddress([]byte{101})
+		beforeFork      = NewEVM(Context{BlockNumber: big.NewInt(9)}, nil, &config, Config{})
+		afterFork       = NewEVM(Context{BlockNumber: big.NewInt(10)}, nil, &config, Config{})
+		activeAtGenesis = ActivePrecompiles(&config, new(big.Int))
+	)
+	if _, ok := beforeFork.Precompile(custom); ok {
+		t.Errorf("custom precompile active before its fork")
+	}
+	if p, ok := afterFork.Precompile(custom); !ok {
+		t.Errorf("custom precompile inactive after its fork")
+	} else if _, ok := p.(*dataCopy); !ok {
+		t.Errorf("custom precompile mismatch: have %T", p)
+	}
+	if p, _ := afterFork.Precompile(sha256Addr); p != PrecompiledContractsIstanbul[sha256Addr] {
+		t.Errorf("built-in precompile mismatch: have %T", p)
+	}
+	if p, _ := beforeFork.Precompile(tmHeaderAddr); p == nil {
+		t.Errorf("legacy light client precompile missing")
+	} else if _, ok := p.(*tmHeaderValidate); !ok {
+		t.Errorf("legacy light client precompile mismatch: have %T", p)
+	}
+	if p, _ := afterFork.Precompile(iavlProofAddr); p == nil {
+		t.Errorf("scaled light client precompile missing")
+	} else if _, ok := p.(*iavlMerkleProofValidateScaled); !ok {
+		t.Errorf("scaled light client precompile mismatch: have %T", p)
+	}
+	if _, ok := activeAtGenesis[custom]; ok {
+		t.Errorf("custom precompile listed before its fork")
+	}
+	if have, want := len(activeAtGenesis), len(PrecompiledContractsIstanbul)+2; have != want {
+		t.Errorf("active precompile count mismatch: have %d, want %d", have, want)
+	}
+}
+
+// Tests that the precompiled contracts registered by a test don't outlive it.
+func TestRegisterPrecompileCleanup(t *testing.T) {
+	custom := common.HexToAddress("0x0000000000000000000000000000000000c0ffee")
+
+	t.Run("register", func(t *testing.T) {
+		restorePrecompilesOnCleanup(t)
+		RegisterPrecompile(custom, "", &dataCopy{})
+		if !IsPrecompileAddress(custom) {
+			t.Fatalf("custom precompile not registered")
+		}
+	})
+	if IsPrecompileAddress(custom) {
+		t.Errorf("custom precompile registered past the test")
+	}
+	if !IsPrecompileAddress(common.BytesToAddress([]byte{1})) || !IsPrecompileAddress(common.BytesToAddress([]byte{101})) {
+		t.Errorf("built-in or light client precompile not recognised")
+	}
+}
@@ -0,0 +1,83 @@
+package lightclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+type validatorJSON struct {
+	PubKey      hexutil.Bytes `json:"pubKey"`
+	VotingPower uint64        `json:"votingPower"`
+}
+
+type consensusStateJSON struct {
+	ChainID             string          `json:"chainId"`
+	Height              uint64          `json:"height"`
+	AppHash             hexutil.Bytes   `json:"appHash"`
+	CurValidatorSetHash hexutil.Bytes   `json:"curValidatorSetHash"`
+	NextValidatorSet    []validatorJSON `json:"nextValidatorSet"`
+}
+
+// MarshalJSON encodes the consensus state with the validators given by their
+// ed25519 public keys, the same way they are laid out in the binary encoding.
+func (cs ConsensusState) MarshalJSON() ([]byte, error) {
+	enc := consensusStateJSON{
+		ChainID:             cs.ChainID,
+		Height:              cs.Height,
+		AppHash:             cs.AppHash,
+		CurValidatorSetHash: cs.CurValidatorSetHash,
+	}
+	if cs.NextValidatorSet != nil {
+		for _, validator := range cs.NextValidatorSet.Validators {
+			pubkey, ok := validator.PubKey.(ed25519.PubKeyEd25519)
+			if !ok {
+				return nil, fmt.Errorf("invalid pubkey type")
+			}
+			enc.NextValidatorSet = append(enc.NextValidatorSet, validatorJSON{PubKey: pubkey[:], VotingPower: uint64(validator.VotingPower)})
+		}
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON decodes a consensus state encoded by MarshalJSON, applying the
+// same checks as DecodeConsensusStateStrict.
+func (cs *ConsensusState) UnmarshalJSON(input []byte) error {
+	var dec consensusStateJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if uint64(len(dec.ChainID)) > chainIDLength {
+		return fmt.Errorf("chainID length should be no more than %d", chainIDLength)
+	}
+	if uint64(len(dec.AppHash)) != appHashLength {
+		return fmt.Errorf("appHash length should be %d, actual length: %d", appHashLength, len(dec.AppHash))
+	}
+	if uint64(len(dec.CurValidatorSetHash)) != validatorSetHashLength {
+		return fmt.Errorf("curValidatorSetHash length should be %d, actual length: %d", validatorSetHashLength, len(dec.CurValidatorSetHash))
+	}
+	var validatorSet []*tmtypes.Validator
+	for index, validator := range dec.NextValidatorSet {
+		if uint64(len(validator.PubKey)) != validatorPubkeyLength {
+			return fmt.Errorf("validator %d pubkey length should be %d, actual length: %d", index, validatorPubkeyLength, len(validator.PubKey))
+		}
+		var pubkey ed25519.PubKeyEd25519
+		copy(pubkey[:], validator.PubKey)
+		validatorSet = append(validatorSet, tmtypes.NewValidator(pubkey, int64(validator.VotingPower)))
+	}
+	nextValidatorSet := &tmtypes.ValidatorSet{Validators: validatorSet}
+	if err := checkValidatorSet(nextValidatorSet); err != nil {
+		return fmt.Errorf("invalid consensus state: %v", err)
+	}
+	*cs = ConsensusState{
+		ChainID:             dec.ChainID,
+		Height:              dec.Height,
+		AppHash:             dec.AppHash,
+		CurValidatorSetHash: dec.CurValidatorSetHash,
+		NextValidatorSet:    nextValidatorSet,
+	}
+	return nil
+}
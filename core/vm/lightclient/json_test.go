@@ -0,0 +1,63 @@
+package lightclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+// testConsensusState is the binary encoding of a consensus state of the Binance
+// Chain testnet, trusting eleven validators.
+var testConsensusState, _ = hex.DecodeString("42696e616e63652d436861696e2d4e696c6500000000000000000000000000000000000003fc05e2b7029751d2a6581efc2f79712ec44d8b49818503" +
+	"25a7feadaa58ef4ddaa18a9380d9ab0fc10d18ca0e0832d5f4c063c5489ec1443dfb738252d038a82131b27ae17cbe9c20cdcfdf876b3b12978d3264" +
+	"a007fcaaa71c4cdb701d9ebc0323f44f000000174876e800184e7b103d34c41003f9b864d5f8c1adda9bd0436b253bb3c844bc739c1e77c900000017" +
+	"4876e8004d420aea843e92a0cfe69d89696dff6827769f9cb52a249af537ce89bf2a4b74000000174876e800bd03de9f8ab29e2800094e153fac6f69" +
+	"6cfa512536c9c2f804dcb2c2c4e4aed6000000174876e8008f4a74a07351895ddf373057b98fae6dfaf2cd21f37a063e19601078fe470d5300000017" +
+	"4876e8004a5d4753eb79f92e80efe22df7aca4f666a4f44bf81c536c4a09d4b9c5b654b5000000174876e800c80e9abef7ff439c10c68fe8f1303ded" +
+	"dfc527718c3b37d8ba6807446e3c827a000000174876e8009142afcc691b7cc05d26c7b0be0c8b46418294171730e079f384fde2fa50bafc00000017" +
+	"4876e80049b288e4ebbb3a281c2d546fc30253d5baf08993b6e5d295fb787a5b314a298e000000174876e80004224339688f012e649de48e24188009" +
+	"2eaa8f6aa0f4f14bfcf9e0c76917c0b6000000174876e8004034b37ceda8a0bf13b1abaeee7a8f9383542099a554d219b93d0ce69e3970e800000017" +
+	"4876e800")
+
+// Tests that consensus states survive a round-trip through their JSON encoding,
+// and that the JSON decoding applies the checks of the binary one.
+func TestConsensusStateJSON(t *testing.T) {
+	cs, err := DecodeConsensusState(testConsensusState)
+	if err != nil {
+		t.Fatalf("failed to decode consensus state: %v", err)
+	}
+	blob, err := json.Marshal(cs)
+	if err != nil {
+		t.Fatalf("failed to encode consensus state to JSON: %v", err)
+	}
+	var dec consensusStateJSON
+	if err := json.Unmarshal(blob, &dec); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	if dec.ChainID != "Binance-Chain-Nile" || dec.Height != cs.Height || len(dec.NextValidatorSet) != 11 {
+		t.Errorf("JSON fields mismatch: have %s", blob)
+	}
+	var decoded ConsensusState
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatalf("failed to decode consensus state from JSON: %v", err)
+	}
+	encoded, err := decoded.EncodeConsensusState()
+	if err != nil {
+		t.Fatalf("failed to encode consensus state: %v", err)
+	}
+	if !bytes.Equal(encoded, testConsensusState) {
+		t.Errorf("consensus state mismatch after JSON round-trip:\nhave %x\nwant %x", encoded, testConsensusState)
+	}
+	// Malformed fields are rejected like in the binary encoding
+	dec.AppHash = dec.AppHash[1:]
+	blob, _ = json.Marshal(dec)
+	if err := json.Unmarshal(blob, &decoded); err == nil {
+		t.Errorf("consensus state with a short app hash accepted")
+	}
+	dec.AppHash, dec.NextValidatorSet = cs.AppHash, nil
+	blob, _ = json.Marshal(dec)
+	if err := json.Unmarshal(blob, &decoded); err == nil {
+		t.Errorf("consensus state without validators accepted")
+	}
+}
@@ -75,6 +75,9 @@ func MultiStoreProofOpDecoder(pop merkle.ProofOp) (merkle.ProofOperator, error)
 	if err != nil {
 		return nil, cmn.ErrorWrap(err, "decoding ProofOp.Data into MultiStoreProofOp")
 	}
+	if op.Proof == nil {
+		return nil, cmn.NewError("missing proof in MultiStoreProofOp")
+	}
 
 	return NewMultiStoreProofOp(pop.Key, op.Proof), nil
 }
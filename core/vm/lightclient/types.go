@@ -3,6 +3,7 @@ package lightclient
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/tendermint/tendermint/crypto/ed25519"
@@ -22,8 +23,37 @@ const (
 	keyLengthBytesLength       uint64 = 32
 	valueLengthBytesLength     uint64 = 32
 	maxConsensusStateLength    uint64 = 32 * (128 - 1) // maximum validator quantity 99
+
+	// maxValidatorSetSize is the number of validators fitting a consensus state
+	maxValidatorSetSize = (maxConsensusStateLength - chainIDLength - heightLength - appHashLength - validatorSetHashLength) / (validatorPubkeyLength + validatorVotingPowerLength)
 )
 
+// checkValidatorSet ensures a validator set can be hashed and have commits
+// verified against it, rather than relying on the panics of the Tendermint
+// types to reject a malformed one.
+func checkValidatorSet(vals *tmtypes.ValidatorSet) error {
+	if vals == nil || len(vals.Validators) == 0 {
+		return errors.New("empty validator set")
+	}
+	if uint64(len(vals.Validators)) > maxValidatorSetSize {
+		return fmt.Errorf("too many validators %d, should not exceed %d", len(vals.Validators), maxValidatorSetSize)
+	}
+	var total int64
+	for i, val := range vals.Validators {
+		if val == nil {
+			return fmt.Errorf("validator %d missing", i)
+		}
+		if _, ok := val.PubKey.(ed25519.PubKeyEd25519); !ok {
+			return fmt.Errorf("validator %d has invalid pubkey type", i)
+		}
+		if val.VotingPower <= 0 || val.VotingPower > tmtypes.MaxTotalVotingPower-total {
+			return fmt.Errorf("validator %d voting power %d out of range", i, val.VotingPower)
+		}
+		total += val.VotingPower
+	}
+	return nil
+}
+
 type ConsensusState struct {
 	ChainID             string
 	Height              uint64
@@ -84,6 +114,23 @@ func DecodeConsensusState(input []byte) (ConsensusState, error) {
 	return consensusState, nil
 }
 
+// DecodeConsensusStateStrict decodes a consensus state like DecodeConsensusState,
+// additionally rejecting the ones which can't be encoded again or have headers
+// verified against them.
+func DecodeConsensusStateStrict(input []byte) (ConsensusState, error) {
+	if uint64(len(input)) > maxConsensusStateLength {
+		return ConsensusState{}, fmt.Errorf("expected input size no more than %d, actual input size: %d", maxConsensusStateLength, len(input))
+	}
+	consensusState, err := DecodeConsensusState(input)
+	if err != nil {
+		return ConsensusState{}, err
+	}
+	if err := checkValidatorSet(consensusState.NextValidatorSet); err != nil {
+		return ConsensusState{}, fmt.Errorf("invalid consensus state: %v", err)
+	}
+	return consensusState, nil
+}
+
 // output:
 // | chainID   | height   | appHash  | curValidatorSetHash | [{validator pubkey, voting power}] |
 // | 32 bytes  | 8 bytes  | 32 bytes | 32 bytes            | [{32 bytes, 8 bytes}]              |
@@ -209,6 +256,53 @@ func DecodeHeader(input []byte) (*Header, error) {
 	return &header, nil
 }
 
+// DecodeHeaderStrict decodes an amino encoded header, rejecting headers missing
+// any of the parts the validation needs.
+func DecodeHeaderStrict(input []byte) (*Header, error) {
+	header, err := DecodeHeader(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := header.checkParts(); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// DecodeHeaderJSON decodes an amino JSON encoded header, applying the same
+// checks as DecodeHeaderStrict.
+func DecodeHeaderJSON(input []byte) (*Header, error) {
+	var header Header
+	err := Cdc.UnmarshalJSON(input, &header)
+	if err != nil {
+		return nil, err
+	}
+	if err := header.checkParts(); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// checkParts ensures none of the parts the validation needs is missing.
+func (h *Header) checkParts() error {
+	if h.SignedHeader.Header == nil {
+		return errors.New("invalid header: header is nil")
+	}
+	if h.SignedHeader.Commit == nil {
+		return errors.New("invalid header: commit is nil")
+	}
+	if uint64(len(h.SignedHeader.Commit.Precommits)) > maxValidatorSetSize {
+		return fmt.Errorf("invalid header: too many precommits %d", len(h.SignedHeader.Commit.Precommits))
+	}
+	if err := checkValidatorSet(h.ValidatorSet); err != nil {
+		return fmt.Errorf("invalid header: %v", err)
+	}
+	if err := checkValidatorSet(h.NextValidatorSet); err != nil {
+		return fmt.Errorf("invalid header: next %v", err)
+	}
+	return nil
+}
+
 type KeyValueMerkleProof struct {
 	Key       []byte
 	Value     []byte
@@ -237,6 +331,17 @@ func (kvmp *KeyValueMerkleProof) Validate() bool {
 // | storeName | key length | key | value length | value | appHash  | proof |
 // | 32 bytes  | 32 bytes   |     | 32 bytes     |       | 32 bytes |       |
 func DecodeKeyValueMerkleProof(input []byte) (*KeyValueMerkleProof, error) {
+	return decodeKeyValueMerkleProof(input, false)
+}
+
+// DecodeKeyValueMerkleProofStrict decodes a key/value merkle proof like
+// DecodeKeyValueMerkleProof, checking the lengths in a way that can't overflow
+// instead of relying on the slicing to panic.
+func DecodeKeyValueMerkleProofStrict(input []byte) (*KeyValueMerkleProof, error) {
+	return decodeKeyValueMerkleProof(input, true)
+}
+
+func decodeKeyValueMerkleProof(input []byte, strict bool) (*KeyValueMerkleProof, error) {
 	inputLength := uint64(len(input))
 	pos := uint64(0)
 
@@ -249,7 +354,12 @@ func DecodeKeyValueMerkleProof(input []byte) (*KeyValueMerkleProof, error) {
 	keyLength := binary.BigEndian.Uint64(input[pos+keyLengthBytesLength-8 : pos+keyLengthBytesLength])
 	pos += keyLengthBytesLength
 
-	if inputLength <= storeNameLengthBytesLength+keyLengthBytesLength+keyLength+valueLengthBytesLength {
+	if strict {
+		// Check the lengths against the remaining input, so that they can't overflow
+		if keyLength >= inputLength-pos-valueLengthBytesLength {
+			return nil, fmt.Errorf("invalid input, keyLength %d is too long", keyLength)
+		}
+	} else if inputLength <= storeNameLengthBytesLength+keyLengthBytesLength+keyLength+valueLengthBytesLength {
 		return nil, fmt.Errorf("invalid input, keyLength %d is too long", keyLength)
 	}
 	key := input[pos : pos+keyLength]
@@ -258,7 +368,11 @@ func DecodeKeyValueMerkleProof(input []byte) (*KeyValueMerkleProof, error) {
 	valueLength := binary.BigEndian.Uint64(input[pos+valueLengthBytesLength-8 : pos+valueLengthBytesLength])
 	pos += valueLengthBytesLength
 
-	if inputLength <= storeNameLengthBytesLength+keyLengthBytesLength+keyLength+valueLengthBytesLength+valueLength+appHashLength {
+	if strict {
+		if remaining := inputLength - pos; remaining <= appHashLength || valueLength >= remaining-appHashLength {
+			return nil, fmt.Errorf("invalid input, valueLength %d is too long", valueLength)
+		}
+	} else if inputLength <= storeNameLengthBytesLength+keyLengthBytesLength+keyLength+valueLengthBytesLength+valueLength+appHashLength {
 		return nil, fmt.Errorf("invalid input, valueLength %d is too long", valueLength)
 	}
 	value := input[pos : pos+valueLength]
@@ -284,3 +398,38 @@ func DecodeKeyValueMerkleProof(input []byte) (*KeyValueMerkleProof, error) {
 
 	return keyValueMerkleProof, nil
 }
+
+// output:
+// | storeName | key length | key | value length | value | appHash  | proof |
+// | 32 bytes  | 32 bytes   |     | 32 bytes     |       | 32 bytes |       |
+func EncodeKeyValueMerkleProof(storeName string, key, value, appHash, proof []byte) ([]byte, error) {
+	if uint64(len(storeName)) > storeNameLengthBytesLength {
+		return nil, fmt.Errorf("storeName length should be no more than %d", storeNameLengthBytesLength)
+	}
+	if uint64(len(appHash)) != appHashLength {
+		return nil, fmt.Errorf("appHash length should be %d, actual length: %d", appHashLength, len(appHash))
+	}
+	keyLength, valueLength := uint64(len(key)), uint64(len(value))
+	serializeLength := storeNameLengthBytesLength + keyLengthBytesLength + keyLength + valueLengthBytesLength + valueLength + appHashLength
+	encodingBytes := make([]byte, serializeLength, serializeLength+uint64(len(proof)))
+
+	pos := uint64(0)
+	copy(encodingBytes[pos:pos+storeNameLengthBytesLength], storeName)
+	pos += storeNameLengthBytesLength
+
+	binary.BigEndian.PutUint64(encodingBytes[pos+keyLengthBytesLength-8:pos+keyLengthBytesLength], keyLength)
+	pos += keyLengthBytesLength
+
+	copy(encodingBytes[pos:pos+keyLength], key)
+	pos += keyLength
+
+	binary.BigEndian.PutUint64(encodingBytes[pos+valueLengthBytesLength-8:pos+valueLengthBytesLength], valueLength)
+	pos += valueLengthBytesLength
+
+	copy(encodingBytes[pos:pos+valueLength], value)
+	pos += valueLength
+
+	copy(encodingBytes[pos:pos+appHashLength], appHash)
+
+	return append(encodingBytes, proof...), nil
+}
@@ -0,0 +1,118 @@
+package lightclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Proof of an account of the Binance Chain testnet, against the app hash of the
+// block 71961349.
+var (
+	testProofKey, _   = hex.DecodeString("6163636f756e743a8a4e2eb018bdf98a8f53ec755740ffc728637a1d")
+	testProofValue, _ = hex.DecodeString("4bdc4c270a750a148a4e2eb018bdf98a8f53ec755740ffc728637a1d12110a0941544348412d3733301080f69bf321120b0a03424e4210e8baeb8d44" +
+		"120f0a075050432d303041108094ebdc031a26eb5ae98721031c199c92e5b0080967da99be27cf2da53317441b4a663e6d9c6caf02be1fdbdc20d796" +
+		"2b2815")
+	testProofAppHash, _ = hex.DecodeString("2c69c314b4de5c8035253c8bc0771d9ca17b1b23a57c0c6d068b57579791cae2")
+	testProof, _        = hex.DecodeString("0add070a066961766c3a76121c6163636f756e743a8a4e2eb018bdf98a8f53ec755740ffc728637a1d1ab407b2070aaf070a2d081810cdfd2b188096" +
+		"a82222209f223f804e2d94ac51c4321b0687397012e6d95eb9783b03bc790da631004c7c0a2d081710adb31a18f395a8222a20d2a38865de82383ccc" +
+		"e0140513b65cec1bf2ae6cd7dfeb22eb6faadb4e26b26f0a2d081510b2990b18f395a82222208a02bbd5a695dfc772627ac8744aa9cf30ae26575bdc" +
+		"e8c96a9a0d0999175b430a2d081410e6ff0418f395a8222a20d39619c779be909e67f23499fb74eb2c19afd7f21523401d4ccf7e917db5cd600a2d08" +
+		"1210e3fe0118f395a8222a20a10cc73843f889d9e03a463eb135e928bb980e19734344cba0fbf4e8a4c5258b0a2c081010dd6518f395a8222a2007fd" +
+		"15843a2fd3f58d021b0e072a6c70742d7a3d993a922445e3491e1c14ee8e0a2c080f10cc2a18eda6a7222a20088942d7b30abd021d8e9505cc41313f" +
+		"ad87c8c10a799f3b51018b7b2cfe4ad90a2c080d10b70d18eda6a7222a2091a37bc44d0c61e3752ddc59eb390355ab65e8a9fb453be4f0acec537f1c" +
+		"a14f0a2c080c10890818eda6a72222201cfc317855a06667c45812fe36efe33af05671dfe0d9b56b02662011af2e79e30a2c080b10ac0318c4b0ee21" +
+		"2220aeb454a4b3243b6269a2fd8841dca9a951c53b30f1e27da91063dae7224402c70a2c080910e40118c4b0ee212a20441340a4de6498f861b97b3f" +
+		"3ad9603af055e5af51a0d96fff2ae28e3c5c6c9a0a2c0808108d0118c4b0ee212220ae32ea4b9ab7b53571da320e2815fd8b2c278124961cca4a1849" +
+		"a799842424450a2b0807104d18c4b0ee212220e2804c9b7f045ec0b4ab20920a937b82fda8b7a9ddd12b21637335b915cfda550a2b0806102418a5f4" +
+		"c7192a20ec85f22addedfc82c771af5b4c77544b7c1d7c5bbac33f2712dfba1045ebdbd00a2b0805101118a5f4c7192a2071ade34dcc447a0ba8adc6" +
+		"03080633d15c06f3525830c86ebce35eca0a4921fc0a2b0804100c18a5f4c7192a205190bce93993e65b266a3417ed511df8897a812cb4b62569e5af" +
+		"cfbec10b69cd0a2b0803100618a5f4c7192220b76c6884f1d412ac10bfb3987fb7d26f0330b2a85539509ebc5c6bdec2f95d520a2b0802100418a5f4" +
+		"c71922206a285b4a4f9d1c687bbafa1f3649b6a6e32b1a85dd0402421210683e846cf0020a2b0801100218a5f4c7192220033b3f7c6dcb258b6e5554" +
+		"5e7a4f51539447cd595eb8a2e373ba0015502da1051a450a1c6163636f756e743a8a4e2eb018bdf98a8f53ec755740ffc728637a1d12201a272295e9" +
+		"4cf1d8090bdb019dde48e9dab026ad2c3e43aaa7e61cc954a9245d18a5f4c7190ab6040a0a6d756c746973746f726512036163631aa204a0040a9d04" +
+		"0a300a0364657812290a27088496a822122038fc49f49648fec62acc434151a51eaa378c1b20a730a749548e36f1529422500a300a03676f7612290a" +
+		"27088496a8221220a78ce489bdf08b9ee869c184876e1623dc38b3e64a5cf1a0005f97976c64deac0a380a0b61746f6d69635f7377617012290a2708" +
+		"8496a8221220544c2fa38f61e10a39ec00b3e724d5834761268bb455cdbf5843bcf1531f8fbc0a300a0376616c12290a27088496a82212201f71082c" +
+		"9f6f45fb456b2c00b41e50d2f662f2dfec3cb6965f19d214bf02f3980a0f0a046d61696e12070a05088496a8220a320a057374616b6512290a270884" +
+		"96a82212200dd467343c718f240e50b4feac42970fc8c1c69a018be955f9c27913ac1f8b3c0a300a0361636312290a27088496a8221220270c19ccc9" +
+		"c40c5176b3dfbd8af734c97a307e0dbd8df9e286dcd5d709f973ed0a330a06746f6b656e7312290a27088496a8221220c4f96eedf50c83964de9df01" +
+		"3afec2e545012d92528b643a5166c828774187b60a320a05706169727312290a27088496a8221220351c55cfda84596ecd22ebc77013662aba97f81f" +
+		"19d9ef3d150213bb07c823060a360a0974696d655f6c6f636b12290a27088496a8221220e7adf5bd30ce022decf0e9341bf05c464ed70cdbc97423bd" +
+		"2bab8f3571e5179b0a330a06706172616d7312290a27088496a822122042a9dfc356ca435db131eb41fb1975c8482f2434537918665e530b0b4633b5" +
+		"f9")
+)
+
+// Tests that key/value merkle proofs survive a round-trip through their encoding
+// and still verify.
+func TestKeyValueMerkleProofEncoding(t *testing.T) {
+	input, err := EncodeKeyValueMerkleProof("acc", testProofKey, testProofValue, testProofAppHash, testProof)
+	if err != nil {
+		t.Fatalf("failed to encode proof: %v", err)
+	}
+	// | storeName | key length | key | value length | value | appHash  | proof |
+	// | 32 bytes  | 32 bytes   |     | 32 bytes     |       | 32 bytes |       |
+	if want := 32 + 32 + len(testProofKey) + 32 + len(testProofValue) + 32 + len(testProof); len(input) != want {
+		t.Fatalf("encoded length mismatch: have %d, want %d", len(input), want)
+	}
+	if !bytes.Equal(input[:32], append([]byte("acc"), make([]byte, 29)...)) {
+		t.Errorf("store name mismatch: have %x", input[:32])
+	}
+	if length := binary.BigEndian.Uint64(input[56:64]); length != uint64(len(testProofKey)) {
+		t.Errorf("key length mismatch: have %d, want %d", length, len(testProofKey))
+	}
+	proof, err := DecodeKeyValueMerkleProof(input)
+	if err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	if proof.StoreName != "acc" || !bytes.Equal(proof.Key, testProofKey) || !bytes.Equal(proof.Value, testProofValue) || !bytes.Equal(proof.AppHash, testProofAppHash) {
+		t.Errorf("proof fields mismatch: have %+v", proof)
+	}
+	if len(proof.Proof.Ops) != 2 || proof.Proof.Ops[0].Type != "iavl:v" || proof.Proof.Ops[1].Type != "multistore" {
+		t.Errorf("proof operations mismatch: have %+v", proof.Proof.Ops)
+	}
+	if !proof.Validate() {
+		t.Errorf("decoded proof failed to verify")
+	}
+}
+
+// Tests that key/value merkle proofs with fields too long for their encoding are
+// rejected.
+func TestKeyValueMerkleProofEncodingInvalid(t *testing.T) {
+	if _, err := EncodeKeyValueMerkleProof(strings.Repeat("a", 33), testProofKey, testProofValue, testProofAppHash, testProof); err == nil {
+		t.Errorf("long store name accepted")
+	}
+	if _, err := EncodeKeyValueMerkleProof("acc", testProofKey, testProofValue, testProofAppHash[1:], testProof); err == nil {
+		t.Errorf("short app hash accepted")
+	}
+}
+
+// Tests that the strict consensus state decoding rejects the states the lenient
+// one of the blocks before the light client gas fork accepts.
+func TestDecodeConsensusStateStrict(t *testing.T) {
+	if _, err := DecodeConsensusStateStrict(testConsensusState); err != nil {
+		t.Fatalf("failed to decode consensus state: %v", err)
+	}
+	// A validator without voting power
+	powerless := append([]byte{}, testConsensusState...)
+	binary.BigEndian.PutUint64(powerless[len(powerless)-8:], 0)
+
+	// More validators than a consensus state can hold
+	oversized := append([]byte{}, testConsensusState...)
+	for i := 0; i < 100; i++ {
+		validator := make([]byte, 40)
+		validator[0] = byte(i)
+		binary.BigEndian.PutUint64(validator[32:], 1)
+		oversized = append(oversized, validator...)
+	}
+	for name, input := range map[string][]byte{"powerless": powerless, "oversized": oversized} {
+		if _, err := DecodeConsensusState(input); err != nil {
+			t.Errorf("%s: lenient decoding failed: %v", name, err)
+		}
+		if _, err := DecodeConsensusStateStrict(input); err == nil {
+			t.Errorf("%s: strict decoding succeeded", name)
+		}
+	}
+}
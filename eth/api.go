@@ -154,6 +154,11 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return api.e.miner.HashRate()
 }
 
+// TxOrdering returns the policy ordering the transactions of the mined blocks.
+func (api *PrivateMinerAPI) TxOrdering() string {
+	return api.e.miner.TxOrdering()
+}
+
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
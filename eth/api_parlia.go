@@ -0,0 +1,75 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/parlia"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// PrivateParliaAPI is the collection of Parlia APIs that need access to the
+// chain state, exposed over the parlia namespace next to the engine's own.
+type PrivateParliaAPI struct {
+	eth *Ethereum
+}
+
+// NewPrivateParliaAPI creates a new API definition for the Parlia methods of the
+// Ethereum service.
+func NewPrivateParliaAPI(eth *Ethereum) *PrivateParliaAPI {
+	return &PrivateParliaAPI{eth: eth}
+}
+
+// SimulateFinalize reports the system transactions, balance changes and slashing
+// Parlia would apply if coinbase sealed the block following the given parent,
+// after the optional list of RLP encoded signed transactions. Nothing is written
+// to the chain state.
+func (api *PrivateParliaAPI) SimulateFinalize(ctx context.Context, parentNrOrHash rpc.BlockNumberOrHash, coinbase common.Address, rawTxs *[]hexutil.Bytes) (*parlia.FinalizeSimulation, error) {
+	engine, ok := api.eth.engine.(*parlia.Parlia)
+	if !ok {
+		return nil, errors.New("finalize simulation requires the parlia engine")
+	}
+	parent, err := api.eth.APIBackend.HeaderByNumberOrHash(ctx, parentNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, errors.New("parent block not found")
+	}
+	statedb, err := api.eth.blockchain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	var txs []*types.Transaction
+	if rawTxs != nil {
+		for i, raw := range *rawTxs {
+			tx := new(types.Transaction)
+			if err := rlp.DecodeBytes(raw, tx); err != nil {
+				return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+			}
+			txs = append(txs, tx)
+		}
+	}
+	return engine.SimulateFinalize(api.eth.blockchain, parent, coinbase, statedb, txs)
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ProfileConfig holds extra parameters to profiling functions.
+type ProfileConfig struct {
+	Reexec *uint64
+	Export bool // Whether to export the costs into the metrics system too
+}
+
+// BlockProfile is the execution cost of a range of blocks, by opcode, precompiled
+// contract, storage cache and contract.
+type BlockProfile struct {
+	From    uint64        `json:"from"`
+	To      uint64        `json:"to"`
+	GasUsed uint64        `json:"gasUsed"`
+	Time    time.Duration `json:"time"` // Nanoseconds spent processing the blocks
+	*tracers.Profile
+}
+
+// ProfileBlock re-executes the blocks from start up to end inclusive, or only
+// start if no end is given, and returns where the execution spent its time and
+// gas.
+func (api *PrivateDebugAPI) ProfileBlock(ctx context.Context, start rpc.BlockNumber, end *rpc.BlockNumber, config *ProfileConfig) (*BlockProfile, error) {
+	first, err := api.blockByNumber(start)
+	if err != nil {
+		return nil, err
+	}
+	last := first
+	if end != nil {
+		if last, err = api.blockByNumber(*end); err != nil {
+			return nil, err
+		}
+	}
+	if first.NumberU64() == 0 {
+		return nil, fmt.Errorf("genesis is not profileable")
+	}
+	if last.NumberU64() < first.NumberU64() {
+		return nil, fmt.Errorf("end block #%d needs to come after start block #%d", last.NumberU64(), first.NumberU64())
+	}
+	return api.profileBlocks(ctx, first, last, config)
+}
+
+// blockByNumber retrieves a canonical block, resolving the special numbers.
+func (api *PrivateDebugAPI) blockByNumber(number rpc.BlockNumber) (*types.Block, error) {
+	var block *types.Block
+	switch number {
+	case rpc.PendingBlockNumber:
+		block = api.eth.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		block = api.eth.blockchain.CurrentBlock()
+	default:
+		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return block, nil
+}
+
+// profileBlocks re-executes a range of blocks on top of the state of the parent
+// of the first one, aggregating the execution costs of all their transactions.
+func (api *PrivateDebugAPI) profileBlocks(ctx context.Context, first, last *types.Block, config *ProfileConfig) (*BlockProfile, error) {
+	parent := api.eth.blockchain.GetBlock(first.ParentHash(), first.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", first.ParentHash())
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, err := api.computeStateDB(parent, reexec)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		profiler = tracers.NewProfiler(config != nil && config.Export)
+		vmConfig = vm.Config{Debug: true, Tracer: profiler}
+		result   = &BlockProfile{From: first.NumberU64(), To: last.NumberU64()}
+		block    = first
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		start := time.Now()
+		_, _, usedGas, err := api.eth.blockchain.Processor().Process(block, statedb, vmConfig)
+		if err != nil {
+			return nil, fmt.Errorf("processing block %d failed: %v", block.NumberU64(), err)
+		}
+		result.Time += time.Since(start)
+		result.GasUsed += usedGas
+
+		if block.NumberU64() == last.NumberU64() {
+			break
+		}
+		// Finalize the state so any modifications are written to the trie
+		root, err := statedb.Commit(api.eth.blockchain.Config().IsEIP158(block.Number()))
+		if err != nil {
+			return nil, err
+		}
+		if err := statedb.Reset(root); err != nil {
+			return nil, fmt.Errorf("state reset after block %d failed: %v", block.NumberU64(), err)
+		}
+		next := block.NumberU64() + 1
+		if block = api.eth.blockchain.GetBlockByNumber(next); block == nil {
+			return nil, fmt.Errorf("block #%d not found", next)
+		}
+	}
+	result.Profile = profiler.Profile()
+	return result, nil
+}
@@ -35,11 +35,13 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -56,12 +58,25 @@ const (
 	defaultTraceReexec = uint64(128)
 )
 
+// Types of the synthetic frames traced for the state changes a PoSA engine
+// applies to a block outside of any transaction.
+const (
+	systemFrameUpgrade  = "systemContractUpgrade" // Code swap of system contracts ahead of the transactions
+	systemFrameTransfer = "systemTransfer"        // Native transfer of the collected fees to the coinbase
+)
+
+// stateDiffTracer is the name of the built-in tracer reporting the state changes
+// of transactions, assembled from the state journal instead of the EVM steps.
+const stateDiffTracer = "stateDiff"
+
 // TraceConfig holds extra parameters to trace functions.
 type TraceConfig struct {
 	*vm.LogConfig
 	Tracer  *string
 	Timeout *string
 	Reexec  *uint64
+
+	SystemFrames bool // Whether block traces include synthetic frames for state changes outside of transactions
 }
 
 // StdTraceConfig holds extra parameters to standard-json trace functions.
@@ -73,8 +88,27 @@ type StdTraceConfig struct {
 
 // txTraceResult is the result of a single transaction trace.
 type txTraceResult struct {
-	Result interface{} `json:"result,omitempty"` // Trace results produced by the tracer
-	Error  string      `json:"error,omitempty"`  // Trace failure produced by the tracer
+	Result interface{}     `json:"result,omitempty"` // Trace results produced by the tracer
+	Error  string          `json:"error,omitempty"`  // Trace failure produced by the tracer
+	System *systemFrame    `json:"system,omitempty"` // Synthetic frame of a state change outside of any transaction
+	Block  state.StateDiff `json:"block,omitempty"`  // State changes of the entire block, closing a state diff trace
+}
+
+// systemFrame is a synthetic trace of a state change the consensus engine applies
+// to a block outside of any transaction, without running the EVM.
+type systemFrame struct {
+	Type      string             `json:"type"`
+	From      *common.Address    `json:"from,omitempty"`
+	To        *common.Address    `json:"to,omitempty"`
+	Value     *hexutil.Big       `json:"value,omitempty"`
+	Contracts []*contractUpgrade `json:"contracts,omitempty"`
+}
+
+// contractUpgrade is the code swap of a single system contract.
+type contractUpgrade struct {
+	Address     common.Address `json:"address"`
+	CodeHash    common.Hash    `json:"codeHash"`    // Hash of the code before the upgrade
+	NewCodeHash common.Hash    `json:"newCodeHash"` // Hash of the code after the upgrade
 }
 
 // blockTraceTask represents a single block trace task when an entire chain is
@@ -205,8 +239,22 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 			for task := range tasks {
 				signer := types.MakeSigner(api.eth.blockchain.Config(), task.block.Number())
 
+				// Apply the state changes the consensus engine makes outside of the
+				// transactions, the way traceBlock does
+				upgrade := upgradeSystemContracts(api.eth.blockchain.Config(), task.block.Header(), task.statedb)
+				if upgrade != nil {
+					// Leave the upgrade out of the journal, it is not the first transaction's
+					task.statedb = task.statedb.Copy()
+				}
+				frames := make([]*systemFrame, len(task.results))
+
 				// Trace all the transactions contained within
 				for i, tx := range task.block.Transactions() {
+					if frames[i] = api.collectSystemFees(tx, task.block.Header(), task.statedb); frames[i] != nil {
+						// Leave the fees out of the journal, they are not the transaction's
+						task.statedb = task.statedb.Copy()
+					}
+
 					msg, _ := tx.AsMessage(signer)
 					vmctx := core.NewEVMContext(msg, task.block.Header(), api.eth.blockchain, nil)
 
@@ -220,6 +268,9 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 					task.statedb.Finalise(api.eth.blockchain.Config().IsEIP158(task.block.Number()))
 					task.results[i] = &txTraceResult{Result: res}
 				}
+				if config != nil && config.SystemFrames {
+					task.results = withSystemFrames(upgrade, frames, task.results)
+				}
 				// Stream the result back to the user or abort on teardown
 				select {
 				case results <- task:
@@ -442,7 +493,8 @@ func (api *PrivateDebugAPI) StandardTraceBadBlockToFile(ctx context.Context, has
 
 // traceBlock configures a new tracer according to the provided configuration, and
 // executes all the transactions contained within. The return value will be one item
-// per transaction, dependent on the requestd tracer.
+// per transaction, dependent on the requestd tracer. State diff traces are closed by
+// an item holding the state changes of the entire block.
 func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, error) {
 	// Create the parent state database
 	if err := api.eth.engine.VerifyHeader(api.eth.blockchain, block.Header(), true); err != nil {
@@ -460,12 +512,24 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 	if err != nil {
 		return nil, err
 	}
+	// State diffs of the whole block are taken against the untouched parent state
+	var (
+		stateDiff = config != nil && config.Tracer != nil && *config.Tracer == stateDiffTracer
+		base      *state.StateDB
+		touched   []state.StateDiff
+	)
+	if stateDiff {
+		base = statedb.Copy()
+	}
+	upgrade := upgradeSystemContracts(api.eth.blockchain.Config(), block.Header(), statedb)
+
 	// Execute all the transaction contained within the block concurrently
 	var (
 		signer = types.MakeSigner(api.eth.blockchain.Config(), block.Number())
 
 		txs     = block.Transactions()
 		results = make([]*txTraceResult, len(txs))
+		frames  = make([]*systemFrame, len(txs)) // Synthetic frames preceding the transactions
 
 		pend = new(sync.WaitGroup)
 		jobs = make(chan *txTraceTask, len(txs))
@@ -497,20 +561,12 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 	var failed error
 	for i, tx := range txs {
 		// Send the trace task over for execution
+		frames[i] = api.collectSystemFees(tx, block.Header(), statedb)
 		jobs <- &txTraceTask{statedb: statedb.Copy(), index: i}
 
 		// Generate the next state snapshot fast without tracing
 		msg, _ := tx.AsMessage(signer)
 		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
-		if posa, ok := api.eth.engine.(consensus.PoSA); ok {
-			if isSystem, _ := posa.IsSystemTransaction(tx, block.Header()); isSystem {
-				balance := statedb.GetBalance(consensus.SystemAddress)
-				if balance.Cmp(common.Big0) > 0 {
-					statedb.SetBalance(consensus.SystemAddress, big.NewInt(0))
-					statedb.AddBalance(block.Header().Coinbase, balance)
-				}
-			}
-		}
 		vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{})
 		if _, _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
 			failed = err
@@ -518,6 +574,9 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 		}
 		// Finalize the state so any modifications are written to the trie
 		// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+		if stateDiff {
+			touched = append(touched, statedb.JournalDiff(vmenv.ChainConfig().IsEIP158(block.Number())))
+		}
 		statedb.Finalise(vmenv.ChainConfig().IsEIP158(block.Number()))
 	}
 	close(jobs)
@@ -527,7 +586,19 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 	if failed != nil {
 		return nil, failed
 	}
-	return results, nil
+	traces := results
+	if config != nil && config.SystemFrames {
+		traces = withSystemFrames(upgrade, frames, results)
+	}
+	if stateDiff {
+		// Pick up the system contract upgrades of blocks without transactions too
+		eip158 := api.eth.blockchain.Config().IsEIP158(block.Number())
+		touched = append(touched, statedb.JournalDiff(eip158))
+		statedb.Finalise(eip158)
+
+		traces = append(traces, &txTraceResult{Block: state.DiffStates(base, statedb, touched...)})
+	}
+	return traces, nil
 }
 
 // standardTraceBlockToFile configures a new tracer which uses standard JSON output,
@@ -556,6 +627,8 @@ func (api *PrivateDebugAPI) standardTraceBlockToFile(ctx context.Context, block
 	if err != nil {
 		return nil, err
 	}
+	upgradeSystemContracts(api.eth.blockchain.Config(), block.Header(), statedb)
+
 	// Retrieve the tracing configurations, or use default values
 	var (
 		logConfig vm.LogConfig
@@ -605,6 +678,7 @@ func (api *PrivateDebugAPI) standardTraceBlockToFile(ctx context.Context, block
 			}
 		}
 		// Execute the transaction and flush any traces to disk
+		api.collectSystemFees(tx, block.Header(), statedb)
 		vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vmConf)
 		_, _, _, err = core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
 		if writer != nil {
@@ -733,12 +807,15 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
 func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
-	// Assemble the structured logger or the JavaScript tracer
+	// Assemble the structured logger or the native or JavaScript tracer
 	var (
 		tracer vm.Tracer
 		err    error
 	)
 	switch {
+	case config != nil && config.Tracer != nil && *config.Tracer == stateDiffTracer:
+		// State diffs are read from the journal, no need to step through the EVM
+
 	case config != nil && config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
 		timeout := defaultTraceTimeout
@@ -747,15 +824,17 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		// Constuct the tracer to execute with, preferring the native implementations
+		txTracer, err := tracers.NewTxTracer(*config.Tracer)
+		if err != nil {
 			return nil, err
 		}
+		tracer = txTracer
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			txTracer.Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -766,15 +845,7 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 		tracer = vm.NewStructLogger(config.LogConfig)
 	}
 	// Run the transaction with tracing enabled.
-	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debug: true, Tracer: tracer})
-	if posa, ok := api.eth.engine.(consensus.PoSA); ok && message.From() == vmctx.Coinbase &&
-		posa.IsSystemContract(message.To()) && message.GasPrice().Cmp(big.NewInt(0)) == 0 {
-		balance := statedb.GetBalance(consensus.SystemAddress)
-		if balance.Cmp(common.Big0) > 0 {
-			statedb.SetBalance(consensus.SystemAddress, big.NewInt(0))
-			statedb.AddBalance(vmctx.Coinbase, balance)
-		}
-	}
+	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debug: tracer != nil, Tracer: tracer})
 	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
 	if err != nil {
 		return nil, fmt.Errorf("tracing failed: %v", err)
@@ -789,9 +860,12 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
-	case *tracers.Tracer:
+	case tracers.TxTracer:
 		return tracer.GetResult()
 
+	case nil:
+		return statedb.JournalDiff(vmenv.ChainConfig().IsEIP158(vmctx.BlockNumber)), nil
+
 	default:
 		panic(fmt.Sprintf("bad tracer type %T", tracer))
 	}
@@ -812,7 +886,10 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 	if err != nil {
 		return nil, vm.Context{}, nil, err
 	}
-
+	if upgrade := upgradeSystemContracts(api.eth.blockchain.Config(), block.Header(), statedb); upgrade != nil {
+		// Leave the upgrade out of the journal, it is not the first transaction's
+		statedb = statedb.Copy()
+	}
 	if txIndex == 0 && len(block.Transactions()) == 0 {
 		return nil, vm.Context{}, statedb, nil
 	}
@@ -823,14 +900,9 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 	for idx, tx := range block.Transactions() {
 		// Assemble the transaction call message and return if the requested offset
 		msg, _ := tx.AsMessage(signer)
-		if posa, ok := api.eth.engine.(consensus.PoSA); ok {
-			if isSystem, _ := posa.IsSystemTransaction(tx, block.Header()); isSystem {
-				balance := statedb.GetBalance(consensus.SystemAddress)
-				if balance.Cmp(common.Big0) > 0 {
-					statedb.SetBalance(consensus.SystemAddress, big.NewInt(0))
-					statedb.AddBalance(block.Header().Coinbase, balance)
-				}
-			}
+		if frame := api.collectSystemFees(tx, block.Header(), statedb); frame != nil {
+			// Leave the fees out of the journal, they are not the transaction's
+			statedb = statedb.Copy()
 		}
 		context := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
 		if idx == txIndex {
@@ -847,3 +919,67 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 	}
 	return nil, vm.Context{}, nil, fmt.Errorf("transaction index %d out of range for block %#x", txIndex, blockHash)
 }
+
+// upgradeSystemContracts applies the system contract upgrades scheduled at the
+// block to the state, returning a synthetic frame of the code swaps or nil if
+// there are none.
+func upgradeSystemContracts(config *params.ChainConfig, header *types.Header, statedb *state.StateDB) *systemFrame {
+	contracts := systemcontracts.UpgradedContracts(config, header.Number)
+	hashes := make([]common.Hash, len(contracts))
+	for i, addr := range contracts {
+		hashes[i] = statedb.GetCodeHash(addr)
+	}
+	systemcontracts.UpgradeBuildInSystemContract(config, header.Number, statedb)
+	if len(contracts) == 0 {
+		return nil
+	}
+	frame := &systemFrame{Type: systemFrameUpgrade}
+	for i, addr := range contracts {
+		frame.Contracts = append(frame.Contracts, &contractUpgrade{
+			Address:     addr,
+			CodeHash:    hashes[i],
+			NewCodeHash: statedb.GetCodeHash(addr),
+		})
+	}
+	return frame
+}
+
+// withSystemFrames interleaves the synthetic frames with the transaction traces
+// of a block: the system contract upgrade first, then the frame preceding every
+// transaction, if any.
+func withSystemFrames(upgrade *systemFrame, frames []*systemFrame, results []*txTraceResult) []*txTraceResult {
+	traces := make([]*txTraceResult, 0, len(results)+len(frames)+2)
+	if upgrade != nil {
+		traces = append(traces, &txTraceResult{System: upgrade})
+	}
+	for i, result := range results {
+		if frames[i] != nil {
+			traces = append(traces, &txTraceResult{System: frames[i]})
+		}
+		traces = append(traces, result)
+	}
+	return traces
+}
+
+// collectSystemFees credits the coinbase with the fees collected at the system
+// address ahead of a system transaction, the way a PoSA engine does when it
+// finalizes the block. It returns a synthetic frame of the transfer, or nil if
+// nothing was moved.
+func (api *PrivateDebugAPI) collectSystemFees(tx *types.Transaction, header *types.Header, statedb *state.StateDB) *systemFrame {
+	posa, ok := api.eth.engine.(consensus.PoSA)
+	if !ok {
+		return nil
+	}
+	if isSystem, _ := posa.IsSystemTransaction(tx, header); !isSystem {
+		return nil
+	}
+	balance := new(big.Int).Set(statedb.GetBalance(consensus.SystemAddress))
+	if balance.Cmp(common.Big0) <= 0 {
+		return nil
+	}
+	statedb.SetBalance(consensus.SystemAddress, big.NewInt(0))
+	statedb.AddBalance(header.Coinbase, balance)
+
+	from, to := consensus.SystemAddress, header.Coinbase
+	return &systemFrame{Type: systemFrameTransfer, From: &from, To: &to, Value: (*hexutil.Big)(balance)}
+}
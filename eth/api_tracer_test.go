@@ -0,0 +1,169 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/parlia"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the state changes a PoSA engine applies outside of the transactions
+// are traced as synthetic frames, interleaved with the transaction traces.
+func TestTraceSystemFrames(t *testing.T) {
+	code := []byte{0x60, 0x80, 0x60, 0x40}
+	config := &params.ChainConfig{
+		ChainID:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		NielsBlock:     big.NewInt(1),
+		SystemContractUpgrades: []*params.SystemContractUpgrade{{
+			Name: "custom",
+			Fork: params.NielsFork,
+			Configs: []*params.SystemContractUpgradeConfig{{
+				ContractAddr: common.HexToAddress(systemcontracts.SlashContract),
+				CodeHash:     crypto.Keccak256Hash(code),
+				Code:         code,
+			}},
+		}},
+		Parlia: &params.ParliaConfig{Period: 3, Epoch: 200},
+	}
+	db := rawdb.NewMemoryDatabase()
+	api := &PrivateDebugAPI{eth: &Ethereum{engine: parlia.New(config, db, nil)}}
+
+	key, _ := crypto.GenerateKey()
+	header := &types.Header{Number: big.NewInt(1), Coinbase: crypto.PubkeyToAddress(key.PublicKey)}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	statedb.AddBalance(consensus.SystemAddress, big.NewInt(1000))
+
+	// The system contract upgrade is traced with the code hashes around it
+	upgrade := upgradeSystemContracts(config, header, statedb)
+	if upgrade == nil || upgrade.Type != systemFrameUpgrade {
+		t.Fatalf("upgrade frame mismatch: have %+v", upgrade)
+	}
+	var found bool
+	for _, contract := range upgrade.Contracts {
+		if contract.Address == common.HexToAddress(systemcontracts.SlashContract) {
+			found = contract.CodeHash != contract.NewCodeHash && contract.NewCodeHash == crypto.Keccak256Hash(code)
+		}
+	}
+	if !found {
+		t.Errorf("configured upgrade missing from the frame: %+v", upgrade.Contracts)
+	}
+	// The fees are only collected ahead of system transactions
+	signer := types.NewEIP155Signer(config.ChainID)
+	user, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+	if frame := api.collectSystemFees(user, header, statedb); frame != nil {
+		t.Errorf("fees collected ahead of a user transaction: %+v", frame)
+	}
+	system, _ := types.SignTx(types.NewTransaction(1, common.HexToAddress(systemcontracts.ValidatorContract), big.NewInt(0), 100000, big.NewInt(0), nil), signer, key)
+	frame := api.collectSystemFees(system, header, statedb)
+	if frame == nil || frame.Type != systemFrameTransfer || *frame.From != consensus.SystemAddress || *frame.To != header.Coinbase || frame.Value.ToInt().Int64() != 1000 {
+		t.Fatalf("transfer frame mismatch: have %+v", frame)
+	}
+	if statedb.GetBalance(consensus.SystemAddress).Sign() != 0 || statedb.GetBalance(header.Coinbase).Int64() != 1000 {
+		t.Errorf("fees not moved to the coinbase")
+	}
+	if frame := api.collectSystemFees(system, header, statedb); frame != nil {
+		t.Errorf("fees collected twice: %+v", frame)
+	}
+	// The frames precede the traces of the transactions they belong to
+	results := []*txTraceResult{{Result: "user"}, {Result: "system"}}
+	traces := withSystemFrames(upgrade, []*systemFrame{nil, frame}, results)
+	want := []*txTraceResult{{System: upgrade}, results[0], {System: frame}, results[1]}
+	if len(traces) != len(want) {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), len(want))
+	}
+	for i := range want {
+		if traces[i].System != want[i].System || traces[i].Result != want[i].Result {
+			t.Errorf("trace %d mismatch: have %+v, want %+v", i, traces[i], want[i])
+		}
+	}
+}
+
+// Tests that the state diff of the first transaction of a block upgrading system
+// contracts leaves the upgrade out, it is not made by the transaction.
+func TestTraceStateDiffUpgrade(t *testing.T) {
+	var (
+		code   = []byte{0x60, 0x80, 0x60, 0x40}
+		slash  = common.HexToAddress(systemcontracts.SlashContract)
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.Address{0x01}
+		db     = rawdb.NewMemoryDatabase()
+		config = *params.TestChainConfig
+	)
+	config.NielsBlock = big.NewInt(1)
+	config.SystemContractUpgrades = []*params.SystemContractUpgrade{{
+		Name: "custom",
+		Fork: params.NielsFork,
+		Configs: []*params.SystemContractUpgradeConfig{{
+			ContractAddr: slash,
+			CodeHash:     crypto.Keccak256Hash(code),
+			Code:         code,
+		}},
+	}}
+	gspec := &core.Genesis{Config: &config, Alloc: core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}}}
+	genesis := gspec.MustCommit(db)
+
+	signer := types.MakeSigner(&config, common.Big1)
+	tx, _ := types.SignTx(types.NewTransaction(0, to, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+	blocks, _ := core.GenerateChain(&config, genesis, ethash.NewFaker(), db, 1, func(i int, b *core.BlockGen) {
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	api := NewPrivateDebugAPI(&Ethereum{blockchain: chain, chainDb: db})
+
+	tracer := stateDiffTracer
+	res, err := api.TraceTransaction(context.Background(), tx.Hash(), &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	diff, ok := res.(state.StateDiff)
+	if !ok {
+		t.Fatalf("state diff type mismatch: have %T", res)
+	}
+	if _, ok := diff[slash]; ok {
+		t.Errorf("system contract upgrade attributed to the transaction")
+	}
+	for _, account := range []common.Address{addr, to} {
+		if _, ok := diff[account]; !ok {
+			t.Errorf("account %x missing from the state diff", account)
+		}
+	}
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -184,6 +185,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
 			TrieCleanNoPrefetch: config.NoPrefetch,
+			TrieAccessPrefetch:  config.AccessPrefetch,
 			TrieDirtyLimit:      config.TrieDirtyCache,
 			TrieDirtyDisabled:   config.NoPruning,
 			TrieTimeLimit:       config.TrieTimeout,
@@ -194,6 +196,12 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.VMProfile {
+		eth.blockchain.SetBlockTracer(tracers.NewMetricsProfiler())
+	}
+	if config.ParallelTxs > 1 {
+		eth.blockchain.SetParallelProcessing(config.ParallelTxs)
+	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -205,6 +213,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = ctx.ResolvePath(config.TxPool.Snapshot)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, chainConfig, eth.blockchain)
 
 	// Permit the downloader to use the trie cache allowance during fast sync
@@ -297,6 +308,14 @@ func (s *Ethereum) APIs() []rpc.API {
 	}
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
+	if _, ok := s.engine.(*parlia.Parlia); ok {
+		apis = append(apis, rpc.API{
+			Namespace: "parlia",
+			Version:   "1.0",
+			Service:   NewPrivateParliaAPI(s),
+			Public:    false,
+		})
+	}
 
 	// Append any APIs exposed explicitly by the les server
 	if s.lesServer != nil {
@@ -500,6 +519,40 @@ func (s *Ethereum) StartMining(threads int) error {
 	return nil
 }
 
+// RotateValidators makes the miner seal every block with the first validator the
+// Parlia engine allows to, among the ones whose keys are available locally. The
+// offline validators never seal, leaving their slots to be missed. It is meant
+// for developer networks running all the validators in a single process.
+func (s *Ethereum) RotateValidators(offline []common.Address) error {
+	engine, ok := s.engine.(*parlia.Parlia)
+	if !ok {
+		return errors.New("validator rotation requires the parlia engine")
+	}
+	skip := make(map[common.Address]bool, len(offline))
+	for _, val := range offline {
+		skip[val] = true
+	}
+	s.miner.SetValidatorRotation(func(parent *types.Header) (common.Address, error) {
+		validators, err := engine.NextValidators(s.blockchain, parent)
+		if err != nil {
+			return common.Address{}, err
+		}
+		for _, val := range validators {
+			if skip[val] {
+				continue
+			}
+			wallet, err := s.accountManager.Find(accounts.Account{Address: val})
+			if wallet == nil || err != nil {
+				continue
+			}
+			engine.Authorize(val, wallet.SignData, wallet.SignTx)
+			return val, nil
+		}
+		return common.Address{}, fmt.Errorf("no local validator allowed to seal block %d", parent.Number.Uint64()+1)
+	})
+	return nil
+}
+
 // StopMining terminates the miner, both at the consensus engine level as well as
 // at the block creation level.
 func (s *Ethereum) StopMining() {
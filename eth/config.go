@@ -103,8 +103,9 @@ type Config struct {
 	// for nodes to connect to.
 	DiscoveryURLs []string
 
-	NoPruning  bool // Whether to disable pruning and flush everything to disk
-	NoPrefetch bool // Whether to disable prefetching and only load state on demand
+	NoPruning      bool // Whether to disable pruning and flush everything to disk
+	NoPrefetch     bool // Whether to disable prefetching and only load state on demand
+	AccessPrefetch bool // Whether to prefetch the state blocks likely access, derived from their transactions
 
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
@@ -146,6 +147,13 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables profiling the execution of the imported blocks into the metrics
+	VMProfile bool
+
+	// Number of workers executing the transactions of the imported blocks in
+	// parallel, 0 to execute them serially
+	ParallelTxs int
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
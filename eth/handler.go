@@ -50,6 +50,10 @@ const (
 	// txChanSize is the size of channel listening to NewTxsEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// doubleSignChanSize is the number of received headers waiting for the
+	// double-sign check, beyond which they are dropped unchecked.
+	doubleSignChanSize = 256
 )
 
 var (
@@ -86,6 +90,12 @@ type ProtocolManager struct {
 
 	whitelist map[uint64]common.Hash
 
+	// doubleSignCheck inspects received headers for validators sealing two
+	// different headers at the same height, if the engine supports it. The
+	// headers are queued to it, not to stall the peers on signature recovery.
+	doubleSignCheck func(header *types.Header)
+	doubleSignCh    chan *types.Header
+
 	// channels for fetcher, syncer, txsyncLoop
 	txsyncCh chan *txsync
 	quitSync chan struct{}
@@ -153,6 +163,13 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 	}
 	manager.downloader = downloader.New(manager.checkpointNumber, chaindb, stateBloom, manager.eventMux, blockchain, nil, manager.removePeer)
 
+	if checker, ok := engine.(doubleSignChecker); ok {
+		manager.doubleSignCheck = func(header *types.Header) {
+			checker.CheckDoubleSign(blockchain, header)
+		}
+		manager.doubleSignCh = make(chan *types.Header, doubleSignChanSize)
+	}
+
 	// Construct the fetcher (short sync)
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
@@ -195,7 +212,7 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 		}
 		return p.RequestTxs(hashes)
 	}
-	manager.txFetcher = fetcher.NewTxFetcher(txpool.Has, txpool.AddRemotes, fetchTx)
+	manager.txFetcher = fetcher.NewTxFetcher(txpool.Has, txpool.AddRemotesFrom, fetchTx)
 
 	manager.chainSync = newChainSyncer(manager)
 
@@ -262,6 +279,12 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go pm.minedBroadcastLoop()
 
+	// check received headers for double signing
+	if pm.doubleSignCh != nil {
+		pm.wg.Add(1)
+		go pm.doubleSignLoop()
+	}
+
 	// start sync handlers
 	pm.wg.Add(2)
 	go pm.chainSync.loop()
@@ -486,6 +509,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&headers); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		for _, header := range headers {
+			pm.queueDoubleSignCheck(header)
+		}
 		// If no headers were received, but we're expencting a checkpoint header, consider it that
 		if len(headers) == 0 && p.syncDrop != nil {
 			// Stop the timer either way, decide later to drop or not
@@ -713,6 +739,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
+		pm.queueDoubleSignCheck(request.Block.Header())
 		pm.blockFetcher.Enqueue(p.id, request.Block)
 
 		// Assuming the block is importable by the peer, but possibly not yet done so,
@@ -763,9 +790,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			} else if err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
-			// Retrieve the requested transaction, skipping if unknown to us
+			// Retrieve the requested transaction, skipping if unknown to us or private
 			tx := pm.txpool.Get(hash)
-			if tx == nil {
+			if tx == nil || pm.txpool.IsPrivate(hash) {
 				continue
 			}
 			// If known, encode and queue for response packet
@@ -910,6 +937,34 @@ func (pm *ProtocolManager) txBroadcastLoop() {
 	}
 }
 
+// queueDoubleSignCheck schedules a received header for the double-sign check,
+// dropping it if the check falls behind rather than blocking the peer.
+func (pm *ProtocolManager) queueDoubleSignCheck(header *types.Header) {
+	if pm.doubleSignCh == nil {
+		return
+	}
+	select {
+	case pm.doubleSignCh <- header:
+	default:
+		log.Trace("Double-sign check busy, dropped header", "number", header.Number, "hash", header.Hash())
+	}
+}
+
+// doubleSignLoop checks the queued headers for double signing.
+func (pm *ProtocolManager) doubleSignLoop() {
+	defer pm.wg.Done()
+
+	for {
+		select {
+		case header := <-pm.doubleSignCh:
+			pm.doubleSignCheck(header)
+
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
 // NodeInfo represents a short summary of the Ethereum sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
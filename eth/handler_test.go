@@ -689,3 +689,54 @@ func TestBroadcastMalformedBlock(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the received headers are queued for the double-sign check without
+// blocking the peers, dropping them once the check falls behind.
+func TestDoubleSignCheckQueue(t *testing.T) {
+	release := make(chan struct{})
+	checked := make(chan *types.Header, 2*doubleSignChanSize)
+
+	pm := &ProtocolManager{
+		quitSync:     make(chan struct{}),
+		doubleSignCh: make(chan *types.Header, doubleSignChanSize),
+		doubleSignCheck: func(header *types.Header) {
+			<-release
+			checked <- header
+		},
+	}
+	pm.wg.Add(1)
+	go pm.doubleSignLoop()
+	defer func() {
+		close(pm.quitSync)
+		pm.wg.Wait()
+	}()
+
+	// Queue more headers than the check can take while stuck
+	queued := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*doubleSignChanSize; i++ {
+			pm.queueDoubleSignCheck(&types.Header{Number: big.NewInt(int64(i))})
+		}
+		close(queued)
+	}()
+	select {
+	case <-queued:
+	case <-time.After(time.Second):
+		t.Fatalf("queueing blocked on a busy check")
+	}
+	// Release the check and ensure the excess headers were dropped
+	close(release)
+
+	var count int
+	for done := false; !done; {
+		select {
+		case <-checked:
+			count++
+		case <-time.After(100 * time.Millisecond):
+			done = true
+		}
+	}
+	if count < doubleSignChanSize || count > doubleSignChanSize+1 {
+		t.Errorf("checked header count mismatch: have %d, want %d or %d", count, doubleSignChanSize, doubleSignChanSize+1)
+	}
+}
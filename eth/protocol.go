@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -111,9 +112,17 @@ type txPool interface {
 	// tx hash.
 	Get(hash common.Hash) *types.Transaction
 
+	// IsPrivate returns whether the transaction with the given hash
+	// must be kept from the network.
+	IsPrivate(hash common.Hash) bool
+
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 
+	// AddRemotesFrom should add the given transactions received from the
+	// given peer to the pool.
+	AddRemotesFrom(peer string, txs []*types.Transaction) []error
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
@@ -123,6 +132,14 @@ type txPool interface {
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 }
 
+// doubleSignChecker is implemented by consensus engines able to spot validators
+// sealing two different headers at the same height.
+type doubleSignChecker interface {
+	// CheckDoubleSign inspects a header received from the network, recording
+	// it as evidence if its signer already sealed another one at that height.
+	CheckDoubleSign(chain consensus.ChainReader, header *types.Header)
+}
+
 // statusData63 is the network packet for the status message for eth/63.
 type statusData63 struct {
 	ProtocolVersion uint32
@@ -0,0 +1,108 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// TxTracer is a vm.Tracer assembling the result of a transaction trace, either
+// natively in Go or by evaluating a JavaScript tracer.
+type TxTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON encoded result of the trace, or any error that
+	// occurred while tracing.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing at the first opportune moment.
+	Stop(err error)
+}
+
+// natives contains the constructors of all the native tracers by name.
+var natives = make(map[string]func() TxTracer)
+
+// RegisterNative makes a native tracer available under the given name. Native
+// tracers take precedence over the JavaScript tracers of the same name.
+func RegisterNative(name string, ctor func() TxTracer) {
+	natives[name] = ctor
+}
+
+// NewTxTracer instantiates a tracer by name or from JavaScript code, preferring
+// the native implementation of the named tracers.
+func NewTxTracer(code string) (TxTracer, error) {
+	if ctor, ok := natives[code]; ok {
+		return ctor(), nil
+	}
+	tracer, err := New(code)
+	if err != nil {
+		return nil, err
+	}
+	return tracer, nil
+}
+
+// interrupter implements the interruption handling shared by the native tracers.
+type interrupter struct {
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (i *interrupter) Stop(err error) {
+	i.reason = err
+	atomic.StoreUint32(&i.interrupt, 1)
+}
+
+// interrupted returns whether the tracer was stopped.
+func (i *interrupter) interrupted() bool {
+	return atomic.LoadUint32(&i.interrupt) > 0
+}
+
+// memorySlice returns the memory range denoted by the given offset and size
+// stack items, the same way log.memory.slice does for the JavaScript tracers.
+func memorySlice(memory *vm.Memory, offset, size *big.Int) []byte {
+	if size.Sign() == 0 {
+		return []byte{}
+	}
+	if !offset.IsInt64() || !size.IsInt64() || offset.Int64()+size.Int64() < offset.Int64() {
+		return nil
+	}
+	return (&memoryWrapper{memory}).slice(offset.Int64(), offset.Int64()+size.Int64())
+}
+
+// stackPeek returns the nth-from-the-top element of the stack, or zero if the
+// stack is too short.
+func stackPeek(stack *vm.Stack, idx int) *big.Int {
+	return (&stackWrapper{stack}).peek(idx)
+}
+
+// encodeResult JSON encodes a native tracer result the same way the JavaScript
+// tracers do, without escaping HTML characters.
+func encodeResult(v interface{}) (json.RawMessage, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
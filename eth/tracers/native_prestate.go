@@ -0,0 +1,222 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func init() {
+	RegisterNative("prestateTracer", func() TxTracer { return newPrestateTracer() })
+}
+
+// prestateAccount is the state of an account before the traced transaction.
+type prestateAccount struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[common.Hash]common.Hash
+	slots   []common.Hash // Storage slots in the order they were accessed
+}
+
+// prestate is the genesis allocation assembled by the prestate tracer. It keeps
+// the accounts and storage slots in access order, like the JavaScript object the
+// prestateTracer builds.
+type prestate struct {
+	accounts map[common.Address]*prestateAccount
+	order    []common.Address
+}
+
+// MarshalJSON implements json.Marshaler, encoding the accounts in access order.
+func (p *prestate) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, addr := range p.order {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		acc := p.accounts[addr]
+		buf.WriteString(`"` + hexutil.Encode(addr.Bytes()) + `":{`)
+		buf.WriteString(`"balance":"` + hexutil.EncodeBig(acc.balance) + `",`)
+		buf.WriteString(`"nonce":` + strconv.FormatUint(acc.nonce, 10) + `,`)
+		buf.WriteString(`"code":"` + hexutil.Encode(acc.code) + `",`)
+		buf.WriteString(`"storage":{`)
+		for j, slot := range acc.slots {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`"` + hexutil.Encode(slot.Bytes()) + `":"` + hexutil.Encode(acc.storage[slot].Bytes()) + `"`)
+		}
+		buf.WriteString(`}}`)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// prestateTracer is the native implementation of the JavaScript prestateTracer,
+// which outputs sufficient information to create a local execution of the
+// transaction from a custom assembled genesis block.
+type prestateTracer struct {
+	interrupter
+
+	prestate *prestate  // Genesis that we're building, nil until the first step
+	db       vm.StateDB // State database of the traced execution
+
+	create bool
+	from   common.Address
+	to     common.Address
+	value  *big.Int
+
+	err error // Error, if one has occurred
+}
+
+// newPrestateTracer creates a native prestate tracer.
+func newPrestateTracer() *prestateTracer {
+	return new(prestateTracer)
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate.accounts[addr]; ok {
+		return
+	}
+	t.prestate.accounts[addr] = &prestateAccount{
+		balance: new(big.Int).Set(t.db.GetBalance(addr)),
+		nonce:   t.db.GetNonce(addr),
+		code:    common.CopyBytes(t.db.GetCode(addr)),
+		storage: make(map[common.Hash]common.Hash),
+	}
+	t.prestate.order = append(t.prestate.order, addr)
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	acc := t.prestate.accounts[addr]
+	if _, ok := acc.storage[key]; ok {
+		return
+	}
+	acc.storage[key] = t.db.GetState(addr, key)
+	acc.slots = append(acc.slots, key)
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, new(big.Int).Set(value)
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	// If tracing was interrupted, set the error and stop
+	if t.interrupted() {
+		t.err = t.reason
+		return nil
+	}
+	// Add the current account if we just started tracing. Balance will potentially
+	// be wrong here, since this will include the value sent along with the message.
+	// We fix that in GetResult.
+	if t.prestate == nil {
+		t.prestate = &prestate{accounts: make(map[common.Address]*prestateAccount)}
+		t.db = env.StateDB
+		t.lookupAccount(contract.Address())
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.BigToAddress(stackPeek(stack, 0)))
+
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.db.GetNonce(from)))
+
+	case vm.CREATE2:
+		// stack: salt, size, offset, endowment
+		code := memorySlice(memory, stackPeek(stack, 1), stackPeek(stack, 2))
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), common.BigToHash(stackPeek(stack, 3)), crypto.Keccak256(code)))
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.BigToAddress(stackPeek(stack, 1)))
+
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(stackPeek(stack, 0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the assembled allocations (prestate).
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	// Without a single executed opcode there is no state to read the accounts from,
+	// the JavaScript tracer fails the same way
+	if t.prestate == nil {
+		return nil, errors.New("prestate unavailable, no code was executed")
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction, and
+	// move it back to the origin
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+
+	fromBal := new(big.Int).Set(t.prestate.accounts[t.from].balance)
+	toBal := new(big.Int).Set(t.prestate.accounts[t.to].balance)
+
+	t.prestate.accounts[t.to].balance = toBal.Sub(toBal, t.value)
+	t.prestate.accounts[t.from].balance = fromBal.Add(fromBal, t.value)
+
+	// Decrement the caller's nonce, and remove empty create targets. We can blindly
+	// delete the contract prestate, as any existing state would have caused the
+	// transaction to be rejected as invalid in the first place.
+	t.prestate.accounts[t.from].nonce--
+	if t.create {
+		delete(t.prestate.accounts, t.to)
+		for i, addr := range t.prestate.order {
+			if addr == t.to {
+				t.prestate.order = append(t.prestate.order[:i], t.prestate.order[i+1:]...)
+				break
+			}
+		}
+	}
+	return encodeResult(t.prestate)
+}
@@ -0,0 +1,365 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// precompileNames names the known precompiled contracts in profiles, the others
+// are reported by address.
+var precompileNames = map[common.Address]string{
+	common.BytesToAddress([]byte{1}):   "ecrecover",
+	common.BytesToAddress([]byte{2}):   "sha256",
+	common.BytesToAddress([]byte{3}):   "ripemd160",
+	common.BytesToAddress([]byte{4}):   "identity",
+	common.BytesToAddress([]byte{5}):   "modexp",
+	common.BytesToAddress([]byte{6}):   "bn256Add",
+	common.BytesToAddress([]byte{7}):   "bn256ScalarMul",
+	common.BytesToAddress([]byte{8}):   "bn256Pairing",
+	common.BytesToAddress([]byte{9}):   "blake2F",
+	common.BytesToAddress([]byte{100}): "tmHeaderValidate",
+	common.BytesToAddress([]byte{101}): "iavlMerkleProofValidate",
+}
+
+// precompileName returns the name a precompiled contract is profiled under.
+func precompileName(addr common.Address) string {
+	if name, ok := precompileNames[addr]; ok {
+		return name
+	}
+	return addr.Hex()
+}
+
+// OpcodeProfile is the cost of executing an opcode.
+type OpcodeProfile struct {
+	Count uint64        `json:"count"`
+	Time  time.Duration `json:"time"` // Nanoseconds, excluding the precompiled contracts called
+}
+
+// PrecompileProfile is the cost of running a precompiled contract.
+type PrecompileProfile struct {
+	Address common.Address `json:"address"`
+	Calls   uint64         `json:"calls"`
+	Time    time.Duration  `json:"time"` // Nanoseconds
+}
+
+// StorageCacheProfile counts the storage slot reads of an opcode served from the
+// state caches and the ones loaded from the snapshot or the trie.
+type StorageCacheProfile struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hitRate"`
+}
+
+// ContractProfile is the cost of executing the code of a contract, excluding the
+// contracts it calls.
+type ContractProfile struct {
+	Calls uint64 `json:"calls"`
+	Gas   uint64 `json:"gas"`
+}
+
+// Profile is the aggregated execution cost of a number of transactions.
+type Profile struct {
+	Transactions uint64                              `json:"transactions"`
+	Opcodes      map[string]*OpcodeProfile           `json:"opcodes"`
+	Precompiles  map[string]*PrecompileProfile       `json:"precompiles"`
+	Storage      map[string]*StorageCacheProfile     `json:"storage"` // By opcode, SLOAD and SSTORE
+	Contracts    map[common.Address]*ContractProfile `json:"contracts"`
+}
+
+// newProfile creates an empty profile.
+func newProfile() *Profile {
+	return &Profile{
+		Opcodes:     make(map[string]*OpcodeProfile),
+		Precompiles: make(map[string]*PrecompileProfile),
+		Storage:     make(map[string]*StorageCacheProfile),
+		Contracts:   make(map[common.Address]*ContractProfile),
+	}
+}
+
+// profileFrame tracks the gas of a call frame, attributing the gas used by each
+// step to the contract once the next step of the frame shows how much it took.
+type profileFrame struct {
+	addr     common.Address
+	depth    int
+	startGas uint64 // Gas available at the first step of the frame
+	gas      uint64 // Gas available at the last step of the frame
+	cost     uint64 // Cost of the last step of the frame
+	children uint64 // Gas used by the frames the last step called
+	failed   bool   // Whether the last step failed, consuming all the gas
+}
+
+// Profiler is a vm.Tracer aggregating the execution cost of transactions by
+// opcode, precompiled contract, storage cache and contract. It can run across
+// any number of transactions, one at a time, and optionally exports the costs
+// into the metrics system after each of them.
+type Profiler struct {
+	profile *Profile // Aggregated costs, nil if only exporting metrics
+	current *Profile // Costs of the transaction being executed
+
+	frames []*profileFrame
+
+	to         common.Address // Recipient of the transaction being executed
+	steps      uint64         // Steps executed in the transaction
+	stepOp     vm.OpCode      // Opcode of the last step
+	stepStart  time.Time      // Start time of the last step
+	stepCall   string         // Precompiled contract called by the last step, if any
+	stepHits   uint64         // Storage cache hits before the last step
+	stepMisses uint64         // Storage cache misses before the last step
+	statedb    *state.StateDB // State the storage cache statistics are read from
+
+	export bool // Whether to export the costs into the metrics system
+}
+
+// NewProfiler creates a profiler aggregating the costs of all the transactions
+// it traces, optionally exporting them into the metrics system too.
+func NewProfiler(export bool) *Profiler {
+	return &Profiler{profile: newProfile(), current: newProfile(), export: export}
+}
+
+// NewMetricsProfiler creates a profiler only exporting the costs into the
+// metrics system, keeping nothing in memory in between the transactions.
+func NewMetricsProfiler() *Profiler {
+	return &Profiler{current: newProfile(), export: true}
+}
+
+// Profile returns the costs aggregated so far, nil if only exporting metrics.
+func (p *Profiler) Profile() *Profile {
+	if p.profile == nil {
+		return nil
+	}
+	for _, cache := range p.profile.Storage {
+		if total := cache.Hits + cache.Misses; total > 0 {
+			cache.HitRate = float64(cache.Hits) / float64(total)
+		}
+	}
+	return p.profile
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the profiling
+// of a transaction.
+func (p *Profiler) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	p.to, p.steps, p.frames, p.statedb = to, 0, p.frames[:0], nil
+	return nil
+}
+
+// CaptureState implements the vm.Tracer interface to profile a single step of
+// VM execution.
+func (p *Profiler) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	now := time.Now()
+	if p.steps == 0 {
+		p.statedb, _ = env.StateDB.(*state.StateDB)
+	} else {
+		p.endStep(now)
+	}
+	p.steps++
+
+	// Account the gas of the frames returned to and of the last step of this one
+	for len(p.frames) > 0 && p.frames[len(p.frames)-1].depth > depth {
+		p.popFrame()
+	}
+	if len(p.frames) == 0 || p.frames[len(p.frames)-1].depth < depth {
+		p.frames = append(p.frames, &profileFrame{addr: contract.Address(), depth: depth, startGas: gas, gas: gas})
+		p.contract(contract.Address()).Calls++
+	}
+	frame := p.frames[len(p.frames)-1]
+	if frame.gas > gas && frame.gas-gas > frame.children {
+		p.contract(frame.addr).Gas += frame.gas - gas - frame.children
+	}
+	frame.gas, frame.cost, frame.children, frame.failed = gas, cost, 0, err != nil
+
+	// Start timing this step, noting any precompiled contract it calls
+	p.stepOp, p.stepCall, p.stepStart = op, "", now
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		if len(stack.Data()) > 1 {
+			to := common.BigToAddress(stackPeek(stack, 1))
+			if _, ok := env.Precompile(to); ok {
+				p.stepCall = precompileName(to)
+				p.precompile(to).Calls++
+			}
+		}
+	}
+	if p.statedb != nil {
+		p.stepHits, p.stepMisses = p.statedb.StorageCacheHits, p.statedb.StorageCacheMisses
+	}
+	p.opcode(op).Count++
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to note a failing step, which
+// consumes all the gas of its frame unless reverting.
+func (p *Profiler) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if len(p.frames) > 0 && op != vm.REVERT {
+		p.frames[len(p.frames)-1].failed = true
+	}
+	return nil
+}
+
+// CaptureEnd implements the vm.Tracer interface to finish the profiling of a
+// transaction, merging its costs into the aggregate and the metrics.
+func (p *Profiler) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	if p.steps > 0 {
+		p.endStep(time.Now())
+	} else if _, ok := precompileNames[p.to]; ok {
+		// Transactions calling a precompiled contract directly don't execute any step
+		prof := p.precompile(p.to)
+		prof.Calls++
+		prof.Time += t
+	}
+	for len(p.frames) > 0 {
+		p.popFrame()
+	}
+	p.current.Transactions++
+	p.flush()
+	return nil
+}
+
+// endStep accounts the time and the storage cache statistics of the last step.
+func (p *Profiler) endStep(now time.Time) {
+	elapsed := now.Sub(p.stepStart)
+	if p.stepCall != "" {
+		p.current.Precompiles[p.stepCall].Time += elapsed
+	} else {
+		p.opcode(p.stepOp).Time += elapsed
+	}
+	if (p.stepOp == vm.SLOAD || p.stepOp == vm.SSTORE) && p.statedb != nil {
+		cache := p.current.Storage[p.stepOp.String()]
+		if cache == nil {
+			cache = new(StorageCacheProfile)
+			p.current.Storage[p.stepOp.String()] = cache
+		}
+		cache.Hits += p.statedb.StorageCacheHits - p.stepHits
+		cache.Misses += p.statedb.StorageCacheMisses - p.stepMisses
+	}
+}
+
+// popFrame accounts the last step of the innermost frame, which returned, and
+// charges the gas used by the whole frame to the step of its caller.
+func (p *Profiler) popFrame() {
+	frame := p.frames[len(p.frames)-1]
+	p.frames = p.frames[:len(p.frames)-1]
+
+	// The gas left after the last step is unknown, approximate it by its cost
+	last := frame.cost
+	if frame.failed || last > frame.gas {
+		last = frame.gas
+	}
+	p.contract(frame.addr).Gas += last
+	if len(p.frames) > 0 {
+		p.frames[len(p.frames)-1].children += frame.startGas - frame.gas + last
+	}
+}
+
+// flush merges the costs of the last transaction into the aggregate profile and
+// exports them into the metrics system, starting afresh for the next one.
+func (p *Profiler) flush() {
+	if p.export && metrics.Enabled {
+		for name, op := range p.current.Opcodes {
+			metrics.GetOrRegisterMeter("vm/profile/opcode/"+name+"/count", nil).Mark(int64(op.Count))
+			metrics.GetOrRegisterMeter("vm/profile/opcode/"+name+"/time", nil).Mark(int64(op.Time))
+		}
+		for name, pre := range p.current.Precompiles {
+			metrics.GetOrRegisterMeter("vm/profile/precompile/"+name+"/count", nil).Mark(int64(pre.Calls))
+			metrics.GetOrRegisterMeter("vm/profile/precompile/"+name+"/time", nil).Mark(int64(pre.Time))
+		}
+		for name, cache := range p.current.Storage {
+			metrics.GetOrRegisterMeter("vm/profile/storage/"+name+"/hits", nil).Mark(int64(cache.Hits))
+			metrics.GetOrRegisterMeter("vm/profile/storage/"+name+"/misses", nil).Mark(int64(cache.Misses))
+		}
+	}
+	if p.profile != nil {
+		p.profile.merge(p.current)
+	}
+	p.current = newProfile()
+}
+
+// merge adds the costs of another profile to this one.
+func (prof *Profile) merge(other *Profile) {
+	prof.Transactions += other.Transactions
+	for name, op := range other.Opcodes {
+		if have, ok := prof.Opcodes[name]; ok {
+			have.Count += op.Count
+			have.Time += op.Time
+		} else {
+			prof.Opcodes[name] = op
+		}
+	}
+	for name, pre := range other.Precompiles {
+		if have, ok := prof.Precompiles[name]; ok {
+			have.Calls += pre.Calls
+			have.Time += pre.Time
+		} else {
+			prof.Precompiles[name] = pre
+		}
+	}
+	for name, cache := range other.Storage {
+		if have, ok := prof.Storage[name]; ok {
+			have.Hits += cache.Hits
+			have.Misses += cache.Misses
+		} else {
+			prof.Storage[name] = cache
+		}
+	}
+	for addr, contract := range other.Contracts {
+		if have, ok := prof.Contracts[addr]; ok {
+			have.Calls += contract.Calls
+			have.Gas += contract.Gas
+		} else {
+			prof.Contracts[addr] = contract
+		}
+	}
+}
+
+// opcode returns the profile of an opcode in the current transaction.
+func (p *Profiler) opcode(op vm.OpCode) *OpcodeProfile {
+	name := op.String()
+	prof, ok := p.current.Opcodes[name]
+	if !ok {
+		prof = new(OpcodeProfile)
+		p.current.Opcodes[name] = prof
+	}
+	return prof
+}
+
+// precompile returns the profile of a precompiled contract in the current
+// transaction.
+func (p *Profiler) precompile(addr common.Address) *PrecompileProfile {
+	name := precompileName(addr)
+	prof, ok := p.current.Precompiles[name]
+	if !ok {
+		prof = &PrecompileProfile{Address: addr}
+		p.current.Precompiles[name] = prof
+	}
+	return prof
+}
+
+// contract returns the profile of a contract in the current transaction.
+func (p *Profiler) contract(addr common.Address) *ContractProfile {
+	prof, ok := p.current.Contracts[addr]
+	if !ok {
+		prof = new(ContractProfile)
+		p.current.Contracts[addr] = prof
+	}
+	return prof
+}
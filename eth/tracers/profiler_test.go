@@ -0,0 +1,74 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the profiler attributes the opcodes, the storage cache accesses,
+// the precompiled contract calls and the gas of a transaction.
+func TestProfiler(t *testing.T) {
+	code := hexutil.MustDecode("0x" +
+		"6000545060005450" + // SLOAD slot 0 twice, missing then hitting the cache
+		"6001600055" + // SSTORE 1 into slot 0
+		"600060006000600060025afa50" + // STATICCALL sha256 with no input
+		"00") // STOP
+
+	profiler := NewProfiler(false)
+	cfg := &runtime.Config{
+		ChainConfig: params.AllEthashProtocolChanges,
+		GasLimit:    100000,
+		EVMConfig:   vm.Config{Debug: true, Tracer: profiler},
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := runtime.Execute(code, nil, cfg); err != nil {
+			t.Fatalf("execution %d failed: %v", i, err)
+		}
+		cfg.State = nil
+	}
+	profile := profiler.Profile()
+
+	if profile.Transactions != 2 {
+		t.Errorf("transaction count mismatch: have %d, want 2", profile.Transactions)
+	}
+	for op, count := range map[string]uint64{"PUSH1": 18, "SLOAD": 4, "SSTORE": 2, "STATICCALL": 2, "STOP": 2} {
+		if have := profile.Opcodes[op]; have == nil || have.Count != count {
+			t.Errorf("%s count mismatch: have %+v, want %d", op, have, count)
+		}
+	}
+	if have := profile.Storage["SLOAD"]; have == nil || have.Hits != 2 || have.Misses != 2 || have.HitRate != 0.5 {
+		t.Errorf("SLOAD cache mismatch: have %+v", have)
+	}
+	if have := profile.Storage["SSTORE"]; have == nil || have.Hits != 2 || have.Misses != 0 {
+		t.Errorf("SSTORE cache mismatch: have %+v", have)
+	}
+	if have := profile.Precompiles["sha256"]; have == nil || have.Calls != 2 {
+		t.Errorf("sha256 calls mismatch: have %+v", have)
+	}
+	// 9 PUSH1, 2 SLOAD, 3 POP, SSTORE, GAS and STATICCALL with sha256, per run
+	contract := profile.Contracts[common.BytesToAddress([]byte("contract"))]
+	if want := uint64(2 * (9*3 + 2*800 + 3*2 + 20000 + 2 + 700 + 60)); contract == nil || contract.Gas != want || contract.Calls != 2 {
+		t.Errorf("contract profile mismatch: have %+v, want gas %d", contract, want)
+	}
+}
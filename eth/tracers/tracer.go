@@ -286,7 +286,8 @@ func (cw *contractWrapper) pushObject(vm *duktape.Context) {
 // Tracer provides an implementation of Tracer that evaluates a Javascript
 // function for each VM execution step.
 type Tracer struct {
-	inited bool // Flag whether the context was already inited from the EVM
+	inited bool    // Flag whether the context was already inited from the EVM
+	env    *vm.EVM // EVM of the traced execution, set on the first step
 
 	vm *duktape.Context // Javascript VM instance
 
@@ -399,7 +400,15 @@ func New(code string) (*Tracer, error) {
 		return 1
 	})
 	tracer.vm.PushGlobalGoFunction("isPrecompiled", func(ctx *duktape.Context) int {
-		_, ok := vm.PrecompiledContractsIstanbul[common.BytesToAddress(popSlice(ctx))]
+		addr := common.BytesToAddress(popSlice(ctx))
+
+		// Consult the contracts active in the traced block once it is known
+		var ok bool
+		if tracer.env != nil {
+			_, ok = tracer.env.Precompile(addr)
+		} else {
+			ok = vm.IsPrecompileAddress(addr)
+		}
 		ctx.PushBoolean(ok)
 		return 1
 	})
@@ -546,6 +555,7 @@ func (jst *Tracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost
 		// Initialize the context if it wasn't done yet
 		if !jst.inited {
 			jst.ctx["block"] = env.BlockNumber.Uint64()
+			jst.env = env
 			jst.inited = true
 		}
 		// If tracing was interrupted, set the error and stop
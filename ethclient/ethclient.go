@@ -278,6 +278,25 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 	return r, err
 }
 
+// SystemTransaction reports why the consensus engine injected the transaction with
+// the given hash into its block. It returns nil for user and pending transactions.
+func (ec *Client) SystemTransaction(ctx context.Context, txHash common.Hash) (*types.SystemTxInfo, error) {
+	var meta *struct {
+		IsSystemTx bool                `json:"isSystemTx"`
+		SystemTx   *types.SystemTxInfo `json:"systemTx"`
+	}
+	if err := ec.c.CallContext(ctx, &meta, "eth_getTransactionByHash", txHash); err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, ethereum.NotFound
+	}
+	if !meta.IsSystemTx {
+		return nil, nil
+	}
+	return meta.SystemTx, nil
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -20,6 +20,8 @@ package graphql
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -141,7 +143,7 @@ func (t *Transaction) resolve(ctx context.Context) (*types.Transaction, error) {
 				numberOrHash: &blockNrOrHash,
 			}
 			t.index = index
-		} else {
+		} else if !ethapi.HiddenTx(ctx, t.backend, t.hash) {
 			t.tx = t.backend.GetPoolTransaction(t.hash)
 		}
 	}
@@ -341,6 +343,65 @@ func (t *Transaction) V(ctx context.Context) (hexutil.Big, error) {
 	return hexutil.Big(*v), nil
 }
 
+// resolveSystemTx decodes why the consensus engine injected this transaction
+// into its block, returning nil for user and pending transactions.
+func (t *Transaction) resolveSystemTx(ctx context.Context) (*types.SystemTxInfo, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || t.block == nil {
+		return nil, err
+	}
+	header, err := t.block.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	return ethapi.DescribeSystemTx(t.backend.Engine(), tx, header), nil
+}
+
+func (t *Transaction) IsSystemTx(ctx context.Context) (bool, error) {
+	info, err := t.resolveSystemTx(ctx)
+	return info != nil, err
+}
+
+func (t *Transaction) SystemTx(ctx context.Context) (*SystemTransaction, error) {
+	info, err := t.resolveSystemTx(ctx)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	return &SystemTransaction{info: info}, nil
+}
+
+// SystemTransaction describes why the consensus engine injected a transaction.
+type SystemTransaction struct {
+	info *types.SystemTxInfo
+}
+
+func (s *SystemTransaction) Reason(ctx context.Context) string {
+	return s.info.Reason
+}
+
+func (s *SystemTransaction) Args(ctx context.Context) []*SystemTransactionArg {
+	args := make([]*SystemTransactionArg, 0, len(s.info.Args))
+	for name, value := range s.info.Args {
+		args = append(args, &SystemTransactionArg{name: name, value: fmt.Sprint(value)})
+	}
+	sort.Slice(args, func(i, j int) bool { return args[i].name < args[j].name })
+	return args
+}
+
+// SystemTransactionArg is a decoded argument of a system contract call.
+type SystemTransactionArg struct {
+	name  string
+	value string
+}
+
+func (a *SystemTransactionArg) Name(ctx context.Context) string {
+	return a.name
+}
+
+func (a *SystemTransactionArg) Value(ctx context.Context) string {
+	return a.value
+}
+
 type BlockType int
 
 // Block represents an Ethereum block.
@@ -832,13 +893,29 @@ type Pending struct {
 	backend ethapi.Backend
 }
 
-func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
+// transactions retrieves the pool transactions, leaving the private ones out for
+// the callers connected over the network.
+func (p *Pending) transactions(ctx context.Context) (types.Transactions, error) {
 	txs, err := p.backend.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if !ethapi.HiddenTx(ctx, p.backend, tx.Hash()) {
+			public = append(public, tx)
+		}
+	}
+	return public, nil
+}
+
+func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
+	txs, err := p.transactions(ctx)
 	return int32(len(txs)), err
 }
 
 func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
-	txs, err := p.backend.GetPoolTransactions()
+	txs, err := p.transactions(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -857,7 +934,7 @@ func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
 func (p *Pending) Account(ctx context.Context, args struct {
 	Address common.Address
 }) *Account {
-	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	pendingBlockNr := ethapi.PublicBlockNrOrHash(ctx, p.backend, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
 	return &Account{
 		backend:       p.backend,
 		address:       args.Address,
@@ -118,6 +118,27 @@ const schema string = `
         r: BigInt!
         s: BigInt!
         v: BigInt!
+        # IsSystemTx is true if the consensus engine injected this transaction into
+        # its block, rather than a user sending it.
+        isSystemTx: Boolean!
+        # SystemTx describes why the consensus engine injected this transaction.
+        # This will be null for user transactions.
+        systemTx: SystemTransaction
+    }
+
+    # SystemTransaction describes why the consensus engine injected a transaction
+    # into a block.
+    type SystemTransaction {
+        # Reason is the purpose of the transaction, such as deposit, slash or init.
+        reason: String!
+        # Args are the decoded arguments of the system contract call, sorted by name.
+        args: [SystemTransactionArg!]!
+    }
+
+    # SystemTransactionArg is a decoded argument of a system contract call.
+    type SystemTransactionArg {
+        name: String!
+        value: String!
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -17,6 +17,7 @@
 package graphql
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -96,10 +97,15 @@ func newHandler(backend ethapi.Backend) (http.Handler, error) {
 	}
 	h := &relay.Handler{Schema: s}
 
+	// Mark the queries as remote, like the RPC server does for its HTTP callers,
+	// to keep the private transactions from them
+	remote := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "remote", r.RemoteAddr)))
+	})
 	mux := http.NewServeMux()
 	mux.Handle("/", GraphiQL{})
-	mux.Handle("/graphql", h)
-	mux.Handle("/graphql/", h)
+	mux.Handle("/graphql", remote)
+	mux.Handle("/graphql/", remote)
 	return mux, nil
 }
 
@@ -32,10 +32,12 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -104,12 +106,12 @@ func NewPublicTxPoolAPI(b Backend) *PublicTxPoolAPI {
 }
 
 // Content returns the transactions contained within the transaction pool.
-func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransaction {
+func (s *PublicTxPoolAPI) Content(ctx context.Context) map[string]map[string]map[string]*RPCTransaction {
 	content := map[string]map[string]map[string]*RPCTransaction{
 		"pending": make(map[string]map[string]*RPCTransaction),
 		"queued":  make(map[string]map[string]*RPCTransaction),
 	}
-	pending, queue := s.b.TxPoolContent()
+	pending, queue := s.content(ctx)
 
 	// Flatten the pending transactions
 	for account, txs := range pending {
@@ -130,8 +132,98 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	return content
 }
 
-// Status returns the number of pending and queued transaction in the pool.
-func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
+// content retrieves the content of the transaction pool, leaving the private
+// transactions out for the callers connected over the network.
+func (s *PublicTxPoolAPI) content(ctx context.Context) (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	pending, queue := s.b.TxPoolContent()
+	if ctx.Value("remote") == nil {
+		return pending, queue
+	}
+	for _, content := range []map[common.Address]types.Transactions{pending, queue} {
+		for account, txs := range content {
+			public := make(types.Transactions, 0, len(txs))
+			for _, tx := range txs {
+				if !HiddenTx(ctx, s.b, tx.Hash()) {
+					public = append(public, tx)
+				}
+			}
+			if len(public) == 0 {
+				delete(content, account)
+			} else {
+				content[account] = public
+			}
+		}
+	}
+	return pending, queue
+}
+
+// HiddenTx reports whether the transaction with the given hash is a private one
+// in the pool, to be kept from the callers connected over the network.
+func HiddenTx(ctx context.Context, b Backend, hash common.Hash) bool {
+	return ctx.Value("remote") != nil && b.IsPrivateTx(hash)
+}
+
+// PublicBlock leaves the private transactions out of the pending block for the
+// callers connected over the network.
+func PublicBlock(ctx context.Context, b Backend, block *types.Block) *types.Block {
+	txs := make(types.Transactions, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		if !HiddenTx(ctx, b, tx.Hash()) {
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) == len(block.Transactions()) {
+		return block
+	}
+	return block.WithBody(txs, block.Uncles())
+}
+
+// PublicBlockNrOrHash redirects the pending state lookups of the callers connected
+// over the network to the latest block while the pending one carries private
+// transactions, whose effects would give them away.
+func PublicBlockNrOrHash(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash) rpc.BlockNumberOrHash {
+	if number, ok := blockNrOrHash.Number(); !ok || number != rpc.PendingBlockNumber || ctx.Value("remote") == nil {
+		return blockNrOrHash
+	}
+	block, _ := b.BlockByNumber(ctx, rpc.PendingBlockNumber)
+	if block == nil {
+		return blockNrOrHash
+	}
+	for _, tx := range block.Transactions() {
+		if b.IsPrivateTx(tx.Hash()) {
+			return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		}
+	}
+	return blockNrOrHash
+}
+
+// blockByNumber retrieves the block with the given number, with the private
+// transactions left out of the pending one for the callers connected over the
+// network.
+func blockByNumber(ctx context.Context, b Backend, number rpc.BlockNumber) (*types.Block, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	if block != nil && number == rpc.PendingBlockNumber {
+		block = PublicBlock(ctx, b, block)
+	}
+	return block, err
+}
+
+// stateAndHeader retrieves the state and header of the given block, the pending
+// state lookups of the callers connected over the network going through
+// PublicBlockNrOrHash.
+func stateAndHeader(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	return b.StateAndHeaderByNumberOrHash(ctx, PublicBlockNrOrHash(ctx, b, blockNrOrHash))
+}
+
+// Status returns the number of pending and queued transaction in the pool or, if
+// a transaction hash is given, the latest state of the transaction: queued,
+// pending, replaced, dropped along with the reason, or included in a block.
+// Transactions which left the pool are only remembered for a while.
+func (s *PublicTxPoolAPI) Status(ctx context.Context, hash *common.Hash) interface{} {
+	if hash != nil {
+		// Keep the private transactions from remote callers
+		return s.b.TxPoolTxState(*hash, ctx.Value("remote") == nil)
+	}
 	pending, queue := s.b.Stats()
 	return map[string]hexutil.Uint{
 		"pending": hexutil.Uint(pending),
@@ -139,14 +231,30 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// senderReputation is the track record of a remote sender, along with its score.
+type senderReputation struct {
+	core.SenderReputation
+	Score int64 `json:"score"`
+}
+
+// Reputation returns the track record and score of the remote senders recently
+// active in the transaction pool.
+func (s *PublicTxPoolAPI) Reputation() map[common.Address]*senderReputation {
+	reputation := make(map[common.Address]*senderReputation)
+	for addr, rep := range s.b.TxPoolReputation() {
+		reputation[addr] = &senderReputation{SenderReputation: rep, Score: rep.Score()}
+	}
+	return reputation
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
-func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
+func (s *PublicTxPoolAPI) Inspect(ctx context.Context) map[string]map[string]map[string]string {
 	content := map[string]map[string]map[string]string{
 		"pending": make(map[string]map[string]string),
 		"queued":  make(map[string]map[string]string),
 	}
-	pending, queue := s.b.TxPoolContent()
+	pending, queue := s.content(ctx)
 
 	// Define a formatter to flatten a transaction into a string
 	var format = func(tx *types.Transaction) string {
@@ -527,7 +635,7 @@ func (s *PublicBlockChainAPI) BlockNumber() hexutil.Uint64 {
 // given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
 // block numbers are also allowed.
 func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
-	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, _, err := stateAndHeader(ctx, s.b, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -552,7 +660,7 @@ type StorageResult struct {
 
 // GetProof returns the Merkle-proof for a given account and optionally some storage keys.
 func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error) {
-	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, _, err := stateAndHeader(ctx, s.b, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -633,7 +741,7 @@ func (s *PublicBlockChainAPI) GetHeaderByHash(ctx context.Context, hash common.H
 // * When fullTx is true all transactions in the block are returned, otherwise
 //   only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
-	block, err := s.b.BlockByNumber(ctx, number)
+	block, err := blockByNumber(ctx, s.b, number)
 	if block != nil && err == nil {
 		response, err := s.rpcMarshalBlock(block, true, fullTx)
 		if err == nil && number == rpc.PendingBlockNumber {
@@ -709,7 +817,7 @@ func (s *PublicBlockChainAPI) GetUncleCountByBlockHash(ctx context.Context, bloc
 
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, _, err := stateAndHeader(ctx, s.b, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -721,7 +829,7 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
 func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, _, err := stateAndHeader(ctx, s.b, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -789,15 +897,8 @@ type account struct {
 	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
 }
 
-func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) ([]byte, uint64, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
-
-	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
-	if state == nil || err != nil {
-		return nil, 0, false, err
-	}
-
-	// Override the fields of specified contracts before execution.
+// overrideAccounts overrides the fields of the specified accounts in the state.
+func overrideAccounts(state *state.StateDB, overrides map[common.Address]account) error {
 	for addr, account := range overrides {
 		// Override account nonce.
 		if account.Nonce != nil {
@@ -812,7 +913,7 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 			state.SetBalance(addr, (*big.Int)(*account.Balance))
 		}
 		if account.State != nil && account.StateDiff != nil {
-			return nil, 0, false, fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
 		}
 		// Replace entire state if caller requires.
 		if account.State != nil {
@@ -825,6 +926,21 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 			}
 		}
 	}
+	return nil
+}
+
+func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
+	state, header, err := stateAndHeader(ctx, b, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, 0, false, err
+	}
+
+	// Override the fields of specified contracts before execution.
+	if err := overrideAccounts(state, overrides); err != nil {
+		return nil, 0, false, err
+	}
 
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
@@ -904,7 +1020,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 	}
 	// Recap the highest gas limit with account's available balance.
 	if args.GasPrice != nil && args.GasPrice.ToInt().BitLen() != 0 {
-		state, _, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+		state, _, err := stateAndHeader(ctx, b, blockNrOrHash)
 		if err != nil {
 			return 0, err
 		}
@@ -1109,6 +1225,12 @@ func (s *PublicBlockChainAPI) rpcMarshalBlock(b *types.Block, inclTx bool, fullT
 	if inclTx {
 		fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(b.Hash()))
 	}
+	if inclTx && fullTx {
+		txs := b.Transactions()
+		for i, tx := range fields["transactions"].([]interface{}) {
+			tagSystemTx(s.b.Engine(), tx.(*RPCTransaction), txs[i], b.Header())
+		}
+	}
 	return fields, err
 }
 
@@ -1128,6 +1250,9 @@ type RPCTransaction struct {
 	V                *hexutil.Big    `json:"v"`
 	R                *hexutil.Big    `json:"r"`
 	S                *hexutil.Big    `json:"s"`
+
+	IsSystemTx bool                `json:"isSystemTx"`         // Whether the consensus engine injected the transaction
+	SystemTx   *types.SystemTxInfo `json:"systemTx,omitempty"` // Why the consensus engine injected the transaction
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1161,6 +1286,29 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	return result
 }
 
+// DescribeSystemTx decodes why the consensus engine injected a transaction into
+// the block of the given header, returning nil for user transactions.
+func DescribeSystemTx(engine consensus.Engine, tx *types.Transaction, header *types.Header) *types.SystemTxInfo {
+	posa, ok := engine.(consensus.PoSA)
+	if !ok || header == nil {
+		return nil
+	}
+	info, err := posa.DescribeSystemTransaction(tx, header)
+	if err != nil {
+		log.Debug("Failed to decode system transaction", "hash", tx.Hash(), "err", err)
+		return nil
+	}
+	return info
+}
+
+// tagSystemTx flags an RPC transaction as a system transaction if the consensus
+// engine injected it into the block of the given header.
+func tagSystemTx(engine consensus.Engine, rpcTx *RPCTransaction, tx *types.Transaction, header *types.Header) {
+	if info := DescribeSystemTx(engine, tx, header); info != nil {
+		rpcTx.IsSystemTx, rpcTx.SystemTx = true, info
+	}
+}
+
 // newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
 func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 	return newRPCTransaction(tx, common.Hash{}, 0, 0)
@@ -1208,7 +1356,7 @@ func NewPublicTransactionPoolAPI(b Backend, nonceLock *AddrLocker) *PublicTransa
 
 // GetBlockTransactionCountByNumber returns the number of transactions in the block with the given block number.
 func (s *PublicTransactionPoolAPI) GetBlockTransactionCountByNumber(ctx context.Context, blockNr rpc.BlockNumber) *hexutil.Uint {
-	if block, _ := s.b.BlockByNumber(ctx, blockNr); block != nil {
+	if block, _ := blockByNumber(ctx, s.b, blockNr); block != nil {
 		n := hexutil.Uint(len(block.Transactions()))
 		return &n
 	}
@@ -1224,10 +1372,20 @@ func (s *PublicTransactionPoolAPI) GetBlockTransactionCountByHash(ctx context.Co
 	return nil
 }
 
+// rpcTransactionFromBlockIndex returns the transaction at the given index of a
+// block, flagged as a system transaction if the consensus engine injected it.
+func (s *PublicTransactionPoolAPI) rpcTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransaction {
+	rpcTx := newRPCTransactionFromBlockIndex(b, index)
+	if rpcTx != nil {
+		tagSystemTx(s.b.Engine(), rpcTx, b.Transactions()[index], b.Header())
+	}
+	return rpcTx
+}
+
 // GetTransactionByBlockNumberAndIndex returns the transaction for the given block number and index.
 func (s *PublicTransactionPoolAPI) GetTransactionByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) *RPCTransaction {
-	if block, _ := s.b.BlockByNumber(ctx, blockNr); block != nil {
-		return newRPCTransactionFromBlockIndex(block, uint64(index))
+	if block, _ := blockByNumber(ctx, s.b, blockNr); block != nil {
+		return s.rpcTransactionFromBlockIndex(block, uint64(index))
 	}
 	return nil
 }
@@ -1235,14 +1393,14 @@ func (s *PublicTransactionPoolAPI) GetTransactionByBlockNumberAndIndex(ctx conte
 // GetTransactionByBlockHashAndIndex returns the transaction for the given block hash and index.
 func (s *PublicTransactionPoolAPI) GetTransactionByBlockHashAndIndex(ctx context.Context, blockHash common.Hash, index hexutil.Uint) *RPCTransaction {
 	if block, _ := s.b.BlockByHash(ctx, blockHash); block != nil {
-		return newRPCTransactionFromBlockIndex(block, uint64(index))
+		return s.rpcTransactionFromBlockIndex(block, uint64(index))
 	}
 	return nil
 }
 
 // GetRawTransactionByBlockNumberAndIndex returns the bytes of the transaction for the given block number and index.
 func (s *PublicTransactionPoolAPI) GetRawTransactionByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) hexutil.Bytes {
-	if block, _ := s.b.BlockByNumber(ctx, blockNr); block != nil {
+	if block, _ := blockByNumber(ctx, s.b, blockNr); block != nil {
 		return newRPCRawTransactionFromBlockIndex(block, uint64(index))
 	}
 	return nil
@@ -1267,7 +1425,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr
 		return (*hexutil.Uint64)(&nonce), nil
 	}
 	// Resolve block number and use its state to ask for the nonce
-	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, _, err := stateAndHeader(ctx, s.b, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -1283,10 +1441,14 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 		return nil, err
 	}
 	if tx != nil {
-		return newRPCTransaction(tx, blockHash, blockNumber, index), nil
+		rpcTx := newRPCTransaction(tx, blockHash, blockNumber, index)
+		if header, _ := s.b.HeaderByHash(ctx, blockHash); header != nil {
+			tagSystemTx(s.b.Engine(), rpcTx, tx, header)
+		}
+		return rpcTx, nil
 	}
 	// No finalized transaction, try to retrieve it from the pool
-	if tx := s.b.GetPoolTransaction(hash); tx != nil {
+	if tx := s.b.GetPoolTransaction(hash); tx != nil && !HiddenTx(ctx, s.b, hash) {
 		return newRPCPendingTransaction(tx), nil
 	}
 
@@ -1302,7 +1464,7 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 		return nil, err
 	}
 	if tx == nil {
-		if tx = s.b.GetPoolTransaction(hash); tx == nil {
+		if tx = s.b.GetPoolTransaction(hash); tx == nil || HiddenTx(ctx, s.b, hash) {
 			// Transaction not found anywhere, abort
 			return nil, nil
 		}
@@ -1359,6 +1521,13 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Flag the transactions injected by the consensus engine
+	fields["isSystemTx"] = false
+	if header, _ := s.b.HeaderByHash(ctx, blockHash); header != nil {
+		if info := DescribeSystemTx(s.b.Engine(), tx, header); info != nil {
+			fields["isSystemTx"], fields["systemTx"] = true, info
+		}
+	}
 	return fields, nil
 }
 
@@ -1539,6 +1708,29 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// SendPrivateTransaction will add the signed transaction to the transaction pool
+// without announcing it to the network, so that only this node includes it in
+// the blocks it builds. If maxBlockNumber is given, the transaction is dropped
+// once the chain reaches that block without including it.
+func (s *PublicTransactionPoolAPI) SendPrivateTransaction(ctx context.Context, encodedTx hexutil.Bytes, maxBlockNumber *hexutil.Uint64) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	var maxBlock uint64
+	if maxBlockNumber != nil {
+		maxBlock = uint64(*maxBlockNumber)
+		if head := s.b.CurrentBlock().NumberU64(); maxBlock <= head {
+			return common.Hash{}, fmt.Errorf("max block number %d already reached, head is %d", maxBlock, head)
+		}
+	}
+	if err := s.b.SendPrivateTx(ctx, tx, maxBlock); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted private transaction", "fullhash", tx.Hash().Hex(), "maxBlock", maxBlock)
+	return tx.Hash(), nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccack256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
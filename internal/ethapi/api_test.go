@@ -0,0 +1,182 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testBackend serves a latest and a pending block along with their states, the
+// rest of the Backend is left unimplemented.
+type testBackend struct {
+	Backend
+
+	latest       *types.Block
+	latestState  *state.StateDB
+	pending      *types.Block
+	pendingState *state.StateDB
+	private      map[common.Hash]bool
+}
+
+func newTestBackend(t *testing.T, alloc map[common.Address]*big.Int) *testBackend {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	for addr, balance := range alloc {
+		statedb.AddBalance(addr, balance)
+	}
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(1),
+		GasLimit:   10000000,
+		Time:       1000,
+		Coinbase:   common.HexToAddress("0xc0ffee"),
+	}
+	return &testBackend{
+		latest:       types.NewBlock(header, nil, nil, nil),
+		latestState:  statedb,
+		pendingState: statedb.Copy(),
+		private:      make(map[common.Hash]bool),
+	}
+}
+
+// setPending makes the given transactions the content of the pending block.
+func (b *testBackend) setPending(txs types.Transactions) {
+	header := types.CopyHeader(b.latest.Header())
+	header.Number = new(big.Int).Add(header.Number, common.Big1)
+	header.ParentHash = b.latest.Hash()
+	b.pending = types.NewBlock(header, txs, nil, nil)
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig  { return params.AllEthashProtocolChanges }
+func (b *testBackend) Engine() consensus.Engine          { return ethash.NewFaker() }
+func (b *testBackend) RPCGasCap() *big.Int               { return nil }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int   { return common.Big1 }
+func (b *testBackend) IsPrivateTx(hash common.Hash) bool { return b.private[hash] }
+
+// GetHeader makes the backend the chain context of the EVM.
+func (b *testBackend) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if hash == b.latest.Hash() {
+		return b.latest.Header()
+	}
+	return nil
+}
+
+func (b *testBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header) (*vm.EVM, func() error, error) {
+	vmError := func() error { return nil }
+
+	context := core.NewEVMContext(msg, header, b, nil)
+	return vm.NewEVM(context, state, b.ChainConfig(), vm.Config{}), vmError, nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.PendingBlockNumber {
+		return b.pending, nil
+	}
+	return b.latest, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok && number == rpc.PendingBlockNumber {
+		return b.pendingState.Copy(), b.pending.Header(), nil
+	}
+	return b.latestState.Copy(), b.latest.Header(), nil
+}
+
+// Tests that the private transactions and their effects are kept out of every
+// pending block and state lookup of the callers connected over the network.
+func TestPendingBlockRemote(t *testing.T) {
+	var (
+		key, _     = crypto.GenerateKey()
+		privKey, _ = crypto.GenerateKey()
+		addr       = crypto.PubkeyToAddress(key.PublicKey)
+		privAddr   = crypto.PubkeyToAddress(privKey.PublicKey)
+		signer     = types.HomesteadSigner{}
+		funds      = big.NewInt(params.Ether)
+
+		local  = context.Background()
+		remote = context.WithValue(context.Background(), "remote", "127.0.0.1:30303")
+	)
+	b := newTestBackend(t, map[common.Address]*big.Int{addr: funds, privAddr: funds})
+
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+	privTx, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1000), 21000, big.NewInt(1), nil), signer, privKey)
+	b.setPending(types.Transactions{tx, privTx})
+	b.pendingState.SubBalance(privAddr, big.NewInt(1000+21000))
+	b.private[privTx.Hash()] = true
+
+	chainAPI := NewPublicBlockChainAPI(b)
+	txAPI := NewPublicTransactionPoolAPI(b, nil)
+
+	if n := txAPI.GetBlockTransactionCountByNumber(local, rpc.PendingBlockNumber); n == nil || *n != 2 {
+		t.Errorf("local pending transaction count mismatch: have %v, want 2", n)
+	}
+	if n := txAPI.GetBlockTransactionCountByNumber(remote, rpc.PendingBlockNumber); n == nil || *n != 1 {
+		t.Errorf("remote pending transaction count mismatch: have %v, want 1", n)
+	}
+	if rpcTx := txAPI.GetTransactionByBlockNumberAndIndex(local, rpc.PendingBlockNumber, 1); rpcTx == nil || rpcTx.Hash != privTx.Hash() {
+		t.Errorf("local caller missing the private transaction")
+	}
+	if rpcTx := txAPI.GetTransactionByBlockNumberAndIndex(remote, rpc.PendingBlockNumber, 0); rpcTx == nil || rpcTx.Hash != tx.Hash() {
+		t.Errorf("remote caller missing the public transaction")
+	}
+	if rpcTx := txAPI.GetTransactionByBlockNumberAndIndex(remote, rpc.PendingBlockNumber, 1); rpcTx != nil {
+		t.Errorf("remote caller got the private transaction %x", rpcTx.Hash)
+	}
+	if raw := txAPI.GetRawTransactionByBlockNumberAndIndex(local, rpc.PendingBlockNumber, 1); raw == nil {
+		t.Errorf("local caller missing the raw private transaction")
+	}
+	if raw := txAPI.GetRawTransactionByBlockNumberAndIndex(remote, rpc.PendingBlockNumber, 1); raw != nil {
+		t.Errorf("remote caller got the raw private transaction %x", raw)
+	}
+	block, err := chainAPI.GetBlockByNumber(remote, rpc.PendingBlockNumber, false)
+	if err != nil {
+		t.Fatalf("failed to retrieve pending block: %v", err)
+	}
+	if txs := block["transactions"].([]interface{}); len(txs) != 1 || txs[0] != tx.Hash() {
+		t.Errorf("remote pending block transactions mismatch: have %v, want [%x]", txs, tx.Hash())
+	}
+	// The pending state gives the private transaction away, remote callers are
+	// served the latest one instead
+	pending := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if balance, err := chainAPI.GetBalance(local, privAddr, pending); err != nil || balance.ToInt().Cmp(b.pendingState.GetBalance(privAddr)) != 0 {
+		t.Errorf("local pending balance mismatch: have %v, want %v (err %v)", balance, b.pendingState.GetBalance(privAddr), err)
+	}
+	if balance, err := chainAPI.GetBalance(remote, privAddr, pending); err != nil || balance.ToInt().Cmp(funds) != 0 {
+		t.Errorf("remote pending balance mismatch: have %v, want %v (err %v)", balance, funds, err)
+	}
+	// Without private transactions the pending state is served as is
+	delete(b.private, privTx.Hash())
+	if balance, err := chainAPI.GetBalance(remote, privAddr, pending); err != nil || balance.ToInt().Cmp(b.pendingState.GetBalance(privAddr)) != 0 {
+		t.Errorf("remote public pending balance mismatch: have %v, want %v (err %v)", balance, b.pendingState.GetBalance(privAddr), err)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// callBundleTimeout is the time allowed for executing a whole bundle.
+const callBundleTimeout = 5 * time.Second
+
+// BundleTransaction represents a transaction of a bundle, either signed and RLP
+// encoded in raw, or given by the fields of an unsigned call. Unsigned calls are
+// not checked for their nonce, and if no gas is specified get all the gas left
+// in the block, capped to the RPC gas cap.
+type BundleTransaction struct {
+	CallArgs
+	Raw *hexutil.Bytes `json:"raw"`
+}
+
+// message converts the bundle transaction to the message executed on statedb,
+// returning the hash of the transaction, or of the unsigned one equivalent to
+// the call.
+func (tx *BundleTransaction) message(signer types.Signer, statedb *state.StateDB, gasLeft uint64, gasCap *big.Int) (types.Message, common.Hash, bool, error) {
+	if tx.Raw != nil {
+		signed := new(types.Transaction)
+		if err := rlp.DecodeBytes(*tx.Raw, signed); err != nil {
+			return types.Message{}, common.Hash{}, false, err
+		}
+		msg, err := signed.AsMessage(signer)
+		if err != nil {
+			return types.Message{}, common.Hash{}, false, err
+		}
+		return msg, signed.Hash(), true, nil
+	}
+	args := tx.CallArgs
+	if args.Gas == nil {
+		gas := hexutil.Uint64(gasLeft)
+		args.Gas = &gas
+	}
+	msg := args.ToMessage(gasCap)
+
+	var unsigned *types.Transaction
+	nonce := statedb.GetNonce(msg.From())
+	if msg.To() == nil {
+		unsigned = types.NewContractCreation(nonce, msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data())
+	} else {
+		unsigned = types.NewTransaction(nonce, *msg.To(), msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data())
+	}
+	return msg, unsigned.Hash(), false, nil
+}
+
+// BundleTxResult is the outcome of executing a transaction of a bundle.
+type BundleTxResult struct {
+	TxHash          *common.Hash    `json:"txHash,omitempty"` // Only set for signed transactions
+	From            common.Address  `json:"from"`
+	To              *common.Address `json:"to"`
+	ContractAddress *common.Address `json:"contractAddress,omitempty"`
+	GasUsed         hexutil.Uint64  `json:"gasUsed"`
+	GasPrice        *hexutil.Big    `json:"gasPrice"`
+	Reverted        bool            `json:"reverted"`
+	Return          hexutil.Bytes   `json:"return"` // Return data of the execution, the revert reason if reverted
+	Logs            []*types.Log    `json:"logs"`
+}
+
+// CallBundleResult is the outcome of executing a bundle of transactions.
+type CallBundleResult struct {
+	Results          []*BundleTxResult `json:"results"`
+	GasUsed          hexutil.Uint64    `json:"gasUsed"`
+	GasFees          *hexutil.Big      `json:"gasFees"`
+	Coinbase         common.Address    `json:"coinbase"`
+	CoinbaseDiff     *hexutil.Big      `json:"coinbaseDiff"`
+	StateBlockNumber hexutil.Uint64    `json:"stateBlockNumber"`
+}
+
+// CallBundle executes the given transactions one after the other on the state
+// of the given block, each one seeing the changes made by the ones before it.
+// The transactions run in the context of the given block itself, its number,
+// timestamp and coinbase included, and its gas limit applies to the bundle as a
+// whole.
+//
+// Additionally, the caller can specify a batch of contract for fields overriding.
+//
+// The coinbase balance difference is the change of the coinbase balance made by
+// the bundle, the transactions paying it directly included. Note, with the parlia
+// engine the gas fees are collected by the system contract and distributed to
+// the validators at the end of the block, they are only reported in gasFees.
+func (s *PublicBlockChainAPI) CallBundle(ctx context.Context, txs []BundleTransaction, blockNrOrHash rpc.BlockNumberOrHash, overrides *map[common.Address]account) (*CallBundleResult, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing bundle finished", "txs", len(txs), "runtime", time.Since(start))
+	}(time.Now())
+
+	if len(txs) == 0 {
+		return nil, errors.New("empty bundle")
+	}
+	statedb, header, err := stateAndHeader(ctx, s.b, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	if overrides != nil {
+		if err := overrideAccounts(statedb, *overrides); err != nil {
+			return nil, err
+		}
+	}
+	coinbase, err := s.b.Engine().Author(header)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the context is cancelled when the bundle has been executed, this
+	// makes sure resources are cleaned up.
+	ctx, cancel := context.WithTimeout(ctx, callBundleTimeout)
+	defer cancel()
+
+	var (
+		config  = s.b.ChainConfig()
+		signer  = types.MakeSigner(config, header.Number)
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		balance = new(big.Int).Set(statedb.GetBalance(coinbase))
+		fees    = new(big.Int)
+		result  = &CallBundleResult{
+			Results:          make([]*BundleTxResult, 0, len(txs)),
+			Coinbase:         coinbase,
+			StateBlockNumber: hexutil.Uint64(header.Number.Uint64()),
+		}
+	)
+	for i := range txs {
+		msg, hash, signed, err := txs[i].message(signer, statedb, gp.Gas(), s.b.RPCGasCap())
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		nonce := statedb.GetNonce(msg.From())
+		statedb.Prepare(hash, header.Hash(), i)
+
+		evm, vmError, err := s.b.GetEVM(ctx, msg, statedb, header)
+		if err != nil {
+			return nil, err
+		}
+		// Wait for the context to be done and cancel the evm. Even if the
+		// EVM has finished, cancelling may be done (repeatedly)
+		go func() {
+			<-ctx.Done()
+			evm.Cancel()
+		}()
+		ret, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		// If the timer caused an abort, return an appropriate error message
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", callBundleTimeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		statedb.Finalise(config.IsEIP158(header.Number))
+
+		res := &BundleTxResult{
+			From:     msg.From(),
+			To:       msg.To(),
+			GasUsed:  hexutil.Uint64(gas),
+			GasPrice: (*hexutil.Big)(msg.GasPrice()),
+			Reverted: failed,
+			Return:   ret,
+			Logs:     statedb.GetLogs(hash),
+		}
+		if signed {
+			res.TxHash = &hash
+		}
+		if msg.To() == nil {
+			addr := crypto.CreateAddress(msg.From(), nonce)
+			res.ContractAddress = &addr
+		}
+		if res.Logs == nil {
+			res.Logs = []*types.Log{}
+		}
+		result.Results = append(result.Results, res)
+		result.GasUsed += res.GasUsed
+		fees.Add(fees, new(big.Int).Mul(new(big.Int).SetUint64(gas), msg.GasPrice()))
+	}
+	result.GasFees = (*hexutil.Big)(fees)
+	result.CoinbaseDiff = (*hexutil.Big)(new(big.Int).Sub(statedb.GetBalance(coinbase), balance))
+	return result, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testSwapCode is a contract selecting its method by the first calldata byte:
+// 0x01 approves the caller, 0x02 swaps for an approved caller, logging it and
+// returning 1, and reverts with 0x2a for the others.
+var testSwapCode = common.FromHex(
+	"600035" + "60001a" + "80600114601757" + "600214601d57" + "600080fd" + // dispatch
+		"5b6001335500" + // 0x17: approve
+		"5b3354602d57" + "602a60005260206000fd" + // 0x1d: swap, revert if not approved
+		"5b3360006000a1" + "60016000526020" + "6000f3") // 0x2d: log and return 1
+
+func TestCallBundle(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		token  = common.HexToAddress("0x70ce")
+		signer = types.HomesteadSigner{}
+
+		approve = hexutil.Bytes{0x01}
+		swap    = hexutil.Bytes{0x02}
+		one     = common.LeftPadBytes([]byte{1}, 32)
+		reason  = common.LeftPadBytes([]byte{0x2a}, 32)
+
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	b := newTestBackend(t, map[common.Address]*big.Int{addr: big.NewInt(params.Ether)})
+	b.latestState.SetCode(token, testSwapCode)
+	coinbase := b.latest.Coinbase()
+	api := NewPublicBlockChainAPI(b)
+
+	call := func(data hexutil.Bytes) BundleTransaction {
+		return BundleTransaction{CallArgs: CallArgs{From: &addr, To: &token, Data: &data}}
+	}
+	raw := func(tx *types.Transaction) BundleTransaction {
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		enc, _ := rlp.EncodeToBytes(signed)
+		return BundleTransaction{Raw: (*hexutil.Bytes)(&enc)}
+	}
+
+	// The swap sees the approval made before it in the bundle
+	res, err := api.CallBundle(context.Background(), []BundleTransaction{call(approve), call(swap)}, latest, nil)
+	if err != nil {
+		t.Fatalf("failed to call bundle: %v", err)
+	}
+	if len(res.Results) != 2 {
+		t.Fatalf("result count mismatch: have %d, want 2", len(res.Results))
+	}
+	if swapped := res.Results[1]; swapped.Reverted || !bytes.Equal(swapped.Return, one) || len(swapped.Logs) != 1 {
+		t.Errorf("dependent swap mismatch: reverted %v, return %x, logs %d", swapped.Reverted, swapped.Return, len(swapped.Logs))
+	}
+	if res.GasUsed != res.Results[0].GasUsed+res.Results[1].GasUsed {
+		t.Errorf("bundle gas mismatch: have %d, want %d", res.GasUsed, res.Results[0].GasUsed+res.Results[1].GasUsed)
+	}
+	// Without the approval the swap is reverted, along with its reason
+	res, err = api.CallBundle(context.Background(), []BundleTransaction{call(swap)}, latest, nil)
+	if err != nil {
+		t.Fatalf("failed to call bundle: %v", err)
+	}
+	if reverted := res.Results[0]; !reverted.Reverted || !bytes.Equal(reverted.Return, reason) || len(reverted.Logs) != 0 {
+		t.Errorf("reverted swap mismatch: reverted %v, return %x, logs %d", reverted.Reverted, reverted.Return, len(reverted.Logs))
+	}
+	// The coinbase difference counts both the gas fees and the direct payments
+	tip := big.NewInt(12345)
+	res, err = api.CallBundle(context.Background(), []BundleTransaction{
+		raw(types.NewTransaction(0, token, new(big.Int), 100000, big.NewInt(2), approve)),
+		raw(types.NewTransaction(1, coinbase, tip, 21000, big.NewInt(3), nil)),
+	}, latest, nil)
+	if err != nil {
+		t.Fatalf("failed to call bundle: %v", err)
+	}
+	fees := new(big.Int).Add(
+		new(big.Int).Mul(new(big.Int).SetUint64(uint64(res.Results[0].GasUsed)), big.NewInt(2)),
+		new(big.Int).Mul(new(big.Int).SetUint64(uint64(res.Results[1].GasUsed)), big.NewInt(3)),
+	)
+	if res.GasFees.ToInt().Cmp(fees) != 0 {
+		t.Errorf("gas fees mismatch: have %v, want %v", res.GasFees, fees)
+	}
+	if want := new(big.Int).Add(fees, tip); res.CoinbaseDiff.ToInt().Cmp(want) != 0 {
+		t.Errorf("coinbase difference mismatch: have %v, want %v", res.CoinbaseDiff, want)
+	}
+	if res.Coinbase != coinbase {
+		t.Errorf("coinbase mismatch: have %x, want %x", res.Coinbase, coinbase)
+	}
+	for i, result := range res.Results {
+		if result.TxHash == nil {
+			t.Errorf("transaction %d: missing hash of signed transaction", i)
+		}
+	}
+	// Signed transactions are checked for their nonce
+	if _, err := api.CallBundle(context.Background(), []BundleTransaction{
+		raw(types.NewTransaction(1, token, new(big.Int), 100000, big.NewInt(1), approve)),
+	}, latest, nil); err == nil {
+		t.Errorf("bundle with invalid nonce succeeded")
+	}
+}
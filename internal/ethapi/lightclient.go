@@ -0,0 +1,195 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/lightclient"
+)
+
+// lightClientLengthPrefix is the size of the length fields framing the input of
+// the light client contracts.
+const lightClientLengthPrefix = 32
+
+// LightClientHeaderArgs represents the arguments to verify a Binance Chain header
+// against a trusted consensus state. Either the raw input of the tendermint
+// header validation contract is given, or the consensus state and the header.
+type LightClientHeaderArgs struct {
+	Input          *hexutil.Bytes  `json:"input"`
+	ConsensusState json.RawMessage `json:"consensusState"` // Binary encoding as hex string, or JSON object
+	Header         json.RawMessage `json:"header"`         // Amino binary encoding as hex string, or amino JSON object
+}
+
+// LightClientProofArgs represents the arguments to verify an IAVL merkle proof
+// of a key/value pair. Either the raw input of the IAVL merkle proof validation
+// contract is given, or the parts of the proof.
+type LightClientProofArgs struct {
+	Input     *hexutil.Bytes `json:"input"`
+	StoreName string         `json:"storeName"`
+	Key       hexutil.Bytes  `json:"key"`
+	Value     hexutil.Bytes  `json:"value"` // Empty to prove the absence of the key
+	AppHash   hexutil.Bytes  `json:"appHash"`
+	Proof     hexutil.Bytes  `json:"proof"` // Protobuf encoded merkle proof
+}
+
+// LightClientHeaderResult is the outcome of a header verification. On failure
+// it reports the validation stage the header was rejected at.
+type LightClientHeaderResult struct {
+	Valid               bool                        `json:"valid"`
+	Stage               string                      `json:"stage,omitempty"`
+	Error               string                      `json:"error,omitempty"`
+	ValidatorSetChanged bool                        `json:"validatorSetChanged"`
+	ConsensusState      *lightclient.ConsensusState `json:"consensusState,omitempty"` // Consensus state after the header
+	Output              hexutil.Bytes               `json:"output,omitempty"`         // Output of the contract
+}
+
+// LightClientProofResult is the outcome of a proof verification. On failure it
+// reports the validation stage the proof was rejected at.
+type LightClientProofResult struct {
+	Valid bool   `json:"valid"`
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// PublicLightClientAPI verifies Binance Chain headers and IAVL proofs the way the
+// light client contracts do, without sending a transaction.
+type PublicLightClientAPI struct{}
+
+// NewPublicLightClientAPI creates a new light client verification API.
+func NewPublicLightClientAPI() *PublicLightClientAPI {
+	return &PublicLightClientAPI{}
+}
+
+// VerifyHeader runs a header through the tendermint header validation contract,
+// returning the consensus state the contract would move to.
+func (api *PublicLightClientAPI) VerifyHeader(args LightClientHeaderArgs) (*LightClientHeaderResult, error) {
+	input, err := args.toInput()
+	if err != nil {
+		return nil, err
+	}
+	output, err := vm.TendermintHeaderValidate(input)
+	if err != nil {
+		stage, msg := lightClientFailure(err)
+		return &LightClientHeaderResult{Stage: stage, Error: msg}, nil
+	}
+	// result
+	// | validatorSetChanged | empty      | consensusStateBytesLength |  new consensusState |
+	// | 1 byte              | 23 bytes   | 8 bytes                   |                     |
+	cs, err := lightclient.DecodeConsensusState(output[lightClientLengthPrefix:])
+	if err != nil {
+		return nil, err
+	}
+	return &LightClientHeaderResult{
+		Valid:               true,
+		ValidatorSetChanged: output[0] == 0x01,
+		ConsensusState:      &cs,
+		Output:              output,
+	}, nil
+}
+
+// VerifyProof runs a proof through the IAVL merkle proof validation contract.
+func (api *PublicLightClientAPI) VerifyProof(args LightClientProofArgs) (*LightClientProofResult, error) {
+	input, err := args.toInput()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := vm.IAVLMerkleProofValidate(input); err != nil {
+		stage, msg := lightClientFailure(err)
+		return &LightClientProofResult{Stage: stage, Error: msg}, nil
+	}
+	return &LightClientProofResult{Valid: true}, nil
+}
+
+// toInput assembles the input of the tendermint header validation contract.
+func (args *LightClientHeaderArgs) toInput() ([]byte, error) {
+	if args.Input != nil {
+		if len(args.ConsensusState) > 0 || len(args.Header) > 0 {
+			return nil, errors.New(`both "input" and "consensusState"/"header" specified`)
+		}
+		return *args.Input, nil
+	}
+	if len(args.ConsensusState) == 0 || len(args.Header) == 0 {
+		return nil, errors.New(`missing "consensusState" or "header"`)
+	}
+	// Use the encodings as given, so that the contract gets to reject them
+	var csBytes hexutil.Bytes
+	if err := json.Unmarshal(args.ConsensusState, &csBytes); err != nil {
+		var cs lightclient.ConsensusState
+		if err := json.Unmarshal(args.ConsensusState, &cs); err != nil {
+			return nil, err
+		}
+		if csBytes, err = cs.EncodeConsensusState(); err != nil {
+			return nil, err
+		}
+	}
+	var headerBytes hexutil.Bytes
+	if err := json.Unmarshal(args.Header, &headerBytes); err != nil {
+		header, err := lightclient.DecodeHeaderJSON(args.Header)
+		if err != nil {
+			return nil, err
+		}
+		if headerBytes, err = header.EncodeHeader(); err != nil {
+			return nil, err
+		}
+	}
+	// payload
+	// | consensus state length | consensus state | tendermint header |
+	// | 32 bytes               |                 |                   |
+	payload := make([]byte, lightClientLengthPrefix, lightClientLengthPrefix+len(csBytes)+len(headerBytes))
+	binary.BigEndian.PutUint64(payload[lightClientLengthPrefix-8:], uint64(len(csBytes)))
+	payload = append(payload, csBytes...)
+	payload = append(payload, headerBytes...)
+
+	return lightClientInput(payload), nil
+}
+
+// toInput assembles the input of the IAVL merkle proof validation contract.
+func (args *LightClientProofArgs) toInput() ([]byte, error) {
+	if args.Input != nil {
+		if args.StoreName != "" || len(args.Key) > 0 || len(args.Value) > 0 || len(args.AppHash) > 0 || len(args.Proof) > 0 {
+			return nil, errors.New(`both "input" and the proof parts specified`)
+		}
+		return *args.Input, nil
+	}
+	payload, err := lightclient.EncodeKeyValueMerkleProof(args.StoreName, args.Key, args.Value, args.AppHash, args.Proof)
+	if err != nil {
+		return nil, err
+	}
+	return lightClientInput(payload), nil
+}
+
+// lightClientInput prefixes the payload with its length, framing it the way the
+// light client contracts expect.
+func lightClientInput(payload []byte) []byte {
+	input := make([]byte, lightClientLengthPrefix, lightClientLengthPrefix+len(payload))
+	binary.BigEndian.PutUint64(input[lightClientLengthPrefix-8:], uint64(len(payload)))
+	return append(input, payload...)
+}
+
+// lightClientFailure splits the failure of a light client contract into the
+// validation stage and the reason.
+func lightClientFailure(err error) (string, string) {
+	if lcErr, ok := err.(*vm.LightClientError); ok {
+		return lcErr.Stage, lcErr.Err.Error()
+	}
+	return "", err.Error()
+}
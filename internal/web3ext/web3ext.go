@@ -18,22 +18,24 @@
 package web3ext
 
 var Modules = map[string]string{
-	"accounting": AccountingJs,
-	"admin":      AdminJs,
-	"chequebook": ChequebookJs,
-	"clique":     CliqueJs,
-	"ethash":     EthashJs,
-	"debug":      DebugJs,
-	"eth":        EthJs,
-	"miner":      MinerJs,
-	"net":        NetJs,
-	"personal":   PersonalJs,
-	"rpc":        RpcJs,
-	"shh":        ShhJs,
-	"swarmfs":    SwarmfsJs,
-	"txpool":     TxpoolJs,
-	"les":        LESJs,
-	"lespay":     LESPayJs,
+	"accounting":  AccountingJs,
+	"admin":       AdminJs,
+	"chequebook":  ChequebookJs,
+	"clique":      CliqueJs,
+	"ethash":      EthashJs,
+	"debug":       DebugJs,
+	"eth":         EthJs,
+	"lightclient": LightClientJs,
+	"miner":       MinerJs,
+	"net":         NetJs,
+	"parlia":      ParliaJs,
+	"personal":    PersonalJs,
+	"rpc":         RpcJs,
+	"shh":         ShhJs,
+	"swarmfs":     SwarmfsJs,
+	"txpool":      TxpoolJs,
+	"les":         LESJs,
+	"lespay":      LESPayJs,
 }
 
 const ChequebookJs = `
@@ -118,6 +120,82 @@ web3._extend({
 });
 `
 
+const LightClientJs = `
+web3._extend({
+	property: 'lightclient',
+	methods: [
+		new web3._extend.Method({
+			name: 'verifyHeader',
+			call: 'lightclient_verifyHeader',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'verifyProof',
+			call: 'lightclient_verifyProof',
+			params: 1
+		}),
+	]
+});
+`
+
+const ParliaJs = `
+web3._extend({
+	property: 'parlia',
+	methods: [
+		new web3._extend.Method({
+			name: 'getSnapshot',
+			call: 'parlia_getSnapshot',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSnapshotAtHash',
+			call: 'parlia_getSnapshotAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getValidators',
+			call: 'parlia_getValidators',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorsAtHash',
+			call: 'parlia_getValidatorsAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorStats',
+			call: 'parlia_getValidatorStats',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorSetHistory',
+			call: 'parlia_getValidatorSetHistory',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBackOffSchedule',
+			call: 'parlia_getBackOffSchedule',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'simulateFinalize',
+			call: 'parlia_simulateFinalize',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getDoubleSignEvidences',
+			call: 'parlia_getDoubleSignEvidences',
+			params: 0
+		}),
+	]
+});
+`
+
 const EthashJs = `
 web3._extend({
 	property: 'ethash',
@@ -416,6 +494,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'profileBlock',
+			call: 'debug_profileBlock',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'traceBlockByHash',
 			call: 'debug_traceBlockByHash',
@@ -439,6 +523,11 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'verifyParliaHeader',
+			call: 'debug_verifyParliaHeader',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -544,6 +633,18 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'sendPrivateTransaction',
+			call: 'eth_sendPrivateTransaction',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'callBundle',
+			call: 'eth_callBundle',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -602,6 +703,10 @@ web3._extend({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
 		}),
+		new web3._extend.Method({
+			name: 'txOrdering',
+			call: 'miner_txOrdering'
+		}),
 	],
 	properties: []
 });
@@ -736,7 +841,13 @@ web3._extend({
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'transactionStatus',
+			call: 'txpool_status',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -756,6 +867,10 @@ web3._extend({
 				return status;
 			}
 		}),
+		new web3._extend.Property({
+			name: 'reputation',
+			getter: 'txpool_reputation'
+		}),
 	]
 });
 `
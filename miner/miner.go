@@ -51,6 +51,13 @@ type Config struct {
 	GasPrice  *big.Int       // Minimum gas price for mining a transaction
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 	Noverify  bool           // Disable remote mining solution verification(only useful in ethash).
+
+	Ordering          string           `toml:",omitempty"` // Built-in transaction ordering policy (price or fifo)
+	OrderingBand      *big.Int         `toml:",omitempty"` // Gas price band width within which the fifo ordering commits first seen first
+	OrderingSenderCap int              `toml:",omitempty"` // Maximum number of transactions of a sender per block (0 = unlimited)
+	OrderingAllow     []common.Address `toml:",omitempty"` // Senders and recipients only the transactions of which are committed, if any
+	OrderingDeny      []common.Address `toml:",omitempty"` // Senders and recipients the transactions of which are never committed
+	OrderingPolicy    TxOrderingPolicy `toml:"-"`          // Custom transaction ordering policy, replacing the built-in one
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -201,6 +208,19 @@ func (miner *Miner) SetEtherbase(addr common.Address) {
 	miner.worker.setEtherbase(addr)
 }
 
+// SetValidatorRotation sets a callback choosing the signer of every new block
+// based on its parent, overriding the etherbase. The callback is expected to
+// authorize the consensus engine with the returned signer.
+func (miner *Miner) SetValidatorRotation(rotate func(parent *types.Header) (common.Address, error)) {
+	miner.worker.setValidatorRotation(rotate)
+}
+
+// TxOrdering returns the description of the policy ordering the transactions of
+// the blocks being built.
+func (miner *Miner) TxOrdering() string {
+	return miner.worker.ordering.Name()
+}
+
 // SubscribePendingLogs starts delivering logs from pending transactions
 // to the given channel.
 func (self *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
@@ -0,0 +1,333 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"container/heap"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// fifoSeenLimit is the number of transactions the fifo ordering remembers the
+// arrival time of.
+const fifoSeenLimit = 131072
+
+var orderingExcludedMeter = metrics.NewRegisteredMeter("miner/ordering/excluded", nil)
+
+// TxIterator yields the transactions to commit to a block one by one, keeping
+// the ones of every sender in nonce order. It is implemented by
+// types.TransactionsByPriceAndNonce.
+type TxIterator interface {
+	Peek() *types.Transaction // Next transaction to commit, nil if none left
+	Shift()                   // Replaces the next transaction with the next one of its sender
+	Pop()                     // Drops the next transaction and the remaining ones of its sender
+}
+
+// TxOrderingPolicy decides which of the pending transactions are committed to
+// the blocks being built, and in which order. The worker orders the local and
+// the remote transactions separately, committing the local ones first.
+type TxOrderingPolicy interface {
+	// Name describes the policy and its parameters.
+	Name() string
+
+	// Order returns an iterator over the given executable transactions, grouped
+	// by sender and nonce sorted. The map is reowned by the policy.
+	Order(signer types.Signer, txs map[common.Address]types.Transactions) TxIterator
+}
+
+// TxObserver is implemented by the ordering policies depending on the arrival of
+// the transactions, the worker notifying them of every new batch.
+type TxObserver interface {
+	ObserveTxs(txs []*types.Transaction)
+}
+
+// NewTxOrdering creates the transaction ordering policy configured: the custom
+// or built-in ordering, capped and filtered as configured.
+func NewTxOrdering(config *Config) (TxOrderingPolicy, error) {
+	policy := config.OrderingPolicy
+	if policy == nil {
+		switch config.Ordering {
+		case "", "price":
+			policy = NewPriceOrdering()
+		case "fifo":
+			policy = NewFIFOOrdering(config.OrderingBand)
+		default:
+			return nil, fmt.Errorf("unknown transaction ordering %q", config.Ordering)
+		}
+	}
+	if config.OrderingSenderCap > 0 {
+		policy = NewSenderCap(config.OrderingSenderCap, policy)
+	}
+	if len(config.OrderingAllow) > 0 || len(config.OrderingDeny) > 0 {
+		policy = NewAddressFilter(config.OrderingAllow, config.OrderingDeny, policy)
+	}
+	return policy, nil
+}
+
+// priceOrdering is the default policy, committing the transactions with the
+// highest gas price first.
+type priceOrdering struct{}
+
+// NewPriceOrdering creates a policy committing the transactions with the highest
+// gas price first.
+func NewPriceOrdering() TxOrderingPolicy {
+	return priceOrdering{}
+}
+
+func (priceOrdering) Name() string { return "price" }
+
+func (priceOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxIterator {
+	return types.NewTransactionsByPriceAndNonce(signer, txs)
+}
+
+// fifoOrdering is a policy committing the transactions in the highest gas price
+// band first, and the ones in the same band in the order they arrived.
+type fifoOrdering struct {
+	band *big.Int   // Width of the gas price bands, nil for every price its own band
+	seen *lru.Cache // Arrival time of the transactions by hash
+}
+
+// NewFIFOOrdering creates a policy committing the transactions in the highest
+// gas price band first, and the ones in the same band first seen first.
+func NewFIFOOrdering(band *big.Int) TxOrderingPolicy {
+	if band != nil && band.Sign() <= 0 {
+		band = nil
+	}
+	seen, _ := lru.New(fifoSeenLimit)
+	return &fifoOrdering{band: band, seen: seen}
+}
+
+func (p *fifoOrdering) Name() string {
+	if p.band == nil {
+		return "fifo"
+	}
+	return fmt.Sprintf("fifo(band=%v)", p.band)
+}
+
+// ObserveTxs records the arrival of new transactions.
+func (p *fifoOrdering) ObserveTxs(txs []*types.Transaction) {
+	now := time.Now()
+	for _, tx := range txs {
+		p.seen.ContainsOrAdd(tx.Hash(), now)
+	}
+}
+
+// arrival returns the time a transaction was first seen, taken to be now if it
+// arrived unobserved.
+func (p *fifoOrdering) arrival(tx *types.Transaction) time.Time {
+	if seen, ok := p.seen.Get(tx.Hash()); ok {
+		return seen.(time.Time)
+	}
+	now := time.Now()
+	p.seen.Add(tx.Hash(), now)
+	return now
+}
+
+func (p *fifoOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxIterator {
+	it := &fifoTxs{policy: p, signer: signer, txs: txs}
+	for from, accTxs := range txs {
+		// Ensure the sender address is from the signer
+		acc, _ := types.Sender(signer, accTxs[0])
+		it.heads = append(it.heads, it.head(accTxs[0]))
+		txs[acc] = accTxs[1:]
+		if from != acc {
+			delete(txs, from)
+		}
+	}
+	heap.Init(&it.heads)
+	return it
+}
+
+// fifoTx is the next transaction of a sender, with its ordering keys.
+type fifoTx struct {
+	tx   *types.Transaction
+	band *big.Int
+	seen time.Time
+}
+
+// fifoHeads is a heap of the next transactions of every sender, highest price
+// band and earliest arrival first.
+type fifoHeads []fifoTx
+
+func (s fifoHeads) Len() int { return len(s) }
+func (s fifoHeads) Less(i, j int) bool {
+	if cmp := s[i].band.Cmp(s[j].band); cmp != 0 {
+		return cmp > 0
+	}
+	return s[i].seen.Before(s[j].seen)
+}
+func (s fifoHeads) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *fifoHeads) Push(x interface{}) {
+	*s = append(*s, x.(fifoTx))
+}
+
+func (s *fifoHeads) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	*s = old[0 : n-1]
+	return x
+}
+
+// fifoTxs iterates over transactions in the order of a fifoOrdering.
+type fifoTxs struct {
+	policy *fifoOrdering
+	signer types.Signer
+	txs    map[common.Address]types.Transactions // Per account nonce-sorted list of the remaining transactions
+	heads  fifoHeads                             // Next transaction for each unique account
+}
+
+func (it *fifoTxs) head(tx *types.Transaction) fifoTx {
+	band := tx.GasPrice()
+	if it.policy.band != nil {
+		band = new(big.Int).Div(band, it.policy.band)
+	}
+	return fifoTx{tx: tx, band: band, seen: it.policy.arrival(tx)}
+}
+
+func (it *fifoTxs) Peek() *types.Transaction {
+	if len(it.heads) == 0 {
+		return nil
+	}
+	return it.heads[0].tx
+}
+
+func (it *fifoTxs) Shift() {
+	acc, _ := types.Sender(it.signer, it.heads[0].tx)
+	if txs, ok := it.txs[acc]; ok && len(txs) > 0 {
+		it.heads[0], it.txs[acc] = it.head(txs[0]), txs[1:]
+		heap.Fix(&it.heads, 0)
+	} else {
+		heap.Pop(&it.heads)
+	}
+}
+
+func (it *fifoTxs) Pop() {
+	heap.Pop(&it.heads)
+}
+
+// senderCap is a policy limiting the number of transactions of every sender in
+// a block, ordering the remaining ones with another policy.
+type senderCap struct {
+	limit int
+	next  TxOrderingPolicy
+}
+
+// NewSenderCap creates a policy committing at most limit transactions of every
+// sender to a block, ordered by next.
+func NewSenderCap(limit int, next TxOrderingPolicy) TxOrderingPolicy {
+	return &senderCap{limit: limit, next: next}
+}
+
+func (p *senderCap) Name() string {
+	return fmt.Sprintf("%s, sendercap(%d)", p.next.Name(), p.limit)
+}
+
+func (p *senderCap) ObserveTxs(txs []*types.Transaction) {
+	if observer, ok := p.next.(TxObserver); ok {
+		observer.ObserveTxs(txs)
+	}
+}
+
+func (p *senderCap) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxIterator {
+	for addr, list := range txs {
+		if len(list) > p.limit {
+			orderingExcludedMeter.Mark(int64(len(list) - p.limit))
+			txs[addr] = list[:p.limit]
+		}
+	}
+	return p.next.Order(signer, txs)
+}
+
+// addressFilter is a policy committing only the transactions of allowed senders
+// or to allowed recipients, if any are, and never the ones of denied senders or
+// to denied recipients, ordering the admitted ones with another policy.
+type addressFilter struct {
+	allow map[common.Address]struct{}
+	deny  map[common.Address]struct{}
+	next  TxOrderingPolicy
+}
+
+// NewAddressFilter creates a policy committing only the transactions sent by or
+// to the allowed addresses, if any, and none sent by or to the denied ones,
+// ordered by next. As transactions are committed in nonce order, the ones of a
+// sender after a filtered out one are filtered out too.
+func NewAddressFilter(allow, deny []common.Address, next TxOrderingPolicy) TxOrderingPolicy {
+	p := &addressFilter{
+		allow: make(map[common.Address]struct{}),
+		deny:  make(map[common.Address]struct{}),
+		next:  next,
+	}
+	for _, addr := range allow {
+		p.allow[addr] = struct{}{}
+	}
+	for _, addr := range deny {
+		p.deny[addr] = struct{}{}
+	}
+	return p
+}
+
+func (p *addressFilter) Name() string {
+	return fmt.Sprintf("%s, allow(%d), deny(%d)", p.next.Name(), len(p.allow), len(p.deny))
+}
+
+func (p *addressFilter) ObserveTxs(txs []*types.Transaction) {
+	if observer, ok := p.next.(TxObserver); ok {
+		observer.ObserveTxs(txs)
+	}
+}
+
+// admits reports whether a transaction of the given sender may be committed.
+func (p *addressFilter) admits(from common.Address, tx *types.Transaction) bool {
+	_, deniedFrom := p.deny[from]
+	_, allowedFrom := p.allow[from]
+
+	var deniedTo, allowedTo bool
+	if to := tx.To(); to != nil {
+		_, deniedTo = p.deny[*to]
+		_, allowedTo = p.allow[*to]
+	}
+	if deniedFrom || deniedTo {
+		return false
+	}
+	return len(p.allow) == 0 || allowedFrom || allowedTo
+}
+
+func (p *addressFilter) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxIterator {
+	for addr, list := range txs {
+		for i, tx := range list {
+			if !p.admits(addr, tx) {
+				orderingExcludedMeter.Mark(int64(len(list) - i))
+				list = list[:i]
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(txs, addr)
+		} else {
+			txs[addr] = list
+		}
+	}
+	return p.next.Order(signer, txs)
+}
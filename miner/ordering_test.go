@@ -0,0 +1,104 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the transaction ordering policies commit the transactions in the
+// configured order, nonce sorted per sender, capping and filtering them.
+func TestTxOrdering(t *testing.T) {
+	var (
+		signer = types.HomesteadSigner{}
+		keys   = make([]*ecdsa.PrivateKey, 3)
+		addrs  = make([]common.Address, len(keys))
+		target = common.HexToAddress("0x7a4e")
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	// Sender 0 pays 21 gwei, sender 1 20 gwei then 15 and sender 2 25 gwei, the
+	// second transaction of sender 1 being sent to the target
+	sign := func(key int, nonce uint64, to common.Address, price int64) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, new(big.Int), 21000, big.NewInt(price*1e9), nil), signer, keys[key])
+		return tx
+	}
+	var (
+		tx0  = sign(0, 0, common.Address{}, 21)
+		tx1a = sign(1, 0, common.Address{}, 20)
+		tx1b = sign(1, 1, target, 15)
+		tx2  = sign(2, 0, common.Address{}, 25)
+	)
+	pending := func() map[common.Address]types.Transactions {
+		return map[common.Address]types.Transactions{
+			addrs[0]: {tx0},
+			addrs[1]: {tx1a, tx1b},
+			addrs[2]: {tx2},
+		}
+	}
+	collect := func(policy TxOrderingPolicy) []*types.Transaction {
+		var txs []*types.Transaction
+		it := policy.Order(signer, pending())
+		for tx := it.Peek(); tx != nil; tx = it.Peek() {
+			txs = append(txs, tx)
+			it.Shift()
+		}
+		return txs
+	}
+	// The fifo ordering commits the transactions in the 20 gwei band first seen
+	// first, then the ones below
+	fifo := NewFIFOOrdering(big.NewInt(10e9))
+	for _, tx := range []*types.Transaction{tx2, tx1a, tx1b, tx0} {
+		fifo.(TxObserver).ObserveTxs([]*types.Transaction{tx})
+		time.Sleep(time.Millisecond)
+	}
+	tests := []struct {
+		policy TxOrderingPolicy
+		want   []*types.Transaction
+	}{
+		{NewPriceOrdering(), []*types.Transaction{tx2, tx0, tx1a, tx1b}},
+		{fifo, []*types.Transaction{tx2, tx1a, tx0, tx1b}},
+		{NewSenderCap(1, NewPriceOrdering()), []*types.Transaction{tx2, tx0, tx1a}},
+		{NewAddressFilter(nil, []common.Address{addrs[0], target}, NewPriceOrdering()), []*types.Transaction{tx2, tx1a}},
+		{NewAddressFilter([]common.Address{addrs[2], target}, nil, NewPriceOrdering()), []*types.Transaction{tx2}},
+	}
+	for i, tt := range tests {
+		have := collect(tt.policy)
+		if len(have) != len(tt.want) {
+			t.Errorf("test %d (%s): transaction count mismatch: have %d, want %d", i, tt.policy.Name(), len(have), len(tt.want))
+			continue
+		}
+		for j := range have {
+			if have[j].Hash() != tt.want[j].Hash() {
+				t.Errorf("test %d (%s): transaction %d mismatch: have %x, want %x", i, tt.policy.Name(), j, have[j].Hash(), tt.want[j].Hash())
+			}
+		}
+	}
+	// Unknown built-in orderings are rejected
+	if _, err := NewTxOrdering(&Config{Ordering: "random"}); err == nil {
+		t.Errorf("unknown ordering accepted")
+	}
+}
@@ -94,6 +94,8 @@ type environment struct {
 	tcount    int            // tx count in cycle
 	gasPool   *core.GasPool  // available gas used to pack transactions
 
+	senders map[common.Address]int // tx count in cycle per sender, for the sender cap
+
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
@@ -161,9 +163,12 @@ type worker struct {
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
 
-	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
+	mu       sync.RWMutex // The lock used to protect the coinbase, extra and rotation fields
 	coinbase common.Address
 	extra    []byte
+	rotate   func(parent *types.Header) (common.Address, error) // Chooses the signer of every new block, if set
+
+	ordering TxOrderingPolicy // Policy ordering the transactions committed to blocks
 
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
@@ -216,6 +221,14 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	worker.chainHeadSub = eth.BlockChain().SubscribeChainHeadEvent(worker.chainHeadCh)
 	worker.chainSideSub = eth.BlockChain().SubscribeChainSideEvent(worker.chainSideCh)
 
+	// Fall back to the default ordering if the configured one is invalid.
+	ordering, err := NewTxOrdering(config)
+	if err != nil {
+		log.Error("Invalid transaction ordering, ordering by price", "err", err)
+		ordering = NewPriceOrdering()
+	}
+	worker.ordering = ordering
+
 	// Sanitize recommit interval if the user-specified one is too short.
 	recommit := worker.config.Recommit
 	if recommit < minRecommitInterval {
@@ -242,6 +255,13 @@ func (w *worker) setEtherbase(addr common.Address) {
 	w.coinbase = addr
 }
 
+// setValidatorRotation sets the callback choosing the signer of every new block.
+func (w *worker) setValidatorRotation(rotate func(parent *types.Header) (common.Address, error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rotate = rotate
+}
+
 // setExtra sets the content used to initialize the block extra field.
 func (w *worker) setExtra(extra []byte) {
 	w.mu.Lock()
@@ -466,6 +486,9 @@ func (w *worker) mainLoop() {
 			}
 
 		case ev := <-w.txsCh:
+			if observer, ok := w.ordering.(TxObserver); ok {
+				observer.ObserveTxs(ev.Txs)
+			}
 			// Apply transactions to the pending state if we're not mining.
 			//
 			// Note all transactions received may not be continuous with transactions
@@ -485,7 +508,8 @@ func (w *worker) mainLoop() {
 					acc, _ := types.Sender(w.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs)
+				w.capSenders(txs)
+				txset := w.ordering.Order(w.current.signer, txs)
 				tcount := w.current.tcount
 				w.commitTransactions(txset, coinbase, nil)
 				// Only update the snapshot if any new transactons were added
@@ -642,6 +666,7 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
 		header:    header,
+		senders:   make(map[common.Address]int),
 	}
 
 	// when 08 is processed ancestors contain 07 (quick block)
@@ -725,7 +750,32 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
+// capSenders trims the transactions arriving while a block is being built to
+// the number the sender cap still leaves their senders in it. The ordering
+// policy caps every batch it orders on its own, not the block as a whole.
+func (w *worker) capSenders(txs map[common.Address]types.Transactions) {
+	limit := w.config.OrderingSenderCap
+	if limit <= 0 {
+		return
+	}
+	for addr, list := range txs {
+		left := limit - w.current.senders[addr]
+		if left < 0 {
+			left = 0
+		}
+		if len(list) > left {
+			orderingExcludedMeter.Mark(int64(len(list) - left))
+			list = list[:left]
+		}
+		if len(list) == 0 {
+			delete(txs, addr)
+		} else {
+			txs[addr] = list
+		}
+	}
+}
+
+func (w *worker) commitTransactions(txs TxIterator, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
 		return true
@@ -806,6 +856,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			w.current.tcount++
+			w.current.senders[from]++
 			txs.Shift()
 
 		default:
@@ -866,12 +917,21 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		Time:       uint64(timestamp),
 	}
 	// Only set the coinbase if our consensus engine is running (avoid spurious block rewards)
+	coinbase := w.coinbase
 	if w.isRunning() {
-		if w.coinbase == (common.Address{}) {
+		if w.rotate != nil {
+			signer, err := w.rotate(parent.Header())
+			if err != nil {
+				log.Error("Failed to choose the block signer", "err", err)
+				return
+			}
+			coinbase = signer
+		}
+		if coinbase == (common.Address{}) {
 			log.Error("Refusing to mine without etherbase")
 			return
 		}
-		header.Coinbase = w.coinbase
+		header.Coinbase = coinbase
 	}
 	if err := w.engine.Prepare(w.chain, header); err != nil {
 		log.Error("Failed to prepare header for mining", "err", err)
@@ -954,14 +1014,14 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 	}
 	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs)
-		if w.commitTransactions(txs, w.coinbase, interrupt) {
+		txs := w.ordering.Order(w.current.signer, localTxs)
+		if w.commitTransactions(txs, coinbase, interrupt) {
 			return
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs)
-		if w.commitTransactions(txs, w.coinbase, interrupt) {
+		txs := w.ordering.Order(w.current.signer, remoteTxs)
+		if w.commitTransactions(txs, coinbase, interrupt) {
 			return
 		}
 	}
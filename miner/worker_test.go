@@ -503,3 +503,42 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+// Tests that the sender cap counts the transactions committed to the block being
+// built, not only the ones arriving in a batch.
+func TestCapSenders(t *testing.T) {
+	var (
+		full  = common.Address{0x01}
+		some  = common.Address{0x02}
+		fresh = common.Address{0x03}
+	)
+	w := &worker{
+		config:  &Config{OrderingSenderCap: 2},
+		current: &environment{senders: map[common.Address]int{full: 2, some: 1}},
+	}
+	batch := func() types.Transactions {
+		return types.Transactions{
+			types.NewTransaction(0, testUserAddress, big.NewInt(1), params.TxGas, nil, nil),
+			types.NewTransaction(1, testUserAddress, big.NewInt(1), params.TxGas, nil, nil),
+			types.NewTransaction(2, testUserAddress, big.NewInt(1), params.TxGas, nil, nil),
+		}
+	}
+	txs := map[common.Address]types.Transactions{full: batch(), some: batch(), fresh: batch()}
+	w.capSenders(txs)
+
+	if _, ok := txs[full]; ok {
+		t.Errorf("sender at the cap left with %d transactions", len(txs[full]))
+	}
+	if len(txs[some]) != 1 || txs[some][0].Nonce() != 0 {
+		t.Errorf("sender below the cap left with %d transactions, want the first one", len(txs[some]))
+	}
+	if len(txs[fresh]) != 2 {
+		t.Errorf("new sender left with %d transactions, want 2", len(txs[fresh]))
+	}
+	// Without a cap, nothing is trimmed
+	w.config = &Config{}
+	txs = map[common.Address]types.Transactions{full: batch()}
+	if w.capSenders(txs); len(txs[full]) != 3 {
+		t.Errorf("uncapped sender left with %d transactions, want 3", len(txs[full]))
+	}
+}
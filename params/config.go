@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -256,16 +257,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, "", new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, "", nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, new(EthashConfig), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, "", new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -340,6 +341,19 @@ type ChainConfig struct {
 	RamanujanBlock      *big.Int `json:"ramanujanBlock,omitempty" toml:",omitempty"`      // ramanujanBlock switch block (nil = no fork, 0 = already activated)
 	NielsBlock          *big.Int `json:"nielsBlock,omitempty" toml:",omitempty"`          // nielsBlock switch block (nil = no fork, 0 = already activated)
 
+	// BSCForks schedules the BSC hard forks listed in BSCForkTable by name, for
+	// the forks without a dedicated block field (nil entry = no fork).
+	BSCForks map[string]*big.Int `json:"bscForks,omitempty" toml:",omitempty"`
+
+	// SystemContractUpgrades schedules system contract upgrades at the named BSC
+	// forks, applied after the built-in upgrades of the fork.
+	SystemContractUpgrades []*SystemContractUpgrade `json:"systemContractUpgrades,omitempty" toml:",omitempty"`
+
+	// SystemContractUpgradesFile is the path of a JSON or TOML manifest scheduling
+	// more system contract upgrades, moved into SystemContractUpgrades when the
+	// genesis is set up.
+	SystemContractUpgradesFile string `json:"systemContractUpgradesFile,omitempty" toml:",omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty" toml:",omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty" toml:",omitempty"`
@@ -376,6 +390,95 @@ func (b *ParliaConfig) String() string {
 	return "parlia"
 }
 
+// SystemContractUpgrade is a named set of system contract upgrades applied at a
+// BSC fork.
+type SystemContractUpgrade struct {
+	Name    string                         `json:"name"`
+	Fork    string                         `json:"fork"` // Name of the fork the upgrade is applied at, e.g. "niels"
+	Configs []*SystemContractUpgradeConfig `json:"configs"`
+}
+
+// SystemContractUpgradeConfig is the upgrade of a single system contract.
+type SystemContractUpgradeConfig struct {
+	ContractAddr common.Address `json:"contractAddr"`
+	CommitUrl    string         `json:"commitUrl,omitempty"`
+	CodeHash     common.Hash    `json:"codeHash"` // Keccak256 of the new bytecode
+	Code         hexutil.Bytes  `json:"code"`
+}
+
+// BSC hard fork names, as used in BSCForkTable and the chain configuration.
+const (
+	RamanujanFork      = "ramanujan"
+	NielsFork          = "niels"
+	LightClientGasFork = "lightClientGas" // Light client precompiles priced by validator set size and proof depth
+)
+
+// BSCFork is a Binance Smart Chain hard fork scheduled by block number.
+type BSCFork struct {
+	Name     string // Name of the fork, as used in the chain config and system contract upgrades
+	AnyOrder bool   // Whether the fork may activate before the forks preceding it
+
+	field func(c *ChainConfig) *big.Int // Dedicated chain config field of the fork, if any
+}
+
+// BSCForkTable lists the BSC hard forks in activation order. Adding a fork only
+// requires appending it here: its block is then read from ChainConfig.BSCForks
+// and checked for ordering, compatibility, fork ID and system contract upgrades.
+var BSCForkTable = []*BSCFork{
+	{Name: RamanujanFork, field: func(c *ChainConfig) *big.Int { return c.RamanujanBlock }},
+	{Name: NielsFork, AnyOrder: true, field: func(c *ChainConfig) *big.Int { return c.NielsBlock }},
+	{Name: LightClientGasFork},
+}
+
+// BSCForkByName returns the BSC fork with the given name, nil if unknown.
+func BSCForkByName(name string) *BSCFork {
+	for _, fork := range BSCForkTable {
+		if fork.Name == name {
+			return fork
+		}
+	}
+	return nil
+}
+
+// Ethereum hard fork names, for referring to them alongside the BSC forks.
+const (
+	HomesteadFork      = "homestead"
+	EIP150Fork         = "eip150"
+	EIP155Fork         = "eip155"
+	EIP158Fork         = "eip158"
+	ByzantiumFork      = "byzantium"
+	ConstantinopleFork = "constantinople"
+	PetersburgFork     = "petersburg"
+	IstanbulFork       = "istanbul"
+	MuirGlacierFork    = "muirGlacier"
+)
+
+// ethForkTable maps the Ethereum hard forks to their chain config fields.
+var ethForkTable = map[string]func(c *ChainConfig) *big.Int{
+	HomesteadFork:      func(c *ChainConfig) *big.Int { return c.HomesteadBlock },
+	EIP150Fork:         func(c *ChainConfig) *big.Int { return c.EIP150Block },
+	EIP155Fork:         func(c *ChainConfig) *big.Int { return c.EIP155Block },
+	EIP158Fork:         func(c *ChainConfig) *big.Int { return c.EIP158Block },
+	ByzantiumFork:      func(c *ChainConfig) *big.Int { return c.ByzantiumBlock },
+	ConstantinopleFork: func(c *ChainConfig) *big.Int { return c.ConstantinopleBlock },
+	PetersburgFork: func(c *ChainConfig) *big.Int {
+		if c.PetersburgBlock == nil {
+			return c.ConstantinopleBlock
+		}
+		return c.PetersburgBlock
+	},
+	IstanbulFork:    func(c *ChainConfig) *big.Int { return c.IstanbulBlock },
+	MuirGlacierFork: func(c *ChainConfig) *big.Int { return c.MuirGlacierBlock },
+}
+
+// IsKnownFork returns whether name is either an Ethereum or a BSC hard fork.
+func IsKnownFork(name string) bool {
+	if _, ok := ethForkTable[name]; ok {
+		return true
+	}
+	return BSCForkByName(name) != nil
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -389,7 +492,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Ramanujan: %v, Niels: %v, Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Ramanujan: %v, Niels: %v, BSC forks: %v, Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -404,6 +507,7 @@ func (c *ChainConfig) String() string {
 		c.MuirGlacierBlock,
 		c.RamanujanBlock,
 		c.NielsBlock,
+		c.BSCForks,
 		engine,
 	)
 }
@@ -445,22 +549,68 @@ func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
 
 // IsRamanujan returns whether num is either equal to the IsRamanujan fork block or greater.
 func (c *ChainConfig) IsRamanujan(num *big.Int) bool {
-	return isForked(c.RamanujanBlock, num)
+	return c.IsBSCFork(RamanujanFork, num)
 }
 
 // IsOnRamanujan returns whether num is equal to the Ramanujan fork block
 func (c *ChainConfig) IsOnRamanujan(num *big.Int) bool {
-	return configNumEqual(c.RamanujanBlock, num)
+	return c.IsOnBSCFork(RamanujanFork, num)
 }
 
 // IsNiels returns whether num is either equal to the Niels fork block or greater.
 func (c *ChainConfig) IsNiels(num *big.Int) bool {
-	return isForked(c.NielsBlock, num)
+	return c.IsBSCFork(NielsFork, num)
 }
 
 // IsOnNiels returns whether num is equal to the IsNiels fork block
 func (c *ChainConfig) IsOnNiels(num *big.Int) bool {
-	return configNumEqual(c.NielsBlock, num)
+	return c.IsOnBSCFork(NielsFork, num)
+}
+
+// IsLightClientGas returns whether num is either equal to the light client gas
+// fork block or greater.
+func (c *ChainConfig) IsLightClientGas(num *big.Int) bool {
+	return c.IsBSCFork(LightClientGasFork, num)
+}
+
+// BSCForkBlock returns the block number the named BSC fork is scheduled at, nil
+// if it is not scheduled or unknown.
+func (c *ChainConfig) BSCForkBlock(name string) *big.Int {
+	fork := BSCForkByName(name)
+	if fork == nil {
+		return nil
+	}
+	if fork.field != nil {
+		if block := fork.field(c); block != nil {
+			return block
+		}
+	}
+	return c.BSCForks[fork.Name]
+}
+
+// IsBSCFork returns whether num is either equal to the named BSC fork block or greater.
+func (c *ChainConfig) IsBSCFork(name string, num *big.Int) bool {
+	return isForked(c.BSCForkBlock(name), num)
+}
+
+// ForkBlock returns the block number the named Ethereum or BSC hard fork is
+// scheduled at, nil if it is not scheduled or unknown.
+func (c *ChainConfig) ForkBlock(name string) *big.Int {
+	if field, ok := ethForkTable[name]; ok {
+		return field(c)
+	}
+	return c.BSCForkBlock(name)
+}
+
+// IsFork returns whether num is either equal to the named Ethereum or BSC fork
+// block or greater.
+func (c *ChainConfig) IsFork(name string, num *big.Int) bool {
+	return isForked(c.ForkBlock(name), num)
+}
+
+// IsOnBSCFork returns whether num is equal to the named BSC fork block.
+func (c *ChainConfig) IsOnBSCFork(name string, num *big.Int) bool {
+	return configNumEqual(c.BSCForkBlock(name), num)
 }
 
 // IsMuirGlacier returns whether num is either equal to the Muir Glacier (EIP-2384) fork block or greater.
@@ -504,14 +654,23 @@ func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *Confi
 }
 
 // CheckConfigForkOrder checks that we don't "skip" any forks, geth isn't pluggable enough
-// to guarantee that forks can be implemented in a different order than on official networks
+// to guarantee that forks can be implemented in a different order than on official networks.
+// The system contract upgrades scheduled at the forks are checked too.
 func (c *ChainConfig) CheckConfigForkOrder() error {
 	type fork struct {
 		name  string
 		block *big.Int
 	}
-	var lastFork fork
-	for _, cur := range []fork{
+	for name, block := range c.BSCForks {
+		bscFork := BSCForkByName(name)
+		if bscFork == nil {
+			return fmt.Errorf("unsupported fork: %v", name)
+		}
+		if bscFork.field != nil && bscFork.field(c) != nil && !configNumEqual(bscFork.field(c), block) {
+			return fmt.Errorf("conflicting fork blocks: %v scheduled at both %v and %v", name, bscFork.field(c), block)
+		}
+	}
+	forks := []fork{
 		{"homesteadBlock", c.HomesteadBlock},
 		{"eip150Block", c.EIP150Block},
 		{"eip155Block", c.EIP155Block},
@@ -521,8 +680,14 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 		{"petersburgBlock", c.PetersburgBlock},
 		{"istanbulBlock", c.IstanbulBlock},
 		{"muirGlacierBlock", c.MuirGlacierBlock},
-		{"ramanujanBlock", c.RamanujanBlock},
-	} {
+	}
+	for _, bscFork := range BSCForkTable {
+		if !bscFork.AnyOrder {
+			forks = append(forks, fork{bscFork.Name, c.BSCForkBlock(bscFork.Name)})
+		}
+	}
+	var lastFork fork
+	for _, cur := range forks {
 		if lastFork.name != "" {
 			// Next one must be higher number
 			if lastFork.block == nil && cur.block != nil {
@@ -538,6 +703,28 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 		}
 		lastFork = cur
 	}
+	return c.checkSystemContractUpgrades()
+}
+
+// checkSystemContractUpgrades checks that the system contract upgrades are
+// scheduled at known forks, and the integrity of every contract bytecode.
+func (c *ChainConfig) checkSystemContractUpgrades() error {
+	for _, upgrade := range c.SystemContractUpgrades {
+		if BSCForkByName(upgrade.Fork) == nil {
+			return fmt.Errorf("system contract upgrade %q: unknown fork %q", upgrade.Name, upgrade.Fork)
+		}
+		if len(upgrade.Configs) == 0 {
+			return fmt.Errorf("system contract upgrade %q: no contracts", upgrade.Name)
+		}
+		for _, cfg := range upgrade.Configs {
+			if len(cfg.Code) == 0 {
+				return fmt.Errorf("system contract upgrade %q: contract %s: empty code", upgrade.Name, cfg.ContractAddr.Hex())
+			}
+			if hash := crypto.Keccak256Hash(cfg.Code); hash != cfg.CodeHash {
+				return fmt.Errorf("system contract upgrade %q: contract %s: code hash mismatch: have %x, want %x", upgrade.Name, cfg.ContractAddr.Hex(), hash, cfg.CodeHash)
+			}
+		}
+	}
 	return nil
 }
 
@@ -581,12 +768,53 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
-	if isForkIncompatible(c.RamanujanBlock, newcfg.RamanujanBlock, head) {
-		return newCompatError("ramanujan fork block", c.RamanujanBlock, newcfg.RamanujanBlock)
+	for _, fork := range BSCForkTable {
+		if isForkIncompatible(c.BSCForkBlock(fork.Name), newcfg.BSCForkBlock(fork.Name), head) {
+			return newCompatError(fork.Name+" fork block", c.BSCForkBlock(fork.Name), newcfg.BSCForkBlock(fork.Name))
+		}
+		if isUpgradeIncompatible(c, newcfg, fork.Name, head) {
+			return newCompatError(fork.Name+" system contract upgrades", c.BSCForkBlock(fork.Name), newcfg.BSCForkBlock(fork.Name))
+		}
 	}
 	return nil
 }
 
+// isUpgradeIncompatible returns true if the system contract upgrades scheduled at
+// the given fork differ between the two configs while the fork is already past
+// in either of them.
+func isUpgradeIncompatible(c1, c2 *ChainConfig, fork string, head *big.Int) bool {
+	if !isForked(c1.BSCForkBlock(fork), head) && !isForked(c2.BSCForkBlock(fork), head) {
+		return false
+	}
+	u1, u2 := c1.systemContractUpgradesAt(fork), c2.systemContractUpgradesAt(fork)
+	if len(u1) != len(u2) {
+		return true
+	}
+	for i := range u1 {
+		if u1[i].Name != u2[i].Name || len(u1[i].Configs) != len(u2[i].Configs) {
+			return true
+		}
+		for j, cfg := range u1[i].Configs {
+			if other := u2[i].Configs[j]; cfg.ContractAddr != other.ContractAddr || cfg.CodeHash != other.CodeHash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// systemContractUpgradesAt returns the system contract upgrades scheduled at the
+// given fork, in the order they are applied.
+func (c *ChainConfig) systemContractUpgradesAt(fork string) []*SystemContractUpgrade {
+	var upgrades []*SystemContractUpgrade
+	for _, upgrade := range c.SystemContractUpgrades {
+		if upgrade.Fork == fork {
+			upgrades = append(upgrades, upgrade)
+		}
+	}
+	return upgrades
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
@@ -17,9 +17,14 @@
 package params
 
 import (
+	"encoding/json"
+	"fmt"
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -59,6 +64,23 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     0,
 			},
 		},
+		{
+			stored: &ChainConfig{NielsBlock: big.NewInt(10)},
+			new:    &ChainConfig{BSCForks: map[string]*big.Int{NielsFork: big.NewInt(20)}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "niels fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{RamanujanBlock: big.NewInt(10)},
+			new:     &ChainConfig{BSCForks: map[string]*big.Int{RamanujanFork: big.NewInt(10)}},
+			head:    15,
+			wantErr: nil,
+		},
 		{
 			stored: &ChainConfig{HomesteadBlock: big.NewInt(30), EIP150Block: big.NewInt(10)},
 			new:    &ChainConfig{HomesteadBlock: big.NewInt(25), EIP150Block: big.NewInt(20)},
@@ -79,3 +101,117 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckConfigForkOrderBSC(t *testing.T) {
+	// newConfig creates a config with all Ethereum forks enabled at genesis except
+	// Muir Glacier, which is enabled at the given block.
+	newConfig := func(muirGlacier int64, ramanujan, niels *big.Int, forks map[string]*big.Int) *ChainConfig {
+		return &ChainConfig{
+			HomesteadBlock:      big.NewInt(0),
+			EIP150Block:         big.NewInt(0),
+			EIP155Block:         big.NewInt(0),
+			EIP158Block:         big.NewInt(0),
+			ByzantiumBlock:      big.NewInt(0),
+			ConstantinopleBlock: big.NewInt(0),
+			PetersburgBlock:     big.NewInt(0),
+			IstanbulBlock:       big.NewInt(0),
+			MuirGlacierBlock:    big.NewInt(muirGlacier),
+			RamanujanBlock:      ramanujan,
+			NielsBlock:          niels,
+			BSCForks:            forks,
+		}
+	}
+	tests := []struct {
+		config  *ChainConfig
+		wantErr bool
+	}{
+		{newConfig(0, big.NewInt(10), big.NewInt(0), nil), false},
+		{newConfig(0, nil, nil, map[string]*big.Int{RamanujanFork: big.NewInt(10)}), false},
+		{newConfig(20, big.NewInt(10), nil, nil), true},
+		{newConfig(20, nil, nil, map[string]*big.Int{RamanujanFork: big.NewInt(10)}), true},
+		{newConfig(0, nil, nil, map[string]*big.Int{"unknown": big.NewInt(10)}), true},
+		{newConfig(0, nil, big.NewInt(5), map[string]*big.Int{NielsFork: big.NewInt(10)}), true},
+	}
+	for i, test := range tests {
+		if err := test.config.CheckConfigForkOrder(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestCheckSystemContractUpgrades(t *testing.T) {
+	code := []byte{0x60, 0x80, 0x60, 0x40}
+	hash := crypto.Keccak256Hash(code)
+
+	upgrade := `{"systemContractUpgrades": [{"name": "custom", "fork": "%s", "configs": [
+		{"contractAddr": "0x0000000000000000000000000000000000001000", "codeHash": "%s", "code": "%s"}
+	]}]}`
+	tests := []struct {
+		config  string
+		wantErr bool
+	}{
+		{fmt.Sprintf(upgrade, NielsFork, hash.Hex(), "0x60806040"), false},
+		{fmt.Sprintf(upgrade, LightClientGasFork, hash.Hex(), "0x60806040"), false},
+		{fmt.Sprintf(upgrade, "shanghai", hash.Hex(), "0x60806040"), true},
+		{fmt.Sprintf(upgrade, NielsFork, common.Hash{0x01}.Hex(), "0x60806040"), true},
+		{fmt.Sprintf(upgrade, NielsFork, hash.Hex(), "0x"), true},
+		{`{"systemContractUpgrades": [{"name": "custom", "fork": "niels", "configs": []}]}`, true},
+	}
+	for i, test := range tests {
+		config := new(ChainConfig)
+		if err := json.Unmarshal([]byte(test.config), config); err != nil {
+			t.Fatalf("test %d: failed to decode config: %v", i, err)
+		}
+		if err := config.CheckConfigForkOrder(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestCheckCompatibleSystemContractUpgrades(t *testing.T) {
+	upgrade := func(name string, hash common.Hash) *SystemContractUpgrade {
+		return &SystemContractUpgrade{Name: name, Fork: NielsFork, Configs: []*SystemContractUpgradeConfig{
+			{ContractAddr: common.HexToAddress("0x1000"), CodeHash: hash},
+		}}
+	}
+	config := func(upgrades ...*SystemContractUpgrade) *ChainConfig {
+		return &ChainConfig{NielsBlock: big.NewInt(10), SystemContractUpgrades: upgrades}
+	}
+	tests := []struct {
+		stored, new *ChainConfig
+		head        uint64
+		wantErr     bool
+	}{
+		{config(upgrade("a", common.Hash{1})), config(upgrade("a", common.Hash{1})), 20, false},
+		{config(upgrade("a", common.Hash{1})), config(upgrade("a", common.Hash{2})), 9, false},
+		{config(upgrade("a", common.Hash{1})), config(upgrade("a", common.Hash{2})), 10, true},
+		{config(upgrade("a", common.Hash{1})), config(upgrade("b", common.Hash{1})), 10, true},
+		{config(upgrade("a", common.Hash{1})), config(), 10, true},
+		{config(), config(upgrade("a", common.Hash{1})), 10, true},
+		{config(upgrade("a", common.Hash{1})), config(upgrade("a", common.Hash{1}), upgrade("b", common.Hash{2})), 10, true},
+	}
+	for i, test := range tests {
+		err := test.stored.CheckCompatible(test.new, test.head)
+		if (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+			continue
+		}
+		if err != nil && err.RewindTo != 9 {
+			t.Errorf("test %d: rewind mismatch: have %d, want 9", i, err.RewindTo)
+		}
+	}
+}
+
+func TestBSCForkBlock(t *testing.T) {
+	config := &ChainConfig{RamanujanBlock: big.NewInt(10), BSCForks: map[string]*big.Int{NielsFork: big.NewInt(20)}}
+
+	if !config.IsRamanujan(big.NewInt(10)) || config.IsRamanujan(big.NewInt(9)) {
+		t.Errorf("ramanujan activation mismatch")
+	}
+	if !config.IsOnNiels(big.NewInt(20)) || config.IsNiels(big.NewInt(19)) {
+		t.Errorf("niels activation mismatch")
+	}
+	if config.BSCForkBlock("unknown") != nil {
+		t.Errorf("unknown fork reported as scheduled")
+	}
+}
@@ -118,6 +118,11 @@ const (
 	TendermintHeaderValidateGas uint64 = 3000 // Gas for validate tendermiint consensus state
 	IAVLMerkleProofValidateGas  uint64 = 3000 // Gas for validate merkle proof
 
+	TendermintHeaderValidateValidatorGas uint64 = 2000 // Per trusted validator price for validating a tendermint header, as of the light client gas fork
+	TendermintHeaderValidateWordGas      uint64 = 12   // Per word price of the input for validating a tendermint header, as of the light client gas fork
+	IAVLMerkleProofValidateOpGas         uint64 = 1500 // Per proof operation price for validating a merkle proof, as of the light client gas fork
+	IAVLMerkleProofValidateWordGas       uint64 = 12   // Per word price of the input for validating a merkle proof, as of the light client gas fork
+
 	EcrecoverGas        uint64 = 3000 // Elliptic curve sender recovery gas price
 	Sha256BaseGas       uint64 = 60   // Base price for a SHA256 operation
 	Sha256PerWordGas    uint64 = 12   // Per-word price for a SHA256 operation
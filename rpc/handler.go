@@ -72,6 +72,10 @@ type callProc struct {
 }
 
 func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry) *handler {
+	// Expose the peer address of network connections like the HTTP server does
+	if remote := conn.remoteAddr(); remote != "" && connCtx.Value("remote") == nil {
+		connCtx = context.WithValue(connCtx, "remote", remote)
+	}
 	rootCtx, cancelRoot := context.WithCancel(connCtx)
 	h := &handler{
 		reg:            reg,
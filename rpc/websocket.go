@@ -170,5 +170,7 @@ func wsClientHeaders(endpoint, origin string) (string, http.Header, error) {
 
 func newWebsocketCodec(conn *websocket.Conn) ServerCodec {
 	conn.SetReadLimit(maxRequestContentLength)
-	return NewFuncCodec(conn, conn.WriteJSON, conn.ReadJSON)
+	codec := NewFuncCodec(conn, conn.WriteJSON, conn.ReadJSON).(*jsonCodec)
+	codec.remote = conn.RemoteAddr().String()
+	return codec
 }
@@ -0,0 +1,100 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package lightclient
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/lightclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// chainIDLength is the length of the chain ID field of an encoded consensus state,
+// the only field that doesn't necessarily survive a decode-encode round.
+const chainIDLength = 32
+
+// FuzzConsensusState checks that any consensus state accepted by the strict
+// decoder can be encoded again.
+func FuzzConsensusState(input []byte) int {
+	cs, err := lightclient.DecodeConsensusStateStrict(input)
+	if err != nil {
+		return 0
+	}
+	output, err := cs.EncodeConsensusState()
+	if err != nil {
+		panic(fmt.Sprintf("decoded consensus state not encodable: %v", err))
+	}
+	if !bytes.Equal(input[chainIDLength:], output[chainIDLength:]) {
+		panic(fmt.Sprintf("decode-encode is not equal, \ninput : %x\noutput: %x", input, output))
+	}
+	return 1
+}
+
+// FuzzHeader checks that any header accepted by the strict decoder can be
+// validated without panicking.
+func FuzzHeader(input []byte) int {
+	header, err := lightclient.DecodeHeaderStrict(input)
+	if err != nil {
+		return 0
+	}
+	header.ValidatorSet.TotalVotingPower()
+	header.NextValidatorSet.TotalVotingPower()
+	header.Validate(header.ChainID)
+	return 1
+}
+
+// FuzzKeyValueMerkleProof checks that any merkle proof accepted by the strict
+// decoder can be validated without panicking.
+func FuzzKeyValueMerkleProof(input []byte) int {
+	kvmp, err := lightclient.DecodeKeyValueMerkleProofStrict(input)
+	if err != nil {
+		return 0
+	}
+	kvmp.Validate()
+	return 1
+}
+
+// lightClientGasConfig is a chain configuration with the light client gas fork
+// active from genesis.
+var lightClientGasConfig = func() *params.ChainConfig {
+	config := *params.AllEthashProtocolChanges
+	config.BSCForks = map[string]*big.Int{params.LightClientGasFork: big.NewInt(0)}
+	return &config
+}()
+
+// FuzzPrecompiles runs the input through the light client precompiles as of
+// the light client gas fork. Their pricing and the validation run outside of
+// the EVM don't recover from panics, unlike the contracts themselves, so any
+// panic in the strict decoding or the validation surfaces here.
+func FuzzPrecompiles(input []byte) int {
+	precompiles := vm.ActivePrecompiles(lightClientGasConfig, new(big.Int))
+	for _, addr := range []common.Address{common.BytesToAddress([]byte{100}), common.BytesToAddress([]byte{101})} {
+		precompiles[addr].RequiredGas(input)
+	}
+	vm.TendermintHeaderValidate(input)
+	vm.IAVLMerkleProofValidate(input)
+	return 0
+}
+
+// Fuzz is the entry point of go-fuzz, feeding the input to all the targets.
+func Fuzz(input []byte) int {
+	return FuzzConsensusState(input) + FuzzHeader(input) + FuzzKeyValueMerkleProof(input) + FuzzPrecompiles(input)
+}